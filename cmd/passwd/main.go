@@ -0,0 +1,27 @@
+// Command passwd prints the argon2id hash of a password for the login users
+// of the config, the password is read from the first line of stdin.
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+
+	"demo/extension/password"
+)
+
+func main() {
+	line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && line == "" {
+		fmt.Fprintln(os.Stderr, "passwd: read password:", err)
+		os.Exit(1)
+	}
+
+	hash, err := password.Hash(strings.TrimRight(line, "\r\n"))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "passwd:", err)
+		os.Exit(1)
+	}
+	fmt.Println(hash)
+}
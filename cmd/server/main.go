@@ -2,6 +2,7 @@ package main
 
 import (
 	"context"
+	"time"
 
 	"go.uber.org/fx"
 
@@ -11,6 +12,8 @@ import (
 func main() {
 	app := fx.New(
 		fx.Provide(context.TODO),
+		// bounds the graceful shutdown, see config.Shutdown
+		fx.StopTimeout(time.Minute),
 		remote.Module,
 	)
 	app.Run()
@@ -0,0 +1,20 @@
+package config
+
+// Admin represents the admin listener, which serves the metrics, the health
+// checks, pprof and the runtime configuration apart from the api. If it is
+// disabled the api server serves the health checks, and the other endpoints
+// under /admin only if the http token is configured.
+type Admin struct {
+	Enable bool   `mapstructure:"enable" default:"false"`
+	Host   string `mapstructure:"host" default:"127.0.0.1"`
+	Port   int    `mapstructure:"port" default:"8089" validate:"required_if=Enable true,omitempty,min=1,max=65535"`
+
+	// Token is the static bearer token of the admin endpoints, the basic
+	// authentication is accepted as well if Username is set. The endpoints
+	// except the health checks are not authenticated if neither is configured.
+	Token    string `mapstructure:"token"`
+	Username string `mapstructure:"username"`
+	Password string `mapstructure:"password" validate:"required_with=Username"`
+
+	Pprof bool `mapstructure:"pprof" default:"true"`
+}
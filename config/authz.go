@@ -0,0 +1,22 @@
+package config
+
+// Authz represents the permissions of the roles and the permissions required
+// by the api routes.
+type Authz struct {
+	// Roles maps the roles to their permissions, for example
+	// admin: ["*"] or viewer: ["orders:read"]. The roles are matched
+	// case-insensitively, since the keys are lowercased when loaded.
+	Roles map[string][]string `mapstructure:"roles"`
+
+	// Routes declares the permissions of the routes in addition to the ones
+	// declared in code.
+	Routes []AuthzRoute `mapstructure:"routes" validate:"dive"`
+}
+
+// AuthzRoute requires all the permissions for the route, the path is the full
+// route pattern such as /api/v1/orders/:id.
+type AuthzRoute struct {
+	Method      string   `mapstructure:"method" validate:"required"`
+	Path        string   `mapstructure:"path" validate:"required"`
+	Permissions []string `mapstructure:"permissions" validate:"required,min=1"`
+}
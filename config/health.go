@@ -0,0 +1,13 @@
+package config
+
+import "time"
+
+// Health represents the health checks of the liveness and readiness probes.
+type Health struct {
+	Timeout time.Duration `mapstructure:"timeout" default:"1s" validate:"gt=0"`
+
+	// DiskPaths are checked for DiskMinFreeMB available megabytes, the
+	// directory of the file session store is checked if it is used.
+	DiskPaths     []string `mapstructure:"disk_paths"`
+	DiskMinFreeMB uint64   `mapstructure:"disk_min_free_mb" default:"100"`
+}
@@ -1,6 +1,10 @@
 package config
 
-// HTTPServer represents the configuration of the http server.
+import "time"
+
+// HTTPServer represents the configuration of the http server. Domain are the
+// hosts served, with or without port, "*.example.com" matches the subdomains.
+// The other hosts are responded with 421, any host is served if it is empty.
 type HTTPServer struct {
 	Host      string   `mapstructure:"host" default:"127.0.0.1"`
 	Port      int      `mapstructure:"port" default:"8000"`
@@ -13,4 +17,43 @@ type HTTPServer struct {
 	// this server, you should set this flag to true, otherwise, the real ip of
 	// the client may not be able to get.
 	ReverseProxy bool `mapstructure:"reverse_proxy" default:"false"`
+	// TrustedProxies are the IPs and CIDRs of the reverse proxies, only their
+	// X-Forwarded-For, Forwarded and X-Forwarded-Proto/Host headers are
+	// trusted. The loopback addresses are trusted if it is empty, and the peer
+	// of the unix socket is always trusted if Network is unix.
+	TrustedProxies []string `mapstructure:"trusted_proxies" validate:"dive,cidr|ip"`
+
+	// Network is tcp or unix, the server listens on Socket instead of the host
+	// and port if it is unix.
+	Network string `mapstructure:"network" default:"tcp" validate:"oneof=tcp unix"`
+	Socket  string `mapstructure:"socket" validate:"required_if=Network unix"`
+
+	ReadTimeout       time.Duration `mapstructure:"read_timeout" default:"30s" validate:"gte=0"`
+	ReadHeaderTimeout time.Duration `mapstructure:"read_header_timeout" default:"10s" validate:"gte=0"`
+	WriteTimeout      time.Duration `mapstructure:"write_timeout" default:"60s" validate:"gte=0"`
+	IdleTimeout       time.Duration `mapstructure:"idle_timeout" default:"120s" validate:"gte=0"`
+	MaxHeaderBytes    int           `mapstructure:"max_header_bytes" default:"1048576" validate:"gte=0"`
+
+	// HTTP2 is served over TLS if it is enabled, H2C serves HTTP/2 without
+	// TLS for the internal traffic.
+	HTTP2 bool `mapstructure:"http2" default:"true"`
+	H2C   bool `mapstructure:"h2c" default:"false"`
+
+	TLS HTTPTLS `mapstructure:"tls"`
+}
+
+// HTTPTLS represents the TLS of the http server, the files are reloaded when
+// they change.
+type HTTPTLS struct {
+	Enable   bool   `mapstructure:"enable" default:"false"`
+	CertFile string `mapstructure:"cert_file" validate:"required_if=Enable true"`
+	KeyFile  string `mapstructure:"key_file" validate:"required_if=Enable true"`
+
+	// ClientCAFile enables the mutual TLS, the client certificates are
+	// verified by ClientAuth.
+	ClientCAFile string `mapstructure:"client_ca_file"`
+	ClientAuth   string `mapstructure:"client_auth" default:"require_and_verify" validate:"oneof=none request require verify_if_given require_and_verify"`
+
+	MinVersion     string        `mapstructure:"min_version" default:"1.2" validate:"oneof=1.2 1.3"`
+	ReloadInterval time.Duration `mapstructure:"reload_interval" default:"30s" validate:"gt=0"`
 }
@@ -0,0 +1,38 @@
+package config
+
+import "time"
+
+// JWT represents the bearer token authentication of the api routes.
+type JWT struct {
+	Enable bool `mapstructure:"enable" default:"false"`
+
+	// Algorithms are the accepted signing algorithms.
+	Algorithms []string `mapstructure:"algorithms" default:"[RS256,ES256]" validate:"dive,oneof=HS256 RS256 ES256"`
+
+	// Secret is the HS256 shared secret.
+	Secret string `mapstructure:"secret"`
+	// JWKSFiles are local JWKS files, replacing a file rotates its keys.
+	JWKSFiles []string `mapstructure:"jwks_files"`
+	// JWKSURLs are fetched and cached, the keys are fetched again after the
+	// refresh interval or when a token is signed by an unknown key.
+	JWKSURLs        []string      `mapstructure:"jwks_urls" validate:"dive,url"`
+	RefreshInterval time.Duration `mapstructure:"refresh_interval" default:"1h"`
+
+	Issuer         string        `mapstructure:"issuer"`
+	Audience       string        `mapstructure:"audience"`
+	Leeway         time.Duration `mapstructure:"leeway" default:"1m"`
+	RequireExpires bool          `mapstructure:"require_expires" default:"true"`
+
+	// PublicPaths are the path prefixes which can be requested without login.
+	PublicPaths []string `mapstructure:"public_paths" default:"[/api/v1/auth/]"`
+
+	Claims JWTClaims `mapstructure:"claims"`
+}
+
+// JWTClaims names the claims mapped into the principal.
+type JWTClaims struct {
+	UserId string `mapstructure:"user_id" default:"sub"`
+	Tenant string `mapstructure:"tenant" default:"tenant"`
+	Roles  string `mapstructure:"roles" default:"roles"`
+	Scopes string `mapstructure:"scopes" default:"scope"`
+}
@@ -0,0 +1,73 @@
+package config
+
+import "time"
+
+// Log represents the configuration of the logger.
+type Log struct {
+	Level     string `mapstructure:"level" default:"info" validate:"oneof=debug info warn error"`
+	Format    string `mapstructure:"format" default:"json" validate:"oneof=json text console"`
+	Output    string `mapstructure:"output" default:"stdout" validate:"oneof=stdout stderr file"`
+	File      string `mapstructure:"file" validate:"required_if=Output file"`
+	AddSource bool   `mapstructure:"add_source" default:"false"`
+
+	Rotate   LogRotate   `mapstructure:"rotate"`
+	Async    LogAsync    `mapstructure:"async"`
+	Redact   LogRedact   `mapstructure:"redact"`
+	Sampling LogSampling `mapstructure:"sampling"`
+}
+
+// LogRotate represents the rotation of the log file, it only takes effect if
+// the output is file. Zero values disable the corresponding rule.
+type LogRotate struct {
+	MaxSizeMB  int           `mapstructure:"max_size_mb" default:"100"`
+	Interval   time.Duration `mapstructure:"interval" default:"24h"`
+	MaxBackups int           `mapstructure:"max_backups" default:"7"`
+	MaxAge     time.Duration `mapstructure:"max_age" default:"168h"`
+	Compress   bool          `mapstructure:"compress" default:"false"`
+}
+
+// LogAsync represents the asynchronous buffered writing of the logs. When the
+// queue is full the records are dropped instead of blocking the caller.
+type LogAsync struct {
+	Enable        bool          `mapstructure:"enable" default:"false"`
+	QueueSize     int           `mapstructure:"queue_size" default:"4096"`
+	FlushInterval time.Duration `mapstructure:"flush_interval" default:"1s"`
+}
+
+// LogRedact represents the masking of sensitive data in the request logs.
+type LogRedact struct {
+	// Headers are the names of the headers whose values are masked.
+	Headers []string `mapstructure:"headers" default:"[Authorization,Cookie,Set-Cookie,Proxy-Authorization,X-Api-Key]"`
+
+	// Fields are the JSON body fields to mask, a name without dots matches at
+	// any depth, a dotted path such as "user.id_card" matches from the root.
+	// The names match the query parameters and the form fields as well, code
+	// and state mask the callback of the OAuth2 login.
+	Fields []string `mapstructure:"fields" default:"[password,token,access_token,refresh_token,secret,code,state]"`
+
+	// Patterns are regular expressions whose matches are masked, for example
+	// phone numbers.
+	Patterns []string `mapstructure:"patterns"`
+
+	Mask string `mapstructure:"mask" default:"******"`
+}
+
+// LogSampling represents the sampling of the logs, in every tick the first
+// records of a key are logged and then every thereafter-th record. Records of
+// error level and requests failed with 5xx are always logged.
+type LogSampling struct {
+	Enable     bool          `mapstructure:"enable" default:"false"`
+	Tick       time.Duration `mapstructure:"tick" default:"1s"`
+	First      uint64        `mapstructure:"first" default:"100"`
+	Thereafter uint64        `mapstructure:"thereafter" default:"100"`
+
+	// Routes overrides the sampling of the request logs of some routes.
+	Routes []RouteSampling `mapstructure:"routes"`
+}
+
+// RouteSampling represents the sampling of the request logs of a route.
+type RouteSampling struct {
+	Route      string `mapstructure:"route" validate:"required"` // e.g. /api/v1/hello
+	First      uint64 `mapstructure:"first"`
+	Thereafter uint64 `mapstructure:"thereafter"`
+}
@@ -0,0 +1,73 @@
+package config
+
+import "time"
+
+// Metrics represents the prometheus metrics.
+type Metrics struct {
+	HTTP MetricsHTTP `mapstructure:"http"`
+	Push MetricsPush `mapstructure:"push"`
+	SLO  MetricsSLO  `mapstructure:"slo"`
+}
+
+// MetricsHTTP represents the histograms of the http metrics, the empty
+// buckets use the defaults of the metrics package.
+type MetricsHTTP struct {
+	DurationBuckets     []float64 `mapstructure:"duration_buckets" validate:"dive,gt=0"`
+	RequestSizeBuckets  []float64 `mapstructure:"request_size_buckets" validate:"dive,gt=0"`
+	ResponseSizeBuckets []float64 `mapstructure:"response_size_buckets" validate:"dive,gt=0"`
+
+	Native NativeHistogram `mapstructure:"native"`
+}
+
+// NativeHistogram represents the prometheus native histograms, which are
+// exposed in addition to the classic buckets. Prometheus scrapes them only if
+// the native-histograms feature is enabled.
+type NativeHistogram struct {
+	Enable           bool          `mapstructure:"enable" default:"false"`
+	BucketFactor     float64       `mapstructure:"bucket_factor" default:"1.1" validate:"gt=1"`
+	MaxBuckets       uint32        `mapstructure:"max_buckets" default:"160"`
+	MinResetDuration time.Duration `mapstructure:"min_reset_duration" default:"1h"`
+}
+
+// MetricsPush represents the push of the metrics to a push gateway, for the
+// instances which can not be scraped.
+type MetricsPush struct {
+	Enable bool   `mapstructure:"enable" default:"false"`
+	URL    string `mapstructure:"url" validate:"required_if=Enable true,omitempty,url"`
+	// Job defaults to the name of the application.
+	Job string `mapstructure:"job"`
+	// Grouping are the labels of the group, instance defaults to the hostname.
+	Grouping map[string]string `mapstructure:"grouping"`
+	Interval time.Duration     `mapstructure:"interval" default:"15s"`
+	Timeout  time.Duration     `mapstructure:"timeout" default:"10s"`
+
+	Username string `mapstructure:"username"`
+	Password string `mapstructure:"password"`
+
+	MaxRetries int           `mapstructure:"max_retries" default:"3"`
+	Backoff    time.Duration `mapstructure:"backoff" default:"1s"`
+}
+
+// MetricsSLO represents the service level objectives, their burn rates are
+// exposed over the rolling windows. The empty windows use the defaults of the
+// metrics package.
+type MetricsSLO struct {
+	Windows    []time.Duration `mapstructure:"windows" validate:"dive,gt=0"`
+	Resolution time.Duration   `mapstructure:"resolution" default:"10s" validate:"gt=0"`
+	Objectives []SLOObjective  `mapstructure:"objectives" validate:"dive"`
+}
+
+// SLOObjective represents an objective of the requests matching the method
+// and the route, the empty values match any. The route is the gin route such
+// as /api/v1/orders/:id, or the full method of a grpc call with method grpc.
+type SLOObjective struct {
+	Name   string `mapstructure:"name" validate:"required"`
+	Method string `mapstructure:"method"`
+	Route  string `mapstructure:"route"`
+
+	// Availability is the target ratio of the requests without system fault.
+	Availability float64 `mapstructure:"availability" validate:"gte=0,lt=1,required_without=Latency"`
+	// LatencyTarget is the target ratio of the requests faster than Latency.
+	Latency       time.Duration `mapstructure:"latency" validate:"gte=0"`
+	LatencyTarget float64       `mapstructure:"latency_target" validate:"required_with=Latency,omitempty,gt=0,lt=1"`
+}
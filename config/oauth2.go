@@ -0,0 +1,35 @@
+package config
+
+import "time"
+
+// OAuth2 represents the login with OAuth2 / OpenID Connect providers.
+type OAuth2 struct {
+	// StateKey signs the state cookie of the logins, a random key is used if
+	// it is empty, which does not work with multiple instances.
+	StateKey string        `mapstructure:"state_key"`
+	StateTTL time.Duration `mapstructure:"state_ttl" default:"10m"`
+
+	// CookieSecure sends the state cookie over https only.
+	CookieSecure bool `mapstructure:"cookie_secure" default:"true"`
+
+	Providers []OAuth2Provider `mapstructure:"providers" validate:"dive"`
+}
+
+// OAuth2Provider represents a provider, the endpoints are discovered from the
+// issuer of an OpenID Connect provider unless they are configured.
+type OAuth2Provider struct {
+	Name         string   `mapstructure:"name" validate:"required"`
+	ClientID     string   `mapstructure:"client_id" validate:"required"`
+	ClientSecret string   `mapstructure:"client_secret"`
+	Scopes       []string `mapstructure:"scopes"`
+
+	// RedirectURL is an absolute url, or a path which is resolved against the
+	// scheme and host of the login request.
+	RedirectURL string `mapstructure:"redirect_url" validate:"required,url|startswith=/"`
+
+	Issuer      string `mapstructure:"issuer" validate:"required_without=AuthURL"`
+	AuthURL     string `mapstructure:"auth_url" validate:"required_without=Issuer"`
+	TokenURL    string `mapstructure:"token_url" validate:"required_with=AuthURL"`
+	UserInfoURL string `mapstructure:"userinfo_url"`
+	JWKSURL     string `mapstructure:"jwks_url"`
+}
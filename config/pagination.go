@@ -0,0 +1,24 @@
+package config
+
+import "time"
+
+// Pagination represents the configuration of list pagination.
+type Pagination struct {
+	DefaultPageSize int          `mapstructure:"default_page_size" default:"10" validate:"gte=1"`
+	MaxPageSize     int          `mapstructure:"max_page_size" default:"1000" validate:"gtefield=DefaultPageSize"`
+	Cursor          CursorConfig `mapstructure:"cursor"`
+}
+
+// CursorConfig represents the configuration of the signed pagination cursor.
+type CursorConfig struct {
+	// SignKey is the HMAC key used to sign cursors. If it is empty a random key
+	// is generated at startup, so cursors will not survive a restart and can
+	// not be shared between instances.
+	SignKey string `mapstructure:"sign_key"`
+
+	// EncryptKey enables the encryption of the cursor payload if it is not empty.
+	EncryptKey string `mapstructure:"encrypt_key"`
+
+	// TTL is the lifetime of a cursor, zero means the cursor never expires.
+	TTL time.Duration `mapstructure:"ttl" default:"24h"`
+}
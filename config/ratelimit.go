@@ -0,0 +1,39 @@
+package config
+
+import "time"
+
+// RateLimit represents the rate limits of the api route groups, a request
+// matching several policies is limited by each of them.
+type RateLimit struct {
+	Enable bool `mapstructure:"enable" default:"false"`
+	// Store is memory or session. The memory store counts the limits per
+	// instance, the session one shares the store of the sessions, so that
+	// the instances sharing a Redis store share the limits.
+	Store string `mapstructure:"store" default:"memory" validate:"oneof=memory session"`
+	// FailOpen lets the requests through if the store fails, otherwise they
+	// are rejected as the server is busy.
+	FailOpen bool              `mapstructure:"fail_open" default:"true"`
+	Policies []RateLimitPolicy `mapstructure:"policies" validate:"dive"`
+}
+
+// RateLimitPolicy limits the requests of the route group with the path
+// prefix, such as /api/v1/auth. Key is ip, principal or route and defaults to
+// ip, the anonymous requests are limited by ip. The api keys are not keys of
+// their own, since they are not authenticated: a client sending a new one
+// with each request would get a new quota each time. A route policy limits all the clients together, so the
+// requests it rejects are responded as the server is busy. Algorithm is
+// token_bucket or sliding_window and defaults to token_bucket.
+type RateLimitPolicy struct {
+	Name    string   `mapstructure:"name" validate:"required"`
+	Group   string   `mapstructure:"group" validate:"required,startswith=/"`
+	Methods []string `mapstructure:"methods"`
+
+	Key       string `mapstructure:"key" validate:"omitempty,oneof=ip principal route"`
+	Algorithm string `mapstructure:"algorithm" validate:"omitempty,oneof=token_bucket sliding_window"`
+
+	// Limit is the number of requests allowed per Period, Burst is the
+	// capacity of the token bucket and defaults to Limit.
+	Limit  int           `mapstructure:"limit" validate:"gt=0"`
+	Period time.Duration `mapstructure:"period" validate:"gt=0"`
+	Burst  int           `mapstructure:"burst" validate:"gte=0"`
+}
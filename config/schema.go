@@ -1,7 +1,22 @@
 package config
 
 type Schema struct {
-	Name string     `mapstructure:"name" default:"demo"`
-	HTTP HTTPServer `mapstructure:"http"`
-	CORS CORSConfig `mapstructure:"cors"`
+	Name  string     `mapstructure:"name" default:"demo"`
+	HTTP  HTTPServer `mapstructure:"http"`
+	Admin Admin      `mapstructure:"admin"`
+	CORS  CORSConfig `mapstructure:"cors"`
+	Log   Log        `mapstructure:"log"`
+
+	Health   Health   `mapstructure:"health"`
+	Shutdown Shutdown `mapstructure:"shutdown"`
+	Tracing  Tracing  `mapstructure:"tracing"`
+	Metrics  Metrics  `mapstructure:"metrics"`
+	OAuth2   OAuth2   `mapstructure:"oauth2"`
+	JWT      JWT      `mapstructure:"jwt"`
+	Authz    Authz    `mapstructure:"authz"`
+	Session  Session  `mapstructure:"session"`
+	Login    Login    `mapstructure:"login"`
+
+	RateLimit  RateLimit  `mapstructure:"rate_limit"`
+	Pagination Pagination `mapstructure:"pagination"`
 }
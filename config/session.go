@@ -0,0 +1,51 @@
+package config
+
+import "time"
+
+// Session represents the server side sessions, the captchas and the login
+// throttling are kept in the same store.
+type Session struct {
+	// Store is memory or file, the memory store does not work with multiple
+	// instances.
+	Store string        `mapstructure:"store" default:"memory" validate:"oneof=memory file"`
+	Dir   string        `mapstructure:"dir" default:"data/sessions" validate:"required_if=Store file"`
+	TTL   time.Duration `mapstructure:"ttl" default:"24h"`
+
+	Cookie SessionCookie `mapstructure:"cookie"`
+}
+
+// SessionCookie represents the cookie of the session id.
+type SessionCookie struct {
+	Name   string `mapstructure:"name" default:"session_id"`
+	Path   string `mapstructure:"path" default:"/"`
+	Domain string `mapstructure:"domain"`
+	// Secure sends the cookie over https only.
+	Secure   bool   `mapstructure:"secure" default:"true"`
+	HTTPOnly bool   `mapstructure:"http_only" default:"true"`
+	SameSite string `mapstructure:"same_site" default:"lax" validate:"oneof=lax strict none"`
+}
+
+// Login represents the username and password login.
+type Login struct {
+	// Captcha requires the answer of a captcha for each login.
+	Captcha bool `mapstructure:"captcha" default:"true"`
+
+	// MaxAccountFailures and MaxIPFailures are the failed logins allowed per
+	// account and per ip within the lock duration, zero disables the limit.
+	MaxAccountFailures int           `mapstructure:"max_account_failures" default:"5"`
+	MaxIPFailures      int           `mapstructure:"max_ip_failures" default:"20"`
+	LockDuration       time.Duration `mapstructure:"lock_duration" default:"15m"`
+
+	Users []LoginUser `mapstructure:"users" validate:"dive"`
+}
+
+// LoginUser represents an account, the password is an argon2id or a bcrypt
+// hash.
+type LoginUser struct {
+	Username string   `mapstructure:"username" validate:"required"`
+	Password string   `mapstructure:"password" validate:"required"`
+	UserId   string   `mapstructure:"user_id"`
+	Tenant   string   `mapstructure:"tenant"`
+	Roles    []string `mapstructure:"roles"`
+	Disabled bool     `mapstructure:"disabled"`
+}
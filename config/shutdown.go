@@ -0,0 +1,17 @@
+package config
+
+import "time"
+
+// Shutdown represents the graceful shutdown. The sum of the durations must be
+// less than the stop timeout of the application, which is 1m.
+type Shutdown struct {
+	// DrainPeriod is waited after the readiness fails, so that the load
+	// balancers stop sending requests before the listener is closed.
+	DrainPeriod time.Duration `mapstructure:"drain_period" default:"5s" validate:"gte=0"`
+	// Timeout is waited for the in-flight requests, the connections which are
+	// still active are closed after it.
+	Timeout time.Duration `mapstructure:"timeout" default:"20s" validate:"gt=0"`
+	// WorkerTimeout is waited for the background workers after the servers
+	// are stopped.
+	WorkerTimeout time.Duration `mapstructure:"worker_timeout" default:"10s" validate:"gt=0"`
+}
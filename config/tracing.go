@@ -0,0 +1,31 @@
+package config
+
+import "time"
+
+// Tracing represents the configuration of the distributed tracing.
+type Tracing struct {
+	Enable bool `mapstructure:"enable" default:"false"`
+
+	// ServiceName defaults to the name of the application.
+	ServiceName string  `mapstructure:"service_name"`
+	SampleRatio float64 `mapstructure:"sample_ratio" default:"1" validate:"gte=0,lte=1"`
+
+	Exporter string       `mapstructure:"exporter" default:"stdout" validate:"oneof=stdout file otlp"`
+	File     string       `mapstructure:"file" validate:"required_if=Exporter file"`
+	OTLP     TracingOTLP  `mapstructure:"otlp"`
+	Batch    TracingBatch `mapstructure:"batch"`
+}
+
+// TracingOTLP represents an OpenTelemetry collector receiving OTLP/HTTP JSON.
+type TracingOTLP struct {
+	Endpoint string            `mapstructure:"endpoint" default:"http://localhost:4318/v1/traces"`
+	Headers  map[string]string `mapstructure:"headers"`
+	Timeout  time.Duration     `mapstructure:"timeout" default:"10s"`
+}
+
+// TracingBatch represents the batching of the ended spans before the export.
+type TracingBatch struct {
+	QueueSize int           `mapstructure:"queue_size" default:"2048"`
+	BatchSize int           `mapstructure:"batch_size" default:"512"`
+	Timeout   time.Duration `mapstructure:"timeout" default:"5s"`
+}
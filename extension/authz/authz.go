@@ -0,0 +1,185 @@
+// Package authz checks the permissions of the principal of a request, the
+// permissions are granted to roles by a RoleStore and can be checked by the
+// route middlewares as well as by the application services together with
+// conditions on the resource.
+package authz
+
+import (
+	"context"
+	"strings"
+	"sync/atomic"
+
+	"demo/extension/contextz"
+	"demo/extension/errorx"
+	"demo/extension/logz"
+)
+
+// Wildcard grants every permission, "orders:*" grants every permission of
+// the orders resource.
+const Wildcard = "*"
+
+// RoleStore returns the permissions granted to a role.
+type RoleStore interface {
+	RolePermissions(ctx context.Context, role string) ([]string, error)
+}
+
+// StaticRoles is a RoleStore backed by a map from role to permissions. The
+// roles are matched case-insensitively, the keys must be lower case as
+// NewStaticRoles makes them, since viper lowercases the keys of the config.
+type StaticRoles map[string][]string
+
+// NewStaticRoles returns the roles with their names lowercased, the
+// permissions of the names differing only by case are merged.
+func NewStaticRoles(roles map[string][]string) StaticRoles {
+	r := make(StaticRoles, len(roles))
+	for role, permissions := range roles {
+		role = strings.ToLower(role)
+		r[role] = append(r[role], permissions...)
+	}
+	return r
+}
+
+func (r StaticRoles) RolePermissions(_ context.Context, role string) ([]string, error) {
+	return r[strings.ToLower(role)], nil
+}
+
+// Authorizer checks the permissions of the principal of a context. The
+// permissions of a principal are the ones of its roles plus its scopes.
+type Authorizer struct {
+	roles RoleStore
+}
+
+func NewAuthorizer(roles RoleStore) *Authorizer {
+	return &Authorizer{roles: roles}
+}
+
+var defaultAuthorizer atomic.Pointer[Authorizer]
+
+func init() {
+	defaultAuthorizer.Store(NewAuthorizer(StaticRoles{}))
+}
+
+// Default returns the authorizer used by Authorize.
+func Default() *Authorizer {
+	return defaultAuthorizer.Load()
+}
+
+// SetDefault replaces the authorizer used by Authorize.
+func SetDefault(a *Authorizer) {
+	defaultAuthorizer.Store(a)
+}
+
+// Authorize checks the permission with the default authorizer.
+func Authorize(ctx context.Context, permission string, opts ...Option) error {
+	return Default().Authorize(ctx, permission, opts...)
+}
+
+// Condition is a resource level check of the principal.
+type Condition func(p *contextz.Principal) bool
+
+type check struct {
+	resource   string
+	conditions []Condition
+}
+
+// Option configures a check.
+type Option func(*check)
+
+// OnResource names the resource of the check in the audit log.
+func OnResource(resource string) Option {
+	return func(c *check) {
+		c.resource = resource
+	}
+}
+
+// When requires the condition in addition to the permission.
+func When(cond Condition) Option {
+	return func(c *check) {
+		c.conditions = append(c.conditions, cond)
+	}
+}
+
+// Owner requires the principal to be the owner of the resource.
+func Owner(userId string) Option {
+	return When(func(p *contextz.Principal) bool {
+		return p.UserId == userId
+	})
+}
+
+// SameTenant requires the principal to belong to the tenant of the resource.
+func SameTenant(tenant string) Option {
+	return When(func(p *contextz.Principal) bool {
+		return p.Tenant == tenant
+	})
+}
+
+// Authorize fails with ErrLoginRequired for an anonymous caller and with
+// ErrForbidden if the principal lacks the permission or a condition does not
+// hold. Denials are written to the audit log.
+func (a *Authorizer) Authorize(ctx context.Context, permission string, opts ...Option) error {
+	var c check
+	for _, opt := range opts {
+		opt(&c)
+	}
+
+	principal, err := contextz.RequirePrincipal(ctx)
+	if err != nil {
+		return err
+	}
+
+	granted, err := a.Granted(ctx, principal, permission)
+	if err != nil {
+		return errorx.ErrInternalServer.Wrap(err)
+	}
+	reason := ""
+	if !granted {
+		reason = "permission"
+	}
+	for _, cond := range c.conditions {
+		if reason == "" && !cond(principal) {
+			reason = "condition"
+		}
+	}
+
+	if reason != "" {
+		logz.Warn(ctx, "[authz] access denied",
+			logz.String("audit", "authz"),
+			logz.String("permission", permission),
+			logz.String("resource", c.resource),
+			logz.String("reason", reason),
+		)
+		return errorx.ErrForbidden.WithMessageF("permission %s is required", permission)
+	}
+	return nil
+}
+
+// Granted reports whether the principal has the permission.
+func (a *Authorizer) Granted(ctx context.Context, principal *contextz.Principal, permission string) (bool, error) {
+	for _, scope := range principal.Scopes {
+		if Match(scope, permission) {
+			return true, nil
+		}
+	}
+	for _, role := range principal.Roles {
+		permissions, err := a.roles.RolePermissions(ctx, role)
+		if err != nil {
+			return false, err
+		}
+		for _, p := range permissions {
+			if Match(p, permission) {
+				return true, nil
+			}
+		}
+	}
+	return false, nil
+}
+
+// Match reports whether the granted permission covers the required one, the
+// segments are separated by ":" and a trailing "*" matches the rest.
+func Match(granted, required string) bool {
+	if granted == Wildcard || granted == required {
+		return true
+	}
+	prefix, ok := strings.CutSuffix(granted, ":"+Wildcard)
+	return ok && strings.HasPrefix(required, prefix+":")
+}
@@ -0,0 +1,91 @@
+package authz
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"demo/extension/contextz"
+	"demo/extension/errorx"
+)
+
+type failingRoles struct{}
+
+func (failingRoles) RolePermissions(context.Context, string) ([]string, error) {
+	return nil, errors.New("store is down")
+}
+
+func TestMatch(t *testing.T) {
+	assert.True(t, Match("*", "orders:read"))
+	assert.True(t, Match("orders:read", "orders:read"))
+	assert.True(t, Match("orders:*", "orders:read"))
+	assert.True(t, Match("orders:*", "orders:items:write"))
+	assert.False(t, Match("orders:*", "ordersx:read"))
+	assert.False(t, Match("orders:read", "orders:write"))
+	assert.False(t, Match("orders", "orders:read"))
+}
+
+func TestAuthorize(t *testing.T) {
+	a := NewAuthorizer(StaticRoles{
+		"admin":  {"*"},
+		"viewer": {"orders:read"},
+	})
+	forbidden := func(err error) bool {
+		var ex errorx.Error
+		return errors.As(err, &ex) && ex.Code == errorx.ErrForbidden.Code
+	}
+
+	ctx := context.Background()
+	assert.ErrorIs(t, a.Authorize(ctx, "orders:read"), errorx.ErrLoginRequired)
+
+	viewer := contextz.WithPrincipal(ctx, &contextz.Principal{UserId: "u1", Tenant: "t1", Roles: []string{"viewer"}})
+	assert.NoError(t, a.Authorize(viewer, "orders:read"))
+	assert.True(t, forbidden(a.Authorize(viewer, "orders:write")))
+
+	// resource level checks
+	assert.NoError(t, a.Authorize(viewer, "orders:read", OnResource("order/1"), Owner("u1"), SameTenant("t1")))
+	assert.True(t, forbidden(a.Authorize(viewer, "orders:read", Owner("u2"))))
+	assert.True(t, forbidden(a.Authorize(viewer, "orders:read", SameTenant("t2"))))
+
+	admin := contextz.WithPrincipal(ctx, &contextz.Principal{UserId: "u2", Roles: []string{"admin"}})
+	assert.NoError(t, a.Authorize(admin, "orders:write"))
+
+	// the scopes of a token are permissions as well
+	client := contextz.WithPrincipal(ctx, &contextz.Principal{UserId: "c1", Scopes: []string{"orders:write"}})
+	assert.NoError(t, a.Authorize(client, "orders:write"))
+
+	var ex errorx.Error
+	err := NewAuthorizer(failingRoles{}).Authorize(viewer, "orders:read")
+	assert.True(t, errors.As(err, &ex))
+	assert.Equal(t, errorx.ErrInternalServer.Code, ex.Code)
+}
+
+func TestDefault(t *testing.T) {
+	previous := Default()
+	defer SetDefault(previous)
+
+	ctx := contextz.WithPrincipal(context.Background(), &contextz.Principal{UserId: "u1", Roles: []string{"viewer"}})
+	assert.Error(t, Authorize(ctx, "orders:read"))
+
+	SetDefault(NewAuthorizer(StaticRoles{"viewer": {"orders:read"}}))
+	assert.NoError(t, Authorize(ctx, "orders:read"))
+}
+
+func TestStaticRolesIgnoreCase(t *testing.T) {
+	// viper lowercases the role names of the config
+	a := NewAuthorizer(NewStaticRoles(map[string][]string{
+		"admin":  {"orders:*"},
+		"Viewer": {"orders:read"},
+		"viewer": {"items:read"},
+	}))
+
+	ctx := contextz.WithPrincipal(context.Background(), &contextz.Principal{UserId: "u1", Roles: []string{"Admin"}})
+	assert.NoError(t, a.Authorize(ctx, "orders:write"))
+
+	ctx = contextz.WithPrincipal(context.Background(), &contextz.Principal{UserId: "u2", Roles: []string{"VIEWER"}})
+	assert.NoError(t, a.Authorize(ctx, "orders:read"))
+	assert.NoError(t, a.Authorize(ctx, "items:read"))
+	assert.Error(t, a.Authorize(ctx, "orders:write"))
+}
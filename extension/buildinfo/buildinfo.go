@@ -0,0 +1,29 @@
+// Package buildinfo holds the version of the build, which is injected at link
+// time:
+//
+//	go build -ldflags "-X demo/extension/buildinfo.Version=v1.2.3 \
+//		-X demo/extension/buildinfo.Commit=$(git rev-parse --short HEAD) \
+//		-X demo/extension/buildinfo.BuildTime=$(date -u +%Y-%m-%dT%H:%M:%SZ)" ./cmd/server
+package buildinfo
+
+import "strings"
+
+var (
+	// Version is the semantic version of the release, such as v1.2.3.
+	Version = "dev"
+	// Commit is the revision of the source.
+	Commit = ""
+	// BuildTime is the time of the build in RFC 3339.
+	BuildTime = ""
+)
+
+// Major returns the major version of Version, such as 1 for v1.2.3, or
+// Version itself if it is not a semantic version.
+func Major() string {
+	v := strings.TrimPrefix(Version, "v")
+	major, _, ok := strings.Cut(v, ".")
+	if !ok {
+		return Version
+	}
+	return major
+}
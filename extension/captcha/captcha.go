@@ -0,0 +1,119 @@
+// Package captcha generates arithmetic challenges, such as "7 + 3 = ?", which
+// are rendered into an image as well. The answers are kept in a key value
+// store and can be verified only once.
+package captcha
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+	"time"
+
+	"demo/extension/errorx"
+	"demo/extension/kv"
+)
+
+// Challenge is sent to the client, the answer is never part of it.
+type Challenge struct {
+	Id       string `json:"id"`
+	Question string `json:"question"`
+	// Image is the question rendered as a PNG data URI.
+	Image string `json:"image"`
+}
+
+// Options is the options of the Captcha.
+type Options struct {
+	// TTL is how long a challenge can be answered, defaults to five minutes.
+	TTL time.Duration
+
+	// Prefix is prepended to the keys of the answers, defaults to "captcha:".
+	Prefix string
+}
+
+type Captcha struct {
+	store kv.KV
+	opts  Options
+}
+
+func New(store kv.KV, opts Options) *Captcha {
+	if opts.TTL <= 0 {
+		opts.TTL = 5 * time.Minute
+	}
+	if opts.Prefix == "" {
+		opts.Prefix = "captcha:"
+	}
+	return &Captcha{store: store, opts: opts}
+}
+
+// Generate creates a challenge and stores its answer.
+func (c *Captcha) Generate(ctx context.Context) (*Challenge, error) {
+	question, answer := arithmetic()
+	img, err := render(question)
+	if err != nil {
+		return nil, err
+	}
+
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return nil, err
+	}
+	id := base64.RawURLEncoding.EncodeToString(b)
+	if err := c.store.Set(ctx, c.opts.Prefix+id, []byte(strconv.Itoa(answer)), c.opts.TTL); err != nil {
+		return nil, err
+	}
+
+	return &Challenge{
+		Id:       id,
+		Question: question,
+		Image:    "data:image/png;base64," + base64.StdEncoding.EncodeToString(img),
+	}, nil
+}
+
+// Verify checks the answer of the challenge, the challenge is discarded even
+// if the answer is wrong. It fails with ErrInvalidCaptcha.
+func (c *Captcha) Verify(ctx context.Context, id, answer string) error {
+	if id == "" || answer == "" {
+		return errorx.ErrInvalidCaptcha.WithMessage("captcha is required")
+	}
+	expected, err := c.store.GetDel(ctx, c.opts.Prefix+id)
+	if errors.Is(err, kv.ErrNil) {
+		return errorx.ErrInvalidCaptcha.WithMessage("captcha is expired")
+	}
+	if err != nil {
+		return errorx.ErrInternalServer.Wrap(err)
+	}
+	if strings.TrimSpace(answer) != string(expected) {
+		return errorx.ErrInvalidCaptcha
+	}
+	return nil
+}
+
+// arithmetic returns an addition, a subtraction with a non negative result or
+// a multiplication of single digits.
+func arithmetic() (string, int) {
+	a, b := randInt(9)+1, randInt(9)+1
+	switch randInt(3) {
+	case 0:
+		return fmt.Sprintf("%d + %d = ?", a, b), a + b
+	case 1:
+		if a < b {
+			a, b = b, a
+		}
+		return fmt.Sprintf("%d - %d = ?", a, b), a - b
+	default:
+		return fmt.Sprintf("%d x %d = ?", a, b), a * b
+	}
+}
+
+func randInt(n int) int {
+	v, err := rand.Int(rand.Reader, big.NewInt(int64(n)))
+	if err != nil {
+		panic(err)
+	}
+	return int(v.Int64())
+}
@@ -0,0 +1,61 @@
+package captcha
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"image/png"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"demo/extension/errorx"
+	"demo/extension/kv"
+)
+
+func answer(question string) string {
+	var a, b int
+	var op string
+	_, _ = fmt.Sscan(question, &a, &op, &b)
+	switch op {
+	case "+":
+		return strconv.Itoa(a + b)
+	case "-":
+		return strconv.Itoa(a - b)
+	default:
+		return strconv.Itoa(a * b)
+	}
+}
+
+func TestCaptcha(t *testing.T) {
+	ctx := context.Background()
+	c := New(kv.NewMemory(), Options{})
+
+	challenge, err := c.Generate(ctx)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, challenge.Id)
+	assert.True(t, strings.HasSuffix(challenge.Question, "= ?"))
+
+	data, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(challenge.Image, "data:image/png;base64,"))
+	assert.NoError(t, err)
+	_, err = png.Decode(bytes.NewReader(data))
+	assert.NoError(t, err)
+
+	assert.NoError(t, c.Verify(ctx, challenge.Id, answer(challenge.Question)))
+
+	// a challenge can be answered only once
+	var ex errorx.Error
+	assert.True(t, errors.As(c.Verify(ctx, challenge.Id, answer(challenge.Question)), &ex))
+	assert.Equal(t, errorx.ErrInvalidCaptcha.Code, ex.Code)
+
+	challenge, err = c.Generate(ctx)
+	assert.NoError(t, err)
+	assert.True(t, errors.As(c.Verify(ctx, challenge.Id, "-1"), &ex))
+	assert.Equal(t, errorx.ErrInvalidCaptcha.Code, ex.Code)
+	assert.True(t, errors.As(c.Verify(ctx, "", ""), &ex))
+	assert.Equal(t, errorx.ErrInvalidCaptcha.Code, ex.Code)
+}
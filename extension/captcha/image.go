@@ -0,0 +1,86 @@
+package captcha
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	mrand "math/rand"
+)
+
+const (
+	scale  = 4
+	width  = 160
+	height = 40
+)
+
+// glyphs is a 3x5 bitmap font of the characters of the questions, each row
+// is three bits from left to right.
+var glyphs = map[rune][5]uint8{
+	'0': {7, 5, 5, 5, 7},
+	'1': {2, 6, 2, 2, 7},
+	'2': {7, 1, 7, 4, 7},
+	'3': {7, 1, 7, 1, 7},
+	'4': {5, 5, 7, 1, 1},
+	'5': {7, 4, 7, 1, 7},
+	'6': {7, 4, 7, 5, 7},
+	'7': {7, 1, 1, 1, 1},
+	'8': {7, 5, 7, 5, 7},
+	'9': {7, 5, 7, 1, 7},
+	'+': {0, 2, 7, 2, 0},
+	'-': {0, 0, 7, 0, 0},
+	'x': {0, 5, 2, 5, 0},
+	'=': {0, 7, 0, 7, 0},
+	'?': {7, 1, 3, 0, 2},
+}
+
+// render draws the question with a jitter per character and some noise, the
+// image only needs to be readable by humans.
+func render(question string) ([]byte, error) {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	bg := color.RGBA{R: 245, G: 245, B: 245, A: 255}
+	for x := 0; x < width; x++ {
+		for y := 0; y < height; y++ {
+			img.Set(x, y, bg)
+		}
+	}
+
+	x := 8
+	for _, r := range question {
+		glyph, ok := glyphs[r]
+		if !ok {
+			x += 2 * scale
+			continue
+		}
+		fg := color.RGBA{R: uint8(mrand.Intn(120)), G: uint8(mrand.Intn(120)), B: uint8(mrand.Intn(120)), A: 255}
+		top := (height-5*scale)/2 + mrand.Intn(7) - 3
+		for row, bits := range glyph {
+			for col := 0; col < 3; col++ {
+				if bits&(4>>col) == 0 {
+					continue
+				}
+				fill(img, x+col*scale, top+row*scale, fg)
+			}
+		}
+		x += 4 * scale
+	}
+
+	for i := 0; i < width*height/12; i++ {
+		gray := uint8(mrand.Intn(200))
+		img.Set(mrand.Intn(width), mrand.Intn(height), color.RGBA{R: gray, G: gray, B: gray, A: 255})
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func fill(img *image.RGBA, x, y int, c color.Color) {
+	for dx := 0; dx < scale; dx++ {
+		for dy := 0; dy < scale; dy++ {
+			img.Set(x+dx, y+dy, c)
+		}
+	}
+}
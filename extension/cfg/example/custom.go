@@ -3,7 +3,7 @@ package main
 import (
 	"fmt"
 
-	"scan/extension/cfg"
+	"demo/extension/cfg"
 )
 
 var _ cfg.IHookAfterLoad = &Config{}
@@ -2,6 +2,8 @@ package contextz
 
 import (
 	"context"
+
+	"demo/extension/tracing"
 )
 
 var moduleKey = &contextKey{name: "module"}
@@ -16,11 +18,16 @@ func AsyncClone(ctx context.Context) context.Context {
 	if moduleName != "" {
 		rv = WithModuleName(rv, moduleName)
 	}
+	if requestId := RequestId(ctx); requestId != "" {
+		rv = WithRequestId(rv, requestId)
+	}
+	if principal, ok := GetPrincipal(ctx); ok {
+		rv = WithPrincipal(rv, principal)
+	}
 
-	// span := tracing.SpanFromContext(ctx)
-	// if !span.Empty() {
-	// 	rv = tracing.ContextWithSpan(rv, span)
-	// }
+	if span := tracing.SpanFromContext(ctx); span != nil {
+		rv = tracing.ContextWithSpan(rv, span)
+	}
 	return rv
 }
 
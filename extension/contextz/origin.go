@@ -0,0 +1,38 @@
+package contextz
+
+import (
+	"context"
+	"strings"
+)
+
+// Origin is the scheme and host requested by the client, they are the ones
+// of the trusted reverse proxy if the request is forwarded.
+type Origin struct {
+	Scheme string
+	Host   string
+}
+
+func (o Origin) String() string {
+	return o.Scheme + "://" + o.Host
+}
+
+// URL returns the absolute url of the path.
+func (o Origin) URL(path string) string {
+	if !strings.HasPrefix(path, "/") {
+		path = "/" + path
+	}
+	return o.String() + path
+}
+
+func WithOrigin(ctx context.Context, origin Origin) context.Context {
+	return context.WithValue(ctx, originContextKey, origin)
+}
+
+// GetOrigin returns the origin of the request, false if it is not resolved.
+func GetOrigin(ctx context.Context) (Origin, bool) {
+	if ctx == nil {
+		return Origin{}, false
+	}
+	origin, ok := ctx.Value(originContextKey).(Origin)
+	return origin, ok
+}
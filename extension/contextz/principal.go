@@ -0,0 +1,82 @@
+package contextz
+
+import (
+	"context"
+	"slices"
+
+	"demo/extension/errorx"
+)
+
+// Principal is the authenticated caller of a request.
+type Principal struct {
+	UserId string
+	Tenant string
+	Roles  []string
+	Scopes []string
+
+	// Claims keeps the other attributes provided by the authenticator, for
+	// example the claims of a token.
+	Claims map[string]any
+}
+
+func (p *Principal) HasRole(role string) bool {
+	return p != nil && slices.Contains(p.Roles, role)
+}
+
+func (p *Principal) HasScope(scope string) bool {
+	return p != nil && slices.Contains(p.Scopes, scope)
+}
+
+func WithPrincipal(ctx context.Context, principal *Principal) context.Context {
+	return context.WithValue(ctx, principalContextKey, principal)
+}
+
+// GetPrincipal returns the principal of the context, false for an anonymous
+// caller.
+func GetPrincipal(ctx context.Context) (*Principal, bool) {
+	if ctx == nil {
+		return nil, false
+	}
+	principal, _ := ctx.Value(principalContextKey).(*Principal)
+	return principal, principal != nil
+}
+
+// RequirePrincipal returns the principal of the context, or ErrLoginRequired
+// for an anonymous caller.
+func RequirePrincipal(ctx context.Context) (*Principal, error) {
+	principal, ok := GetPrincipal(ctx)
+	if !ok {
+		return nil, errorx.ErrLoginRequired
+	}
+	return principal, nil
+}
+
+// RequireRoles fails with ErrLoginRequired for an anonymous caller and with
+// ErrForbidden unless the principal has all the roles.
+func RequireRoles(ctx context.Context, roles ...string) error {
+	principal, err := RequirePrincipal(ctx)
+	if err != nil {
+		return err
+	}
+	for _, role := range roles {
+		if !principal.HasRole(role) {
+			return errorx.ErrForbidden.WithMessageF("role %s is required", role)
+		}
+	}
+	return nil
+}
+
+// RequireScopes fails with ErrLoginRequired for an anonymous caller and with
+// ErrForbidden unless the principal has all the scopes.
+func RequireScopes(ctx context.Context, scopes ...string) error {
+	principal, err := RequirePrincipal(ctx)
+	if err != nil {
+		return err
+	}
+	for _, scope := range scopes {
+		if !principal.HasScope(scope) {
+			return errorx.ErrForbidden.WithMessageF("scope %s is required", scope)
+		}
+	}
+	return nil
+}
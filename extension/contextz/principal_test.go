@@ -0,0 +1,54 @@
+package contextz
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"demo/extension/errorx"
+)
+
+func TestPrincipal(t *testing.T) {
+	ctx := context.Background()
+	_, ok := GetPrincipal(ctx)
+	assert.False(t, ok)
+	_, err := RequirePrincipal(ctx)
+	assert.ErrorIs(t, err, errorx.ErrLoginRequired)
+	assert.ErrorIs(t, RequireRoles(ctx, "admin"), errorx.ErrLoginRequired)
+
+	ctx = WithPrincipal(ctx, &Principal{UserId: "u1", Roles: []string{"admin"}, Scopes: []string{"read"}})
+	principal, err := RequirePrincipal(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, "u1", principal.UserId)
+
+	assert.NoError(t, RequireRoles(ctx, "admin"))
+	assert.NoError(t, RequireScopes(ctx, "read"))
+
+	var ex errorx.Error
+	assert.True(t, errors.As(RequireRoles(ctx, "admin", "owner"), &ex))
+	assert.Equal(t, errorx.ErrForbidden.Code, ex.Code)
+	assert.True(t, errors.As(RequireScopes(ctx, "write"), &ex))
+	assert.Equal(t, errorx.ErrForbidden.Code, ex.Code)
+
+	clone := AsyncClone(ctx)
+	principal, ok = GetPrincipal(clone)
+	assert.True(t, ok)
+	assert.Equal(t, "u1", principal.UserId)
+}
+
+func TestSession(t *testing.T) {
+	_, err := RequireSession(context.Background())
+	assert.ErrorIs(t, err, errorx.ErrLoginRequired)
+
+	now := time.Now()
+	session := &Session{Id: "s1", UserId: "u1", ExpiresAt: now.Add(time.Minute)}
+	got, err := RequireSession(WithSession(context.Background(), session))
+	assert.NoError(t, err)
+	assert.Same(t, session, got)
+	assert.False(t, session.Expired(now))
+	assert.True(t, session.Expired(now.Add(time.Minute)))
+	assert.False(t, (&Session{}).Expired(now))
+}
@@ -0,0 +1,43 @@
+package contextz
+
+import (
+	"context"
+	"time"
+
+	"demo/extension/errorx"
+)
+
+// Session is the server side session of a request authenticated by a cookie.
+type Session struct {
+	Id        string
+	UserId    string
+	Values    map[string]any
+	ExpiresAt time.Time
+}
+
+func (s *Session) Expired(now time.Time) bool {
+	return !s.ExpiresAt.IsZero() && !now.Before(s.ExpiresAt)
+}
+
+func WithSession(ctx context.Context, session *Session) context.Context {
+	return context.WithValue(ctx, sessionContextKey, session)
+}
+
+// GetSession returns the session of the context, false if there is none.
+func GetSession(ctx context.Context) (*Session, bool) {
+	if ctx == nil {
+		return nil, false
+	}
+	session, _ := ctx.Value(sessionContextKey).(*Session)
+	return session, session != nil
+}
+
+// RequireSession returns the session of the context, or ErrLoginRequired if
+// there is none.
+func RequireSession(ctx context.Context) (*Session, error) {
+	session, ok := GetSession(ctx)
+	if !ok {
+		return nil, errorx.ErrLoginRequired
+	}
+	return session, nil
+}
@@ -2,17 +2,38 @@ package contextz
 
 import (
 	"context"
+
+	"demo/extension/tracing"
 )
 
+var requestIdKey = &contextKey{name: "request_id"}
+
+func WithRequestId(ctx context.Context, requestId string) context.Context {
+	return context.WithValue(ctx, requestIdKey, requestId)
+}
+
 func RequestId(ctx context.Context) string {
 	if ctx == nil {
 		return ""
 	}
-	return ""
-	// span := tracing.SpanFromContext(ctx)
-	// if span.Empty() {
-	// 	return ""
-	// }
+	requestId, _ := ctx.Value(requestIdKey).(string)
+	return requestId
+}
 
-	// return span.RequestID()
+// TraceId returns the hex trace id of the span of the context, or "".
+func TraceId(ctx context.Context) string {
+	sc := tracing.SpanFromContext(ctx).SpanContext()
+	if !sc.IsValid() {
+		return ""
+	}
+	return sc.TraceID.String()
+}
+
+// SpanId returns the hex span id of the span of the context, or "".
+func SpanId(ctx context.Context) string {
+	sc := tracing.SpanFromContext(ctx).SpanContext()
+	if !sc.IsValid() {
+		return ""
+	}
+	return sc.SpanID.String()
 }
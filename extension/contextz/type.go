@@ -5,6 +5,7 @@ type contextKey struct {
 }
 
 var (
-	sessionStoreContextKey = &contextKey{name: "session_store"}
-	userContextKey         = &contextKey{name: "user_entity"}
+	sessionContextKey   = &contextKey{name: "session"}
+	principalContextKey = &contextKey{name: "principal"}
+	originContextKey    = &contextKey{name: "origin"}
 )
@@ -8,7 +8,8 @@ var (
 	ErrIllegalArgument  = NewErrorWithLevel(20003, "请求参数错误", LevelInfo)
 	ErrServerBusy       = NewErrorWithLevel(20004, "服务器繁忙，请稍后重试", LevelError)
 	ErrForbidden        = NewErrorWithLevel(20005, "无权访问", LevelInfo)
-	ErrInvalidSession   = NewErrorWithLevel(20008, "无效的会话", LevelInfo) // Invalid sessionstore.
+	ErrInvalidSession   = NewErrorWithLevel(20008, "无效的会话", LevelInfo)  // Invalid sessionstore.
+	ErrMisdirected      = NewErrorWithLevel(20009, "不支持的主机", LevelInfo) // The host is not served.
 
 	ErrLoginRequired             = NewErrorWithLevel(20100, "需要登录", LevelInfo)
 	ErrNotSupportedAuthorization = NewErrorWithLevel(20101, "未支持的认证类型", LevelInfo)
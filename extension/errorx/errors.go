@@ -220,6 +220,9 @@ func Explode(err error) (code int, grpcCode codes.Code, message string, detail s
 		if te, ok := err.(*Error); ok {
 			return te.Code, te.GRPCCode, te.Reason, te.Message
 		}
+		if te, ok := err.(Error); ok {
+			return te.Code, te.GRPCCode, te.Reason, te.Message
+		}
 	}
 }
 
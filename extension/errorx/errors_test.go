@@ -127,3 +127,25 @@ func TestErrorLevel(t *testing.T) {
 		})
 	}
 }
+
+func TestExplode(t *testing.T) {
+	testcases := []struct {
+		name string
+		err  error
+		want int
+	}{
+		{name: "nil", err: nil, want: DefaultSuccessCode},
+		{name: "value", err: ErrIllegalArgument, want: ErrIllegalArgument.Code},
+		{name: "wrapped value", err: ErrIllegalArgument.Wrap(stderr.New("original")), want: ErrIllegalArgument.Code},
+		{name: "wrapped pointer", err: ErrIllegalArgument.WithWrap(stderr.New("original")), want: ErrIllegalArgument.Code},
+		{name: "unknown", err: stderr.New("original"), want: ErrUnknown.Code},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			if code, _, _, _ := Explode(tc.err); code != tc.want {
+				t.Errorf("Explode(%v) code = %d, want %d", tc.err, code, tc.want)
+			}
+		})
+	}
+}
@@ -5,7 +5,7 @@ import (
 	"errors"
 	"sync"
 
-	"scan/extension/logz"
+	"demo/extension/logz"
 )
 
 var ErrNoHandler = errors.New("no handler")
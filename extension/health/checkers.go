@@ -0,0 +1,53 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"google.golang.org/grpc"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+
+	"demo/extension/kv"
+)
+
+// KV checks that the store responds, the probe key does not need to exist.
+func KV(store kv.KV) Checker {
+	return CheckerFunc(func(ctx context.Context) error {
+		_, err := store.Get(ctx, "health:probe")
+		if err != nil && !errors.Is(err, kv.ErrNil) {
+			return err
+		}
+		return nil
+	})
+}
+
+// DiskSpace checks that the file system of path has minFree bytes available.
+func DiskSpace(path string, minFree uint64) Checker {
+	return CheckerFunc(func(context.Context) error {
+		free, err := diskFree(path)
+		if err != nil {
+			return err
+		}
+		if free < minFree {
+			return fmt.Errorf("%s has %d bytes available, %d are required", path, free, minFree)
+		}
+		return nil
+	})
+}
+
+// GRPC checks a downstream service by the grpc health checking protocol, the
+// empty service checks the server.
+func GRPC(conn grpc.ClientConnInterface, service string) Checker {
+	client := healthpb.NewHealthClient(conn)
+	return CheckerFunc(func(ctx context.Context) error {
+		resp, err := client.Check(ctx, &healthpb.HealthCheckRequest{Service: service})
+		if err != nil {
+			return err
+		}
+		if resp.GetStatus() != healthpb.HealthCheckResponse_SERVING {
+			return fmt.Errorf("grpc service %q is %s", service, resp.GetStatus())
+		}
+		return nil
+	})
+}
@@ -0,0 +1,224 @@
+// Package health runs the checks of the components and reports them in the
+// format of the health check draft:
+// https://datatracker.ietf.org/doc/html/draft-inadarei-api-health-check
+package health
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Statuses of the checks and the service.
+const (
+	StatusPass = "pass"
+	StatusWarn = "warn"
+	StatusFail = "fail"
+)
+
+// Checker checks the health of a component.
+type Checker interface {
+	Check(ctx context.Context) error
+}
+
+// CheckerFunc adapts a function to a Checker.
+type CheckerFunc func(ctx context.Context) error
+
+func (f CheckerFunc) Check(ctx context.Context) error {
+	return f(ctx)
+}
+
+// Check is a registered checker.
+type Check struct {
+	// Name is the key of the result, "component:measurement" by the draft,
+	// for example "kv:responseTime".
+	Name          string
+	ComponentId   string
+	ComponentType string
+
+	Checker Checker
+	// Timeout defaults to the timeout of the options.
+	Timeout time.Duration
+
+	// Critical checks fail the service when they fail, the others warn.
+	Critical bool
+	// Liveness checks are run by the liveness as well as the readiness, only
+	// the failures which are fixed by a restart should fail the liveness.
+	Liveness bool
+}
+
+// Options configures the Health.
+type Options struct {
+	ServiceId   string
+	Description string
+	Version     string
+	ReleaseId   string
+	// Timeout of the checks, defaults to 1s.
+	Timeout time.Duration
+}
+
+// Result is the response of the health check.
+type Result struct {
+	Status      string                   `json:"status"`
+	Version     string                   `json:"version,omitempty"`
+	ReleaseId   string                   `json:"releaseId,omitempty"`
+	ServiceId   string                   `json:"serviceId,omitempty"`
+	Description string                   `json:"description,omitempty"`
+	Notes       []string                 `json:"notes,omitempty"`
+	Output      string                   `json:"output,omitempty"`
+	Checks      map[string][]CheckResult `json:"checks,omitempty"`
+}
+
+// CheckResult is the result of a check, the observed value is its response
+// time.
+type CheckResult struct {
+	ComponentId   string    `json:"componentId,omitempty"`
+	ComponentType string    `json:"componentType,omitempty"`
+	ObservedValue float64   `json:"observedValue"`
+	ObservedUnit  string    `json:"observedUnit"`
+	Status        string    `json:"status"`
+	Time          time.Time `json:"time"`
+	Output        string    `json:"output,omitempty"`
+}
+
+// Health runs the registered checks.
+type Health struct {
+	opts Options
+
+	mu     sync.RWMutex
+	checks []Check
+
+	draining atomic.Pointer[string]
+}
+
+func New(opts Options) *Health {
+	if opts.Timeout <= 0 {
+		opts.Timeout = time.Second
+	}
+	return &Health{opts: opts}
+}
+
+// Register adds the checks, the checks without checker are ignored.
+func (h *Health) Register(checks ...Check) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for _, c := range checks {
+		if c.Checker != nil {
+			h.checks = append(h.checks, c)
+		}
+	}
+}
+
+// Liveness runs the liveness checks.
+func (h *Health) Liveness(ctx context.Context) Result {
+	return h.run(ctx, func(c Check) bool { return c.Liveness })
+}
+
+// Readiness runs all the checks, it fails without running them once Drain is
+// called.
+func (h *Health) Readiness(ctx context.Context) Result {
+	if reason := h.draining.Load(); reason != nil {
+		return Result{
+			Status:      StatusFail,
+			Version:     h.opts.Version,
+			ReleaseId:   h.opts.ReleaseId,
+			ServiceId:   h.opts.ServiceId,
+			Description: h.opts.Description,
+			Output:      *reason,
+		}
+	}
+	return h.run(ctx, func(Check) bool { return true })
+}
+
+// Drain fails the readiness with the reason, so that the load balancers stop
+// sending requests before the service is shut down.
+func (h *Health) Drain(reason string) {
+	h.draining.Store(&reason)
+}
+
+func (h *Health) run(ctx context.Context, filter func(Check) bool) Result {
+	h.mu.RLock()
+	checks := slices.DeleteFunc(slices.Clone(h.checks), func(c Check) bool { return !filter(c) })
+	h.mu.RUnlock()
+
+	results := make([]CheckResult, len(checks))
+	var wg sync.WaitGroup
+	for i, c := range checks {
+		wg.Add(1)
+		go func(i int, c Check) {
+			defer wg.Done()
+			results[i] = h.check(ctx, c)
+		}(i, c)
+	}
+	wg.Wait()
+
+	rv := Result{
+		Status:      StatusPass,
+		Version:     h.opts.Version,
+		ReleaseId:   h.opts.ReleaseId,
+		ServiceId:   h.opts.ServiceId,
+		Description: h.opts.Description,
+	}
+	if len(checks) > 0 {
+		rv.Checks = make(map[string][]CheckResult, len(checks))
+	}
+	for i, c := range checks {
+		rv.Checks[c.Name] = append(rv.Checks[c.Name], results[i])
+		rv.Status = worse(rv.Status, results[i].Status)
+	}
+	return rv
+}
+
+func (h *Health) check(ctx context.Context, c Check) CheckResult {
+	timeout := c.Timeout
+	if timeout <= 0 {
+		timeout = h.opts.Timeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	start := time.Now()
+	done := make(chan error, 1)
+	go func() {
+		done <- c.Checker.Check(ctx)
+	}()
+
+	var err error
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		err = errors.New("check timed out after " + timeout.String())
+	}
+
+	rv := CheckResult{
+		ComponentId:   c.ComponentId,
+		ComponentType: c.ComponentType,
+		ObservedValue: float64(time.Since(start).Microseconds()) / 1000,
+		ObservedUnit:  "ms",
+		Status:        StatusPass,
+		Time:          start,
+	}
+	if err != nil {
+		rv.Status = StatusWarn
+		if c.Critical {
+			rv.Status = StatusFail
+		}
+		rv.Output = err.Error()
+	}
+	return rv
+}
+
+// worse returns the worse of the statuses.
+func worse(a, b string) string {
+	rank := map[string]int{StatusPass: 0, StatusWarn: 1, StatusFail: 2}
+	if rank[b] > rank[a] {
+		return b
+	}
+	return a
+}
@@ -0,0 +1,57 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"demo/extension/kv"
+)
+
+func TestHealth(t *testing.T) {
+	h := New(Options{Version: "1", ReleaseId: "1.2.3", Timeout: 50 * time.Millisecond})
+	assert.Equal(t, StatusPass, h.Readiness(context.Background()).Status)
+
+	h.Register(
+		Check{Name: "kv:responseTime", Checker: KV(kv.NewMemory()), Critical: true, Liveness: true},
+		Check{Name: "cache:responseTime", Checker: CheckerFunc(func(context.Context) error {
+			return errors.New("cache is down")
+		})},
+	)
+
+	live := h.Liveness(context.Background())
+	assert.Equal(t, StatusPass, live.Status)
+	assert.Len(t, live.Checks, 1)
+	assert.Equal(t, "ms", live.Checks["kv:responseTime"][0].ObservedUnit)
+
+	ready := h.Readiness(context.Background())
+	assert.Equal(t, StatusWarn, ready.Status, "non-critical failures warn")
+	assert.Equal(t, "1.2.3", ready.ReleaseId)
+	assert.Equal(t, "cache is down", ready.Checks["cache:responseTime"][0].Output)
+
+	h.Register(Check{Name: "grpc:responseTime", Critical: true, Checker: CheckerFunc(func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})})
+	start := time.Now()
+	ready = h.Readiness(context.Background())
+	assert.Less(t, time.Since(start), time.Second)
+	assert.Equal(t, StatusFail, ready.Status)
+	assert.Contains(t, ready.Checks["grpc:responseTime"][0].Output, "timed out")
+
+	h.Drain("shutting down")
+	ready = h.Readiness(context.Background())
+	assert.Equal(t, StatusFail, ready.Status)
+	assert.Equal(t, "shutting down", ready.Output)
+	assert.Empty(t, ready.Checks)
+	assert.Equal(t, StatusPass, h.Liveness(context.Background()).Status, "the liveness is not affected")
+}
+
+func TestDiskSpace(t *testing.T) {
+	assert.NoError(t, DiskSpace(t.TempDir(), 1).Check(context.Background()))
+	assert.Error(t, DiskSpace(t.TempDir(), 1<<62).Check(context.Background()))
+	assert.Error(t, DiskSpace("/does/not/exist", 1).Check(context.Background()))
+}
@@ -0,0 +1,57 @@
+package jwt
+
+import (
+	"context"
+
+	"demo/extension/contextz"
+	"demo/extension/errorx"
+)
+
+// ClaimsMapping names the claims which are mapped into the principal.
+type ClaimsMapping struct {
+	UserId string
+	Tenant string
+	Roles  string
+	Scopes string
+}
+
+// DefaultClaimsMapping maps the subject, the tenant, roles and scope claims.
+func DefaultClaimsMapping() ClaimsMapping {
+	return ClaimsMapping{UserId: "sub", Tenant: "tenant", Roles: "roles", Scopes: "scope"}
+}
+
+// Principal maps the claims into a principal, the claims are kept as is.
+func (m ClaimsMapping) Principal(claims Claims) *contextz.Principal {
+	return &contextz.Principal{
+		UserId: claims.String(m.UserId),
+		Tenant: claims.String(m.Tenant),
+		Roles:  claims.Strings(m.Roles),
+		Scopes: claims.Strings(m.Scopes),
+		Claims: claims,
+	}
+}
+
+// Authenticator verifies the bearer tokens and maps them into principals.
+type Authenticator struct {
+	keys    KeySet
+	opts    VerifyOptions
+	mapping ClaimsMapping
+}
+
+func NewAuthenticator(keys KeySet, opts VerifyOptions, mapping ClaimsMapping) *Authenticator {
+	return &Authenticator{keys: keys, opts: opts, mapping: mapping}
+}
+
+// VerifyToken verifies the token, the failures are ErrInvalidToken.
+func (a *Authenticator) VerifyToken(ctx context.Context, raw string) (*contextz.Principal, error) {
+	token, err := Verify(ctx, raw, a.keys, a.opts)
+	if err != nil {
+		return nil, errorx.ErrInvalidToken.WithMessage(err.Error()).Wrap(err)
+	}
+
+	principal := a.mapping.Principal(token.Claims)
+	if principal.UserId == "" {
+		return nil, errorx.ErrInvalidToken.WithMessage("the token has no user id")
+	}
+	return principal, nil
+}
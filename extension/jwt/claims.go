@@ -0,0 +1,84 @@
+package jwt
+
+import (
+	"slices"
+	"strings"
+	"time"
+)
+
+// Claims is the payload of a token.
+type Claims map[string]any
+
+func (c Claims) Subject() string { return c.String("sub") }
+
+func (c Claims) Issuer() string { return c.String("iss") }
+
+// Audience returns the aud claim, which is either a string or an array.
+func (c Claims) Audience() []string { return c.Strings("aud") }
+
+// String returns a string claim, or "" if it is absent or not a string.
+func (c Claims) String(name string) string {
+	s, _ := c[name].(string)
+	return s
+}
+
+// Strings returns a claim which is an array of strings or a space separated
+// string such as scope.
+func (c Claims) Strings(name string) []string {
+	switch v := c[name].(type) {
+	case string:
+		return strings.Fields(v)
+	case []string:
+		return v
+	case []any:
+		rv := make([]string, 0, len(v))
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				rv = append(rv, s)
+			}
+		}
+		return rv
+	default:
+		return nil
+	}
+}
+
+// Time returns a NumericDate claim, the zero time if it is absent.
+func (c Claims) Time(name string) time.Time {
+	switch v := c[name].(type) {
+	case float64:
+		return time.Unix(int64(v), 0)
+	case int64:
+		return time.Unix(v, 0)
+	case int:
+		return time.Unix(int64(v), 0)
+	default:
+		return time.Time{}
+	}
+}
+
+func (c Claims) validate(opts VerifyOptions) error {
+	now := time.Now()
+	if opts.Now != nil {
+		now = opts.Now()
+	}
+
+	exp := c.Time("exp")
+	if exp.IsZero() && opts.RequireExpires {
+		return ErrMissingExpires
+	}
+	if !exp.IsZero() && !now.Before(exp.Add(opts.Leeway)) {
+		return ErrExpired
+	}
+	if nbf := c.Time("nbf"); !nbf.IsZero() && now.Add(opts.Leeway).Before(nbf) {
+		return ErrNotValidYet
+	}
+
+	if opts.Issuer != "" && c.Issuer() != opts.Issuer {
+		return ErrIssuer
+	}
+	if opts.Audience != "" && !slices.Contains(c.Audience(), opts.Audience) {
+		return ErrAudience
+	}
+	return nil
+}
@@ -0,0 +1,387 @@
+package jwt
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+)
+
+// KeySet looks up the verification key of a token.
+type KeySet interface {
+	// Key returns the key with the id for the algorithm, ErrKeyNotFound if
+	// there is none. kid may be empty if the token does not specify one.
+	Key(ctx context.Context, kid, alg string) (any, error)
+}
+
+// JWK is a JSON Web Key, the public RSA and EC P-256 keys and the symmetric
+// keys are supported.
+type JWK struct {
+	KeyType   string `json:"kty"`
+	KeyID     string `json:"kid,omitempty"`
+	Algorithm string `json:"alg,omitempty"`
+	Use       string `json:"use,omitempty"`
+
+	K string `json:"k,omitempty"`
+
+	N string `json:"n,omitempty"`
+	E string `json:"e,omitempty"`
+
+	Curve string `json:"crv,omitempty"`
+	X     string `json:"x,omitempty"`
+	Y     string `json:"y,omitempty"`
+}
+
+// JWKS is a JSON Web Key Set document.
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// NewJWK returns the JWK of a *rsa.PublicKey or an *ecdsa.PublicKey.
+func NewJWK(kid string, key any) (JWK, error) {
+	enc := base64.RawURLEncoding
+	switch k := key.(type) {
+	case *rsa.PublicKey:
+		return JWK{
+			KeyType:   "RSA",
+			KeyID:     kid,
+			Algorithm: RS256,
+			Use:       "sig",
+			N:         enc.EncodeToString(k.N.Bytes()),
+			E:         enc.EncodeToString(big.NewInt(int64(k.E)).Bytes()),
+		}, nil
+	case *ecdsa.PublicKey:
+		if k.Curve != elliptic.P256() {
+			return JWK{}, fmt.Errorf("jwt: unsupported curve %s", k.Curve.Params().Name)
+		}
+		x, y := make([]byte, 32), make([]byte, 32)
+		k.X.FillBytes(x)
+		k.Y.FillBytes(y)
+		return JWK{
+			KeyType:   "EC",
+			KeyID:     kid,
+			Algorithm: ES256,
+			Use:       "sig",
+			Curve:     "P-256",
+			X:         enc.EncodeToString(x),
+			Y:         enc.EncodeToString(y),
+		}, nil
+	default:
+		return JWK{}, fmt.Errorf("jwt: unsupported key type %T", key)
+	}
+}
+
+// Key decodes the key into a *rsa.PublicKey, an *ecdsa.PublicKey or the
+// []byte secret of a symmetric key.
+func (k JWK) Key() (any, error) {
+	dec := base64.RawURLEncoding
+	switch k.KeyType {
+	case "oct":
+		secret, err := dec.DecodeString(k.K)
+		if err != nil || len(secret) == 0 {
+			return nil, fmt.Errorf("jwt: invalid oct key %s", k.KeyID)
+		}
+		return secret, nil
+	case "RSA":
+		n, err := dec.DecodeString(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := dec.DecodeString(k.E)
+		if err != nil {
+			return nil, err
+		}
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}, nil
+	case "EC":
+		if k.Curve != "P-256" {
+			return nil, fmt.Errorf("jwt: unsupported curve %s", k.Curve)
+		}
+		x, err := dec.DecodeString(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := dec.DecodeString(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		key := &ecdsa.PublicKey{Curve: elliptic.P256(), X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}
+		if !key.Curve.IsOnCurve(key.X, key.Y) {
+			return nil, fmt.Errorf("jwt: invalid ec key %s", k.KeyID)
+		}
+		return key, nil
+	default:
+		return nil, fmt.Errorf("jwt: unsupported key type %s", k.KeyType)
+	}
+}
+
+type staticKey struct {
+	id  string
+	alg string
+	key any
+}
+
+// StaticKeySet is an immutable set of keys.
+type StaticKeySet struct {
+	keys []staticKey
+}
+
+// NewSecretKeySet returns a set of the HS256 shared secret.
+func NewSecretKeySet(kid string, secret []byte) *StaticKeySet {
+	return &StaticKeySet{keys: []staticKey{{id: kid, alg: HS256, key: secret}}}
+}
+
+// ParseJWKS parses a JWKS document, the keys which are not for signatures or
+// of an unsupported type are skipped.
+func ParseJWKS(data []byte) (*StaticKeySet, error) {
+	var doc JWKS
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("jwt: parse jwks: %w", err)
+	}
+
+	set := &StaticKeySet{}
+	for _, jwk := range doc.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+		key, err := jwk.Key()
+		if err != nil {
+			continue
+		}
+		set.keys = append(set.keys, staticKey{id: jwk.KeyID, alg: jwk.Algorithm, key: key})
+	}
+	return set, nil
+}
+
+func (s *StaticKeySet) Key(_ context.Context, kid, alg string) (any, error) {
+	var candidates []staticKey
+	for _, k := range s.keys {
+		if k.alg != "" && k.alg != alg {
+			continue
+		}
+		if kid != "" && k.id == kid {
+			return k.key, nil
+		}
+		candidates = append(candidates, k)
+	}
+	// the token or the key may have no id, they match only if the key is
+	// unambiguous
+	if len(candidates) == 1 && (kid == "" || candidates[0].id == "") {
+		return candidates[0].key, nil
+	}
+	return nil, ErrKeyNotFound
+}
+
+// MultiKeySet looks up a key in each of the sets in order.
+type MultiKeySet []KeySet
+
+func (m MultiKeySet) Key(ctx context.Context, kid, alg string) (any, error) {
+	var firstErr error
+	for _, set := range m {
+		key, err := set.Key(ctx, kid, alg)
+		if err == nil {
+			return key, nil
+		}
+		if firstErr == nil || errors.Is(firstErr, ErrKeyNotFound) {
+			firstErr = err
+		}
+	}
+	if firstErr == nil {
+		firstErr = ErrKeyNotFound
+	}
+	return nil, firstErr
+}
+
+// FileKeySet reads the keys from a JWKS file. The file is checked for changes
+// at most once per interval, so that the keys can be rotated by replacing it.
+type FileKeySet struct {
+	path     string
+	interval time.Duration
+
+	mu        sync.Mutex
+	keys      *StaticKeySet
+	modTime   time.Time
+	checkedAt time.Time
+}
+
+// NewFileKeySet reads the JWKS file, interval defaults to one minute.
+func NewFileKeySet(path string, interval time.Duration) (*FileKeySet, error) {
+	if interval <= 0 {
+		interval = time.Minute
+	}
+	s := &FileKeySet{path: path, interval: interval}
+	if err := s.reload(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *FileKeySet) Key(ctx context.Context, kid, alg string) (any, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if time.Since(s.checkedAt) >= s.interval {
+		// keep the current keys if the file is being replaced
+		_ = s.reload()
+	}
+	return s.keys.Key(ctx, kid, alg)
+}
+
+func (s *FileKeySet) reload() error {
+	s.checkedAt = time.Now()
+	info, err := os.Stat(s.path)
+	if err != nil {
+		return fmt.Errorf("jwt: stat jwks file: %w", err)
+	}
+	if s.keys != nil && info.ModTime().Equal(s.modTime) {
+		return nil
+	}
+
+	data, err := os.ReadFile(s.path)
+	if err != nil {
+		return fmt.Errorf("jwt: read jwks file: %w", err)
+	}
+	keys, err := ParseJWKS(data)
+	if err != nil {
+		return err
+	}
+	s.keys, s.modTime = keys, info.ModTime()
+	return nil
+}
+
+// RemoteOptions is the options of the RemoteKeySet.
+type RemoteOptions struct {
+	// Client defaults to http.DefaultClient.
+	Client *http.Client
+
+	// MinRefresh limits how often the keys are fetched again because of an
+	// unknown key id, defaults to one minute.
+	MinRefresh time.Duration
+
+	// MaxAge is the lifetime of the cached keys, zero keeps them until an
+	// unknown key id is seen.
+	MaxAge time.Duration
+}
+
+// RemoteKeySet fetches the keys from a JWKS URL and caches them. The keys are
+// fetched again when they are older than MaxAge or a token is signed by an
+// unknown key, so that the keys rotated by the issuer are picked up. The
+// cached keys are still used if a refresh fails.
+//
+// The keys are fetched at most once per MinRefresh, failed fetches included,
+// and ErrKeyNotFound is returned in between if no keys were fetched yet. The
+// fetch is done without holding the lock, the concurrent lookups wait for the
+// fetch in progress instead of starting their own.
+type RemoteKeySet struct {
+	url  string
+	opts RemoteOptions
+
+	mu          sync.Mutex
+	keys        *StaticKeySet
+	fetchedAt   time.Time
+	attemptedAt time.Time
+	fetching    chan struct{}
+}
+
+func NewRemoteKeySet(url string, opts RemoteOptions) *RemoteKeySet {
+	if opts.Client == nil {
+		opts.Client = http.DefaultClient
+	}
+	if opts.MinRefresh <= 0 {
+		opts.MinRefresh = time.Minute
+	}
+	return &RemoteKeySet{url: url, opts: opts}
+}
+
+func (s *RemoteKeySet) Key(ctx context.Context, kid, alg string) (any, error) {
+	keys, fetchedAt := s.cached()
+	if keys == nil || s.opts.MaxAge > 0 && time.Since(fetchedAt) >= s.opts.MaxAge {
+		if err := s.refresh(ctx); err != nil && keys == nil {
+			return nil, err
+		}
+		keys, _ = s.cached()
+	}
+	if keys == nil {
+		return nil, ErrKeyNotFound
+	}
+
+	key, err := keys.Key(ctx, kid, alg)
+	if !errors.Is(err, ErrKeyNotFound) {
+		return key, err
+	}
+	if err := s.refresh(ctx); err != nil {
+		return nil, err
+	}
+	keys, _ = s.cached()
+	return keys.Key(ctx, kid, alg)
+}
+
+func (s *RemoteKeySet) cached() (*StaticKeySet, time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.keys, s.fetchedAt
+}
+
+// refresh fetches the keys, or waits for the fetch in progress. It fails with
+// ErrKeyNotFound if a fetch was attempted within MinRefresh.
+func (s *RemoteKeySet) refresh(ctx context.Context) error {
+	s.mu.Lock()
+	if fetching := s.fetching; fetching != nil {
+		s.mu.Unlock()
+		select {
+		case <-fetching:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	if time.Since(s.attemptedAt) < s.opts.MinRefresh {
+		s.mu.Unlock()
+		return ErrKeyNotFound
+	}
+	s.attemptedAt = time.Now()
+	fetching := make(chan struct{})
+	s.fetching = fetching
+	s.mu.Unlock()
+
+	keys, err := s.fetch(ctx)
+
+	s.mu.Lock()
+	if err == nil {
+		s.keys, s.fetchedAt = keys, time.Now()
+	}
+	s.fetching = nil
+	s.mu.Unlock()
+	close(fetching)
+	return err
+}
+
+func (s *RemoteKeySet) fetch(ctx context.Context) (*StaticKeySet, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := s.opts.Client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("jwt: fetch jwks: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("jwt: fetch jwks: unexpected status %s", resp.Status)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, fmt.Errorf("jwt: fetch jwks: %w", err)
+	}
+	return ParseJWKS(data)
+}
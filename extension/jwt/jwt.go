@@ -0,0 +1,218 @@
+package jwt
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"slices"
+	"strings"
+	"time"
+)
+
+const (
+	HS256 = "HS256"
+	RS256 = "RS256"
+	ES256 = "ES256"
+)
+
+var (
+	ErrMalformed      = errors.New("jwt: token is malformed")
+	ErrAlgorithm      = errors.New("jwt: algorithm is not allowed")
+	ErrKeyNotFound    = errors.New("jwt: signing key is not found")
+	ErrSignature      = errors.New("jwt: signature is invalid")
+	ErrExpired        = errors.New("jwt: token is expired")
+	ErrNotValidYet    = errors.New("jwt: token is not valid yet")
+	ErrIssuer         = errors.New("jwt: issuer mismatch")
+	ErrAudience       = errors.New("jwt: audience mismatch")
+	ErrMissingExpires = errors.New("jwt: expiration time is required")
+)
+
+// Header is the JOSE header of a token.
+type Header struct {
+	Algorithm string `json:"alg"`
+	KeyID     string `json:"kid,omitempty"`
+	Type      string `json:"typ,omitempty"`
+}
+
+// Token is a verified token.
+type Token struct {
+	Header Header
+	Claims Claims
+	Raw    string
+}
+
+// VerifyOptions is the options of Verify.
+type VerifyOptions struct {
+	// Algorithms are the accepted signing algorithms, required.
+	Algorithms []string
+
+	// Issuer must equal the iss claim when it is not empty.
+	Issuer string
+
+	// Audience must be one of the aud claim when it is not empty.
+	Audience string
+
+	// RequireExpires rejects the tokens without the exp claim.
+	RequireExpires bool
+
+	// Leeway is the allowed clock skew of the time based claims.
+	Leeway time.Duration
+
+	// Now overrides the current time, mostly for tests.
+	Now func() time.Time
+}
+
+// Verify parses the token, verifies its signature with a key of the set and
+// validates the registered claims.
+func Verify(ctx context.Context, raw string, keys KeySet, opts VerifyOptions) (*Token, error) {
+	parts := strings.Split(raw, ".")
+	if len(parts) != 3 {
+		return nil, ErrMalformed
+	}
+
+	token := &Token{Raw: raw}
+	if err := decodeSegment(parts[0], &token.Header); err != nil {
+		return nil, err
+	}
+	if err := decodeSegment(parts[1], &token.Claims); err != nil {
+		return nil, err
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, ErrMalformed
+	}
+
+	if !slices.Contains(opts.Algorithms, token.Header.Algorithm) {
+		return nil, ErrAlgorithm
+	}
+	key, err := keys.Key(ctx, token.Header.KeyID, token.Header.Algorithm)
+	if err != nil {
+		return nil, err
+	}
+	if err := verifySignature(token.Header.Algorithm, key, parts[0]+"."+parts[1], signature); err != nil {
+		return nil, err
+	}
+
+	if err := token.Claims.validate(opts); err != nil {
+		return nil, err
+	}
+	return token, nil
+}
+
+// Sign creates a signed token, the key is a []byte secret for HS256, a
+// *rsa.PrivateKey for RS256 and an *ecdsa.PrivateKey for ES256.
+func Sign(alg, kid string, key any, claims Claims) (string, error) {
+	header, err := json.Marshal(Header{Algorithm: alg, KeyID: kid, Type: "JWT"})
+	if err != nil {
+		return "", err
+	}
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+
+	signingInput := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	signature, err := sign(alg, key, signingInput)
+	if err != nil {
+		return "", err
+	}
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
+
+func decodeSegment(segment string, v any) error {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return ErrMalformed
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return ErrMalformed
+	}
+	return nil
+}
+
+func sign(alg string, key any, signingInput string) ([]byte, error) {
+	digest := sha256.Sum256([]byte(signingInput))
+
+	switch alg {
+	case HS256:
+		k, ok := key.([]byte)
+		if !ok || len(k) == 0 {
+			return nil, fmt.Errorf("jwt: %s requires a secret", alg)
+		}
+		mac := hmac.New(sha256.New, k)
+		mac.Write([]byte(signingInput))
+		return mac.Sum(nil), nil
+	case RS256:
+		k, ok := key.(*rsa.PrivateKey)
+		if !ok {
+			return nil, fmt.Errorf("jwt: %s requires a rsa private key", alg)
+		}
+		return rsa.SignPKCS1v15(rand.Reader, k, crypto.SHA256, digest[:])
+	case ES256:
+		k, ok := key.(*ecdsa.PrivateKey)
+		if !ok {
+			return nil, fmt.Errorf("jwt: %s requires an ecdsa private key", alg)
+		}
+		r, s, err := ecdsa.Sign(rand.Reader, k, digest[:])
+		if err != nil {
+			return nil, err
+		}
+		// JWS uses the fixed size concatenation of r and s instead of ASN.1
+		signature := make([]byte, 64)
+		r.FillBytes(signature[:32])
+		s.FillBytes(signature[32:])
+		return signature, nil
+	default:
+		return nil, ErrAlgorithm
+	}
+}
+
+func verifySignature(alg string, key any, signingInput string, signature []byte) error {
+	digest := sha256.Sum256([]byte(signingInput))
+
+	switch alg {
+	case HS256:
+		k, ok := key.([]byte)
+		if !ok || len(k) == 0 {
+			return ErrKeyNotFound
+		}
+		mac := hmac.New(sha256.New, k)
+		mac.Write([]byte(signingInput))
+		if !hmac.Equal(mac.Sum(nil), signature) {
+			return ErrSignature
+		}
+	case RS256:
+		k, ok := key.(*rsa.PublicKey)
+		if !ok {
+			return ErrKeyNotFound
+		}
+		if rsa.VerifyPKCS1v15(k, crypto.SHA256, digest[:], signature) != nil {
+			return ErrSignature
+		}
+	case ES256:
+		k, ok := key.(*ecdsa.PublicKey)
+		if !ok {
+			return ErrKeyNotFound
+		}
+		if len(signature) != 64 {
+			return ErrSignature
+		}
+		r := new(big.Int).SetBytes(signature[:32])
+		s := new(big.Int).SetBytes(signature[32:])
+		if !ecdsa.Verify(k, digest[:], r, s) {
+			return ErrSignature
+		}
+	default:
+		return ErrAlgorithm
+	}
+	return nil
+}
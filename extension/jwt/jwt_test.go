@@ -0,0 +1,279 @@
+package jwt
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"demo/extension/errorx"
+)
+
+func jwksOf(t *testing.T, keys map[string]any) []byte {
+	var doc JWKS
+	for kid, key := range keys {
+		jwk, err := NewJWK(kid, key)
+		assert.NoError(t, err)
+		doc.Keys = append(doc.Keys, jwk)
+	}
+	data, err := json.Marshal(doc)
+	assert.NoError(t, err)
+	return data
+}
+
+func TestSignAndVerify(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.NoError(t, err)
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+
+	keys, err := ParseJWKS(jwksOf(t, map[string]any{"rsa": &rsaKey.PublicKey, "ec": &ecKey.PublicKey}))
+	assert.NoError(t, err)
+
+	now := time.Now()
+	claims := Claims{"iss": "issuer", "aud": []string{"api", "web"}, "sub": "u1", "scope": "read write", "exp": now.Add(time.Hour).Unix()}
+	opts := VerifyOptions{Algorithms: []string{RS256, ES256}, Issuer: "issuer", Audience: "api", RequireExpires: true}
+
+	for _, tc := range []struct {
+		alg, kid string
+		key      any
+	}{{RS256, "rsa", rsaKey}, {ES256, "ec", ecKey}} {
+		raw, err := Sign(tc.alg, tc.kid, tc.key, claims)
+		assert.NoError(t, err)
+
+		token, err := Verify(context.Background(), raw, keys, opts)
+		assert.NoError(t, err, tc.alg)
+		assert.Equal(t, tc.kid, token.Header.KeyID)
+		assert.Equal(t, "u1", token.Claims.Subject())
+		assert.Equal(t, []string{"api", "web"}, token.Claims.Audience())
+		assert.Equal(t, []string{"read", "write"}, token.Claims.Strings("scope"))
+
+		// tamper the payload
+		parts := strings.Split(raw, ".")
+		forged, _ := Sign(tc.alg, tc.kid, tc.key, Claims{"sub": "admin"})
+		parts[1] = strings.Split(forged, ".")[1]
+		_, err = Verify(context.Background(), strings.Join(parts, "."), keys, opts)
+		assert.ErrorIs(t, err, ErrSignature, tc.alg)
+	}
+}
+
+func TestVerifyClaims(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+	keys, err := ParseJWKS(jwksOf(t, map[string]any{"k1": &key.PublicKey}))
+	assert.NoError(t, err)
+
+	now := time.Now()
+	verify := func(claims Claims, opts VerifyOptions) error {
+		raw, err := Sign(ES256, "k1", key, claims)
+		assert.NoError(t, err)
+		if opts.Algorithms == nil {
+			opts.Algorithms = []string{ES256}
+		}
+		_, err = Verify(context.Background(), raw, keys, opts)
+		return err
+	}
+
+	assert.ErrorIs(t, verify(Claims{"exp": now.Add(-time.Minute).Unix()}, VerifyOptions{}), ErrExpired)
+	assert.NoError(t, verify(Claims{"exp": now.Add(-time.Minute).Unix()}, VerifyOptions{Leeway: 2 * time.Minute}))
+	assert.ErrorIs(t, verify(Claims{"nbf": now.Add(time.Hour).Unix()}, VerifyOptions{}), ErrNotValidYet)
+	assert.ErrorIs(t, verify(Claims{}, VerifyOptions{RequireExpires: true}), ErrMissingExpires)
+	assert.ErrorIs(t, verify(Claims{"iss": "other"}, VerifyOptions{Issuer: "issuer"}), ErrIssuer)
+	assert.ErrorIs(t, verify(Claims{"aud": "web"}, VerifyOptions{Audience: "api"}), ErrAudience)
+	assert.ErrorIs(t, verify(Claims{}, VerifyOptions{Algorithms: []string{RS256}}), ErrAlgorithm)
+
+	_, err = Verify(context.Background(), "not.a-token", keys, VerifyOptions{Algorithms: []string{ES256}})
+	assert.ErrorIs(t, err, ErrMalformed)
+}
+
+func TestRemoteKeySetRotation(t *testing.T) {
+	oldKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	newKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+
+	var (
+		fetches atomic.Int32
+		current atomic.Value
+	)
+	current.Store(jwksOf(t, map[string]any{"old": &oldKey.PublicKey}))
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fetches.Add(1)
+		_, _ = w.Write(current.Load().([]byte))
+	}))
+	defer srv.Close()
+
+	keys := NewRemoteKeySet(srv.URL, RemoteOptions{MinRefresh: time.Millisecond})
+	opts := VerifyOptions{Algorithms: []string{ES256}}
+
+	raw, _ := Sign(ES256, "old", oldKey, Claims{"sub": "u1"})
+	_, err := Verify(context.Background(), raw, keys, opts)
+	assert.NoError(t, err)
+	_, err = Verify(context.Background(), raw, keys, opts)
+	assert.NoError(t, err)
+	assert.Equal(t, int32(1), fetches.Load(), "the keys are cached")
+
+	// the issuer rotates its key
+	current.Store(jwksOf(t, map[string]any{"new": &newKey.PublicKey}))
+	time.Sleep(2 * time.Millisecond)
+	raw, _ = Sign(ES256, "new", newKey, Claims{"sub": "u1"})
+	_, err = Verify(context.Background(), raw, keys, opts)
+	assert.NoError(t, err)
+	assert.Equal(t, int32(2), fetches.Load())
+
+	// unknown keys do not refetch more often than the minimum interval
+	keys = NewRemoteKeySet(srv.URL, RemoteOptions{MinRefresh: time.Hour})
+	_, err = Verify(context.Background(), raw, keys, opts)
+	assert.NoError(t, err)
+	raw, _ = Sign(ES256, "unknown", oldKey, Claims{"sub": "u1"})
+	_, err = Verify(context.Background(), raw, keys, opts)
+	assert.ErrorIs(t, err, ErrKeyNotFound)
+	assert.Equal(t, int32(3), fetches.Load())
+}
+
+func TestRemoteKeySetUnavailable(t *testing.T) {
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	raw, _ := Sign(ES256, "k1", key, Claims{"sub": "u1"})
+	opts := VerifyOptions{Algorithms: []string{ES256}}
+
+	var (
+		fetches atomic.Int32
+		up      atomic.Bool
+	)
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fetches.Add(1)
+		<-release
+		if !up.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		_, _ = w.Write(jwksOf(t, map[string]any{"k1": &key.PublicKey}))
+	}))
+	defer srv.Close()
+
+	keys := NewRemoteKeySet(srv.URL, RemoteOptions{MinRefresh: 50 * time.Millisecond})
+
+	// the concurrent lookups share the slow first fetch
+	var wg sync.WaitGroup
+	errs := make([]error, 5)
+	for i := range errs {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			_, errs[i] = Verify(context.Background(), raw, keys, opts)
+		}(i)
+	}
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+	wg.Wait()
+	assert.Equal(t, int32(1), fetches.Load())
+	for _, err := range errs {
+		assert.Error(t, err)
+	}
+
+	// the failed fetch is not retried before the minimum interval
+	_, err := Verify(context.Background(), raw, keys, opts)
+	assert.ErrorIs(t, err, ErrKeyNotFound)
+	assert.Equal(t, int32(1), fetches.Load())
+
+	up.Store(true)
+	time.Sleep(60 * time.Millisecond)
+	_, err = Verify(context.Background(), raw, keys, opts)
+	assert.NoError(t, err)
+	assert.Equal(t, int32(2), fetches.Load())
+}
+
+func TestHS256(t *testing.T) {
+	keys := NewSecretKeySet("", []byte("secret"))
+	opts := VerifyOptions{Algorithms: []string{HS256}}
+
+	raw, err := Sign(HS256, "any", []byte("secret"), Claims{"sub": "u1"})
+	assert.NoError(t, err)
+	_, err = Verify(context.Background(), raw, keys, opts)
+	assert.NoError(t, err)
+
+	raw, _ = Sign(HS256, "", []byte("other"), Claims{"sub": "u1"})
+	_, err = Verify(context.Background(), raw, keys, opts)
+	assert.ErrorIs(t, err, ErrSignature)
+
+	// a rsa public key must not be usable as a HS256 secret
+	rsaKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	rsaKeys, _ := ParseJWKS(jwksOf(t, map[string]any{"rsa": &rsaKey.PublicKey}))
+	_, err = Verify(context.Background(), raw, rsaKeys, VerifyOptions{Algorithms: []string{HS256, RS256}})
+	assert.ErrorIs(t, err, ErrKeyNotFound)
+}
+
+func TestFileKeySetRotation(t *testing.T) {
+	oldKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	newKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+
+	file := filepath.Join(t.TempDir(), "jwks.json")
+	assert.NoError(t, os.WriteFile(file, jwksOf(t, map[string]any{"old": &oldKey.PublicKey}), 0o600))
+	keys, err := NewFileKeySet(file, time.Nanosecond)
+	assert.NoError(t, err)
+
+	opts := VerifyOptions{Algorithms: []string{ES256}}
+	raw, _ := Sign(ES256, "old", oldKey, Claims{"sub": "u1"})
+	_, err = Verify(context.Background(), raw, keys, opts)
+	assert.NoError(t, err)
+
+	assert.NoError(t, os.WriteFile(file, jwksOf(t, map[string]any{"new": &newKey.PublicKey}), 0o600))
+	assert.NoError(t, os.Chtimes(file, time.Now(), time.Now().Add(time.Second)))
+	raw, _ = Sign(ES256, "new", newKey, Claims{"sub": "u1"})
+	_, err = Verify(context.Background(), raw, keys, opts)
+	assert.NoError(t, err)
+
+	// a broken file keeps the current keys
+	assert.NoError(t, os.WriteFile(file, []byte("{"), 0o600))
+	assert.NoError(t, os.Chtimes(file, time.Now(), time.Now().Add(2*time.Second)))
+	_, err = Verify(context.Background(), raw, keys, opts)
+	assert.NoError(t, err)
+
+	_, err = NewFileKeySet(filepath.Join(t.TempDir(), "missing.json"), 0)
+	assert.Error(t, err)
+}
+
+func TestAuthenticator(t *testing.T) {
+	secret := []byte("secret")
+	auth := NewAuthenticator(
+		MultiKeySet{NewSecretKeySet("", secret)},
+		VerifyOptions{Algorithms: []string{HS256}, Issuer: "issuer", RequireExpires: true},
+		DefaultClaimsMapping(),
+	)
+
+	exp := time.Now().Add(time.Hour).Unix()
+	raw, _ := Sign(HS256, "", secret, Claims{
+		"iss": "issuer", "exp": exp, "sub": "u1", "tenant": "t1",
+		"roles": []string{"admin"}, "scope": "orders:read orders:write",
+	})
+	principal, err := auth.VerifyToken(context.Background(), raw)
+	assert.NoError(t, err)
+	assert.Equal(t, "u1", principal.UserId)
+	assert.Equal(t, "t1", principal.Tenant)
+	assert.Equal(t, []string{"admin"}, principal.Roles)
+	assert.True(t, principal.HasScope("orders:write"))
+
+	for _, claims := range []Claims{
+		{"iss": "issuer", "sub": "u1"},
+		{"iss": "other", "exp": exp, "sub": "u1"},
+		{"iss": "issuer", "exp": exp},
+	} {
+		raw, _ = Sign(HS256, "", secret, claims)
+		_, err = auth.VerifyToken(context.Background(), raw)
+		var ex errorx.Error
+		assert.ErrorAs(t, err, &ex)
+		assert.Equal(t, errorx.ErrInvalidToken.Code, ex.Code)
+	}
+}
@@ -0,0 +1,208 @@
+package kv
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// File keeps each key in a file of the directory, so that the keys survive a
+// restart. It is safe for one process only.
+type File struct {
+	dir string
+
+	mu      sync.Mutex
+	sweptAt time.Time
+}
+
+// NewFile creates the directory if it does not exist.
+func NewFile(dir string) (*File, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("kv: create directory: %w", err)
+	}
+	return &File{dir: dir, sweptAt: time.Now()}, nil
+}
+
+func (f *File) Get(_ context.Context, key string) ([]byte, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	e, err := f.read(key)
+	if err != nil {
+		return nil, err
+	}
+	return e.Value, nil
+}
+
+func (f *File) GetDel(_ context.Context, key string) ([]byte, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	e, err := f.read(key)
+	if err != nil {
+		return nil, err
+	}
+	if err := f.remove(key); err != nil {
+		return nil, err
+	}
+	return e.Value, nil
+}
+
+func (f *File) Set(_ context.Context, key string, value []byte, ttl time.Duration) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.sweep()
+	return f.write(key, newEntry(value, ttl))
+}
+
+func (f *File) Del(_ context.Context, keys ...string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for _, key := range keys {
+		if err := f.remove(key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (f *File) Incr(_ context.Context, key string, ttl time.Duration) (int64, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	e, err := f.read(key)
+	if errors.Is(err, ErrNil) {
+		f.sweep()
+		e, err = newEntry(nil, ttl), nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	n, err := incr(e.Value)
+	if err != nil {
+		return 0, err
+	}
+	e.Value = []byte(strconv.FormatInt(n, 10))
+	return n, f.write(key, e)
+}
+
+func (f *File) CompareAndSwap(_ context.Context, key string, old, value []byte, ttl time.Duration) (bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	e, err := f.read(key)
+	if errors.Is(err, ErrNil) {
+		if old != nil {
+			return false, nil
+		}
+		f.sweep()
+		return true, f.write(key, newEntry(value, ttl))
+	}
+	if err != nil {
+		return false, err
+	}
+	if old == nil || !bytes.Equal(e.Value, old) {
+		return false, nil
+	}
+	return true, f.write(key, newEntry(value, ttl))
+}
+
+// path hashes the key so that any key is a valid file name.
+func (f *File) path(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return filepath.Join(f.dir, hex.EncodeToString(sum[:]))
+}
+
+func (f *File) read(key string) (entry, error) {
+	e, err := readEntry(f.path(key))
+	if err != nil {
+		return entry{}, err
+	}
+	if e.expired(time.Now()) {
+		_ = f.remove(key)
+		return entry{}, ErrNil
+	}
+	return e, nil
+}
+
+func (f *File) write(key string, e entry) error {
+	data, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	// write a temporary file and rename it, so that a crash never leaves a
+	// partial value behind
+	tmp, err := os.CreateTemp(f.dir, ".tmp-*")
+	if err != nil {
+		return fmt.Errorf("kv: write: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("kv: write: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("kv: write: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), f.path(key)); err != nil {
+		return fmt.Errorf("kv: write: %w", err)
+	}
+	return nil
+}
+
+func (f *File) remove(key string) error {
+	if err := os.Remove(f.path(key)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("kv: delete: %w", err)
+	}
+	return nil
+}
+
+// sweep removes the files of the expired keys which are never read again.
+func (f *File) sweep() {
+	now := time.Now()
+	if now.Sub(f.sweptAt) < sweepInterval {
+		return
+	}
+	f.sweptAt = now
+
+	files, err := os.ReadDir(f.dir)
+	if err != nil {
+		return
+	}
+	for _, file := range files {
+		if file.IsDir() {
+			continue
+		}
+		path := filepath.Join(f.dir, file.Name())
+		if e, err := readEntry(path); err == nil && e.expired(now) {
+			_ = os.Remove(path)
+		}
+	}
+}
+
+func readEntry(path string) (entry, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return entry{}, ErrNil
+	}
+	if err != nil {
+		return entry{}, fmt.Errorf("kv: read: %w", err)
+	}
+	var e entry
+	if err := json.Unmarshal(data, &e); err != nil {
+		return entry{}, fmt.Errorf("kv: read: %w", err)
+	}
+	return e, nil
+}
@@ -0,0 +1,63 @@
+// Package kv provides a small key value store with the semantics of the Redis
+// commands it is named after, so that a Redis client can be adapted to it
+// without changing the callers.
+package kv
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+var (
+	// ErrNil is returned by Get and GetDel for a missing or expired key, like
+	// the nil reply of Redis.
+	ErrNil = errors.New("kv: nil")
+
+	// ErrNotInteger is returned by Incr if the value is not a counter.
+	ErrNotInteger = errors.New("kv: value is not an integer")
+)
+
+type KV interface {
+	// Get returns the value of the key.
+	Get(ctx context.Context, key string) ([]byte, error)
+
+	// GetDel returns the value of the key and deletes it.
+	GetDel(ctx context.Context, key string) ([]byte, error)
+
+	// Set sets the value of the key, a zero ttl keeps it until it is deleted.
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+
+	// Del deletes the keys, the missing keys are ignored.
+	Del(ctx context.Context, keys ...string) error
+
+	// Incr increments the counter of the key and returns its new value. The
+	// ttl is set only when the counter is created, as INCR followed by
+	// EXPIRE NX does.
+	Incr(ctx context.Context, key string, ttl time.Duration) (int64, error)
+
+	// CompareAndSwap sets the value of the key only if its current value is
+	// old, a nil old expects a missing key, and reports whether it was set. A
+	// Redis client runs it as a script comparing the reply of GET with old.
+	CompareAndSwap(ctx context.Context, key string, old, value []byte, ttl time.Duration) (bool, error)
+}
+
+// sweepInterval is how often the expired keys are removed.
+const sweepInterval = time.Minute
+
+type entry struct {
+	Value     []byte    `json:"value"`
+	ExpiresAt time.Time `json:"expires_at,omitempty"`
+}
+
+func newEntry(value []byte, ttl time.Duration) entry {
+	e := entry{Value: value}
+	if ttl > 0 {
+		e.ExpiresAt = time.Now().Add(ttl)
+	}
+	return e
+}
+
+func (e entry) expired(now time.Time) bool {
+	return !e.ExpiresAt.IsZero() && !now.Before(e.ExpiresAt)
+}
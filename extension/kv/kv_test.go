@@ -0,0 +1,82 @@
+package kv
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func testKV(t *testing.T, store KV) {
+	ctx := context.Background()
+
+	_, err := store.Get(ctx, "missing")
+	assert.ErrorIs(t, err, ErrNil)
+
+	assert.NoError(t, store.Set(ctx, "a", []byte("1"), 0))
+	value, err := store.Get(ctx, "a")
+	assert.NoError(t, err)
+	assert.Equal(t, "1", string(value))
+
+	value, err = store.GetDel(ctx, "a")
+	assert.NoError(t, err)
+	assert.Equal(t, "1", string(value))
+	_, err = store.GetDel(ctx, "a")
+	assert.ErrorIs(t, err, ErrNil)
+
+	assert.NoError(t, store.Set(ctx, "short", []byte("x"), 20*time.Millisecond))
+	time.Sleep(30 * time.Millisecond)
+	_, err = store.Get(ctx, "short")
+	assert.ErrorIs(t, err, ErrNil)
+
+	n, err := store.Incr(ctx, "counter", time.Hour)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), n)
+	n, err = store.Incr(ctx, "counter", time.Hour)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), n)
+
+	assert.NoError(t, store.Set(ctx, "text", []byte("abc"), 0))
+	_, err = store.Incr(ctx, "text", 0)
+	assert.ErrorIs(t, err, ErrNotInteger)
+
+	ok, err := store.CompareAndSwap(ctx, "cas", []byte("0"), []byte("1"), time.Hour)
+	assert.NoError(t, err)
+	assert.False(t, ok, "the key is missing")
+	ok, err = store.CompareAndSwap(ctx, "cas", nil, []byte("1"), time.Hour)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	ok, err = store.CompareAndSwap(ctx, "cas", nil, []byte("2"), time.Hour)
+	assert.NoError(t, err)
+	assert.False(t, ok, "the key exists")
+	ok, err = store.CompareAndSwap(ctx, "cas", []byte("1"), []byte("2"), time.Hour)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	value, err = store.Get(ctx, "cas")
+	assert.NoError(t, err)
+	assert.Equal(t, "2", string(value))
+
+	assert.NoError(t, store.Del(ctx, "counter", "text", "cas", "missing"))
+	_, err = store.Get(ctx, "counter")
+	assert.ErrorIs(t, err, ErrNil)
+}
+
+func TestMemory(t *testing.T) {
+	testKV(t, NewMemory())
+}
+
+func TestFile(t *testing.T) {
+	dir := t.TempDir()
+	store, err := NewFile(dir)
+	assert.NoError(t, err)
+	testKV(t, store)
+
+	// the keys survive a restart
+	assert.NoError(t, store.Set(context.Background(), "persistent", []byte("v"), 0))
+	reopened, err := NewFile(dir)
+	assert.NoError(t, err)
+	value, err := reopened.Get(context.Background(), "persistent")
+	assert.NoError(t, err)
+	assert.Equal(t, "v", string(value))
+}
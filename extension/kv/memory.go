@@ -0,0 +1,127 @@
+package kv
+
+import (
+	"bytes"
+	"context"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Memory keeps the keys in memory, they are lost when the process exits.
+type Memory struct {
+	mu      sync.Mutex
+	entries map[string]entry
+	sweptAt time.Time
+}
+
+func NewMemory() *Memory {
+	return &Memory{entries: make(map[string]entry), sweptAt: time.Now()}
+}
+
+func (m *Memory) Get(_ context.Context, key string) ([]byte, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	e, ok := m.get(key)
+	if !ok {
+		return nil, ErrNil
+	}
+	return e.Value, nil
+}
+
+func (m *Memory) GetDel(_ context.Context, key string) ([]byte, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	e, ok := m.get(key)
+	if !ok {
+		return nil, ErrNil
+	}
+	delete(m.entries, key)
+	return e.Value, nil
+}
+
+func (m *Memory) Set(_ context.Context, key string, value []byte, ttl time.Duration) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.sweep()
+	m.entries[key] = newEntry(append([]byte(nil), value...), ttl)
+	return nil
+}
+
+func (m *Memory) Del(_ context.Context, keys ...string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, key := range keys {
+		delete(m.entries, key)
+	}
+	return nil
+}
+
+func (m *Memory) Incr(_ context.Context, key string, ttl time.Duration) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	e, ok := m.get(key)
+	if !ok {
+		m.sweep()
+		e = newEntry(nil, ttl)
+	}
+	n, err := incr(e.Value)
+	if err != nil {
+		return 0, err
+	}
+	e.Value = []byte(strconv.FormatInt(n, 10))
+	m.entries[key] = e
+	return n, nil
+}
+
+func (m *Memory) CompareAndSwap(_ context.Context, key string, old, value []byte, ttl time.Duration) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	e, ok := m.get(key)
+	if ok != (old != nil) || ok && !bytes.Equal(e.Value, old) {
+		return false, nil
+	}
+	m.sweep()
+	m.entries[key] = newEntry(append([]byte(nil), value...), ttl)
+	return true, nil
+}
+
+func (m *Memory) get(key string) (entry, bool) {
+	e, ok := m.entries[key]
+	if ok && e.expired(time.Now()) {
+		delete(m.entries, key)
+		return entry{}, false
+	}
+	return e, ok
+}
+
+// sweep removes the expired keys which are never read again.
+func (m *Memory) sweep() {
+	now := time.Now()
+	if now.Sub(m.sweptAt) < sweepInterval {
+		return
+	}
+	m.sweptAt = now
+	for key, e := range m.entries {
+		if e.expired(now) {
+			delete(m.entries, key)
+		}
+	}
+}
+
+func incr(value []byte) (int64, error) {
+	if len(value) == 0 {
+		return 1, nil
+	}
+	n, err := strconv.ParseInt(string(value), 10, 64)
+	if err != nil {
+		return 0, ErrNotInteger
+	}
+	return n + 1, nil
+}
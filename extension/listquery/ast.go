@@ -0,0 +1,68 @@
+package listquery
+
+// Direction is the direction of a sort key.
+type Direction int
+
+const (
+	Asc Direction = iota
+	Desc
+)
+
+func (d Direction) String() string {
+	if d == Desc {
+		return "desc"
+	}
+	return "asc"
+}
+
+// Sort is a sort key of a list query.
+type Sort struct {
+	Field     string
+	Column    string
+	Direction Direction
+}
+
+// Filter is a filter condition of a list query. Values are typed according to
+// the field type: string, int64, float64, bool or time.Time.
+type Filter struct {
+	Field    string
+	Column   string
+	Operator Operator
+	Values   []any
+}
+
+// Value returns the first value of the filter.
+func (f Filter) Value() any {
+	if len(f.Values) == 0 {
+		return nil
+	}
+	return f.Values[0]
+}
+
+// Query is the parsed sort and filter of a list request. Filters are
+// combined with AND.
+type Query struct {
+	Sorts   []Sort
+	Filters []Filter
+}
+
+// Translator translates a Query into a storage specific representation.
+type Translator interface {
+	Filter(f Filter) error
+	Sort(s Sort) error
+}
+
+// Translate walks the filters and then the sorts of the query in order.
+func (q Query) Translate(t Translator) error {
+	for _, f := range q.Filters {
+		if err := t.Filter(f); err != nil {
+			return err
+		}
+	}
+	for _, s := range q.Sorts {
+		if err := t.Sort(s); err != nil {
+			return err
+		}
+	}
+	return nil
+}
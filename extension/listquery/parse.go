@@ -0,0 +1,205 @@
+package listquery
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"demo/extension/errorx"
+)
+
+const (
+	SortParam   = "sort"
+	FilterParam = "filter"
+)
+
+// FieldError describes why a sort or filter on a field is invalid.
+type FieldError struct {
+	Field  string `json:"field"`
+	Reason string `json:"reason"`
+}
+
+func (e FieldError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Field, e.Reason)
+}
+
+// FieldErrors is a list of FieldError.
+type FieldErrors []FieldError
+
+func (e FieldErrors) Error() string {
+	messages := make([]string, 0, len(e))
+	for _, fe := range e {
+		messages = append(messages, fe.Error())
+	}
+	return strings.Join(messages, "; ")
+}
+
+func illegalArgument(errs FieldErrors) error {
+	return errorx.ErrIllegalArgument.WithMessage(errs.Error()).Wrap(errs)
+}
+
+// Bind parses the sort and filter query params of the request.
+func Bind(c *gin.Context, s *Schema) (Query, error) {
+	return s.Parse(c.Request.URL.Query())
+}
+
+// Parse parses the "sort" param and the repeated "filter" params. The errors
+// of all fields are collected and returned as errorx.ErrIllegalArgument.
+func (s *Schema) Parse(values url.Values) (Query, error) {
+	var (
+		q    Query
+		errs FieldErrors
+	)
+
+	if expr := values.Get(SortParam); expr != "" {
+		sorts, err := s.parseSort(expr)
+		errs = append(errs, err...)
+		q.Sorts = sorts
+	} else {
+		q.Sorts = append(q.Sorts, s.DefaultSort...)
+	}
+
+	filters := values[FilterParam]
+	if s.MaxFilters > 0 && len(filters) > s.MaxFilters {
+		errs = append(errs, FieldError{Field: FilterParam, Reason: fmt.Sprintf("at most %d filters are allowed", s.MaxFilters)})
+	}
+	for _, expr := range filters {
+		f, err := s.parseFilter(expr)
+		if err != nil {
+			errs = append(errs, *err)
+			continue
+		}
+		q.Filters = append(q.Filters, f)
+	}
+
+	if len(errs) > 0 {
+		return Query{}, illegalArgument(errs)
+	}
+	return q, nil
+}
+
+// ParseSort parses a sort expression such as "-created_at,name", a leading "-"
+// means descending order.
+func (s *Schema) ParseSort(expr string) ([]Sort, error) {
+	sorts, errs := s.parseSort(expr)
+	if len(errs) > 0 {
+		return nil, illegalArgument(errs)
+	}
+	return sorts, nil
+}
+
+// ParseFilter parses a filter expression "field:operator:value", the value of
+// the "in" and "nin" operators is a comma separated list.
+func (s *Schema) ParseFilter(expr string) (Filter, error) {
+	f, err := s.parseFilter(expr)
+	if err != nil {
+		return Filter{}, illegalArgument(FieldErrors{*err})
+	}
+	return f, nil
+}
+
+func (s *Schema) parseSort(expr string) ([]Sort, FieldErrors) {
+	var (
+		sorts []Sort
+		errs  FieldErrors
+		seen  = make(map[string]bool)
+	)
+
+	keys := strings.Split(expr, ",")
+	if s.MaxSorts > 0 && len(keys) > s.MaxSorts {
+		return nil, FieldErrors{{Field: SortParam, Reason: fmt.Sprintf("at most %d sort keys are allowed", s.MaxSorts)}}
+	}
+
+	for _, key := range keys {
+		key = strings.TrimSpace(key)
+		direction := Asc
+		switch {
+		case strings.HasPrefix(key, "-"):
+			direction, key = Desc, key[1:]
+		case strings.HasPrefix(key, "+"):
+			key = key[1:]
+		}
+
+		f, ok := s.fields[key]
+		switch {
+		case key == "":
+			errs = append(errs, FieldError{Field: SortParam, Reason: "empty sort key"})
+		case !ok || !f.Sortable:
+			errs = append(errs, FieldError{Field: key, Reason: "field is not sortable"})
+		case seen[key]:
+			errs = append(errs, FieldError{Field: key, Reason: "duplicate sort key"})
+		default:
+			seen[key] = true
+			sorts = append(sorts, Sort{Field: f.Name, Column: f.column(), Direction: direction})
+		}
+	}
+
+	return sorts, errs
+}
+
+func (s *Schema) parseFilter(expr string) (Filter, *FieldError) {
+	parts := strings.SplitN(expr, ":", 3)
+	if len(parts) != 3 {
+		return Filter{}, &FieldError{Field: FilterParam, Reason: fmt.Sprintf("filter %q must be in the form field:operator:value", expr)}
+	}
+
+	name, op, raw := parts[0], Operator(parts[1]), parts[2]
+	f, ok := s.fields[name]
+	if !ok || len(f.Operators) == 0 {
+		return Filter{}, &FieldError{Field: name, Reason: "field is not filterable"}
+	}
+	if !f.allows(op) {
+		return Filter{}, &FieldError{Field: name, Reason: fmt.Sprintf("operator %q is not allowed", op)}
+	}
+
+	var rawValues []string
+	if op.multiValue() {
+		rawValues = strings.Split(raw, ",")
+	} else {
+		rawValues = []string{raw}
+	}
+
+	values := make([]any, 0, len(rawValues))
+	for _, rv := range rawValues {
+		var (
+			v   any
+			err error
+		)
+		if op == OpIsNull {
+			v, err = strconv.ParseBool(rv)
+		} else {
+			v, err = parseValue(f.Type, rv)
+		}
+		if err != nil {
+			return Filter{}, &FieldError{Field: name, Reason: fmt.Sprintf("invalid value %q", rv)}
+		}
+		values = append(values, v)
+	}
+
+	return Filter{Field: f.Name, Column: f.column(), Operator: op, Values: values}, nil
+}
+
+func parseValue(t FieldType, raw string) (any, error) {
+	switch t {
+	case TypeInt:
+		return strconv.ParseInt(raw, 10, 64)
+	case TypeFloat:
+		return strconv.ParseFloat(raw, 64)
+	case TypeBool:
+		return strconv.ParseBool(raw)
+	case TypeTime:
+		if v, err := time.Parse(time.RFC3339, raw); err == nil {
+			return v, nil
+		}
+		return time.Parse(time.DateOnly, raw)
+	default:
+		if raw == "" {
+			return nil, fmt.Errorf("empty value")
+		}
+		return raw, nil
+	}
+}
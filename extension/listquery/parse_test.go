@@ -0,0 +1,106 @@
+package listquery
+
+import (
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"demo/extension/errorx"
+)
+
+var orderSchema = NewSchema(
+	Field{Name: "name", Type: TypeString, Sortable: true, Operators: []Operator{OpEq, OpLike}},
+	Field{Name: "status", Type: TypeString, Operators: []Operator{OpEq, OpIn}},
+	Field{Name: "amount", Type: TypeInt, Sortable: true, Operators: []Operator{OpGte, OpLte}},
+	Field{Name: "created_at", Column: "create_time", Type: TypeTime, Sortable: true, Operators: []Operator{OpGte, OpLt}},
+).WithDefaultSort("-created_at")
+
+func TestParse(t *testing.T) {
+	values := url.Values{
+		"sort":   {"-created_at,name"},
+		"filter": {"status:in:paid,shipped", "created_at:gte:2024-01-02T15:04:05Z", "amount:lte:100"},
+	}
+
+	q, err := orderSchema.Parse(values)
+	assert.NoError(t, err)
+	assert.Equal(t, []Sort{
+		{Field: "created_at", Column: "create_time", Direction: Desc},
+		{Field: "name", Column: "name", Direction: Asc},
+	}, q.Sorts)
+	assert.Equal(t, []Filter{
+		{Field: "status", Column: "status", Operator: OpIn, Values: []any{"paid", "shipped"}},
+		{Field: "created_at", Column: "create_time", Operator: OpGte, Values: []any{time.Date(2024, 1, 2, 15, 4, 5, 0, time.UTC)}},
+		{Field: "amount", Column: "amount", Operator: OpLte, Values: []any{int64(100)}},
+	}, q.Filters)
+
+	where, args, orderBy, err := ToSQL(q)
+	assert.NoError(t, err)
+	assert.Equal(t, "status IN (?, ?) AND create_time >= ? AND amount <= ?", where)
+	assert.Len(t, args, 4)
+	assert.Equal(t, "create_time DESC, name ASC", orderBy)
+}
+
+func TestToSQLLike(t *testing.T) {
+	q, err := orderSchema.Parse(url.Values{"filter": {`name:like:50%_off\`}})
+	assert.NoError(t, err)
+
+	where, args, _, err := ToSQL(q)
+	assert.NoError(t, err)
+	assert.Equal(t, `name LIKE ? ESCAPE '\'`, where)
+	// the wildcards of the value are matched literally
+	assert.Equal(t, []any{`%50\%\_off\\%`}, args)
+}
+
+func TestParseDefaultSort(t *testing.T) {
+	q, err := orderSchema.Parse(url.Values{})
+	assert.NoError(t, err)
+	assert.Equal(t, orderSchema.DefaultSort, q.Sorts)
+}
+
+func TestParseErrors(t *testing.T) {
+	testcases := []struct {
+		name   string
+		values url.Values
+		want   FieldErrors
+	}{
+		{
+			name:   "not sortable",
+			values: url.Values{"sort": {"status,-name,name"}},
+			want: FieldErrors{
+				{Field: "status", Reason: "field is not sortable"},
+				{Field: "name", Reason: "duplicate sort key"},
+			},
+		},
+		{
+			name:   "unknown field",
+			values: url.Values{"filter": {"password:eq:x"}},
+			want:   FieldErrors{{Field: "password", Reason: "field is not filterable"}},
+		},
+		{
+			name:   "operator not allowed",
+			values: url.Values{"filter": {"status:gte:paid"}},
+			want:   FieldErrors{{Field: "status", Reason: `operator "gte" is not allowed`}},
+		},
+		{
+			name:   "invalid value",
+			values: url.Values{"filter": {"amount:gte:abc", "status"}},
+			want: FieldErrors{
+				{Field: "amount", Reason: `invalid value "abc"`},
+				{Field: "filter", Reason: `filter "status" must be in the form field:operator:value`},
+			},
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := orderSchema.Parse(tc.values)
+			assert.True(t, errorx.ErrIllegalArgument.Is(err))
+
+			var errs FieldErrors
+			assert.ErrorAs(t, err, &errs)
+			assert.Equal(t, tc.want, errs)
+		})
+	}
+}
@@ -0,0 +1,105 @@
+package listquery
+
+// FieldType is the type of the values of a field.
+type FieldType int
+
+const (
+	TypeString FieldType = iota
+	TypeInt
+	TypeFloat
+	TypeBool
+	TypeTime
+)
+
+// Operator is a filter operator.
+type Operator string
+
+const (
+	OpEq     Operator = "eq"
+	OpNe     Operator = "ne"
+	OpGt     Operator = "gt"
+	OpGte    Operator = "gte"
+	OpLt     Operator = "lt"
+	OpLte    Operator = "lte"
+	OpIn     Operator = "in"
+	OpNotIn  Operator = "nin"
+	OpLike   Operator = "like"
+	OpIsNull Operator = "null"
+)
+
+// multiValue reports whether the operator accepts a comma separated list.
+func (op Operator) multiValue() bool {
+	return op == OpIn || op == OpNotIn
+}
+
+// Field describes a field of a resource which can be sorted or filtered.
+type Field struct {
+	// Name is the name used in the query string.
+	Name string
+
+	// Column is the name used by the storage backend, defaults to Name.
+	Column string
+
+	Type     FieldType
+	Sortable bool
+
+	// Operators are the allowed filter operators, the field can not be
+	// filtered if it is empty.
+	Operators []Operator
+}
+
+func (f Field) column() string {
+	if f.Column == "" {
+		return f.Name
+	}
+	return f.Column
+}
+
+func (f Field) allows(op Operator) bool {
+	for _, o := range f.Operators {
+		if o == op {
+			return true
+		}
+	}
+	return false
+}
+
+// Schema is the whitelist of the fields of a resource.
+type Schema struct {
+	fields map[string]Field
+
+	// MaxSorts limits the number of sort keys, zero means no limit.
+	MaxSorts int
+
+	// MaxFilters limits the number of filters, zero means no limit.
+	MaxFilters int
+
+	// DefaultSort is used when the request does not specify a sort.
+	DefaultSort []Sort
+}
+
+// NewSchema creates a Schema with the given fields.
+func NewSchema(fields ...Field) *Schema {
+	s := &Schema{fields: make(map[string]Field, len(fields))}
+	for _, f := range fields {
+		s.fields[f.Name] = f
+	}
+	return s
+}
+
+// WithDefaultSort sets the default sort expression, it panics if the
+// expression is not valid for the schema.
+func (s *Schema) WithDefaultSort(expr string) *Schema {
+	sorts, err := s.ParseSort(expr)
+	if err != nil {
+		panic(err)
+	}
+	s.DefaultSort = sorts
+	return s
+}
+
+// Field returns the field with the given name.
+func (s *Schema) Field(name string) (Field, bool) {
+	f, ok := s.fields[name]
+	return f, ok
+}
@@ -0,0 +1,69 @@
+package listquery
+
+import (
+	"fmt"
+	"strings"
+)
+
+// SQLTranslator translates a Query into a SQL WHERE and ORDER BY clause with
+// "?" placeholders. Columns come from the Schema whitelist, never from the
+// request, so they are safe to be interpolated.
+type SQLTranslator struct {
+	conditions []string
+	orders     []string
+	args       []any
+}
+
+// ToSQL translates the query, where and orderBy are empty if the query has no
+// filters or sorts respectively.
+func ToSQL(q Query) (where string, args []any, orderBy string, err error) {
+	t := &SQLTranslator{}
+	if err := q.Translate(t); err != nil {
+		return "", nil, "", err
+	}
+	return strings.Join(t.conditions, " AND "), t.args, strings.Join(t.orders, ", "), nil
+}
+
+func (t *SQLTranslator) Filter(f Filter) error {
+	switch f.Operator {
+	case OpEq, OpNe, OpGt, OpGte, OpLt, OpLte:
+		t.conditions = append(t.conditions, fmt.Sprintf("%s %s ?", f.Column, sqlOperators[f.Operator]))
+		t.args = append(t.args, f.Value())
+	case OpLike:
+		t.conditions = append(t.conditions, fmt.Sprintf(`%s LIKE ? ESCAPE '\'`, f.Column))
+		t.args = append(t.args, "%"+likeEscaper.Replace(fmt.Sprint(f.Value()))+"%")
+	case OpIn, OpNotIn:
+		placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(f.Values)), ", ")
+		t.conditions = append(t.conditions, fmt.Sprintf("%s %s (%s)", f.Column, sqlOperators[f.Operator], placeholders))
+		t.args = append(t.args, f.Values...)
+	case OpIsNull:
+		if isNull, _ := f.Value().(bool); isNull {
+			t.conditions = append(t.conditions, fmt.Sprintf("%s IS NULL", f.Column))
+		} else {
+			t.conditions = append(t.conditions, fmt.Sprintf("%s IS NOT NULL", f.Column))
+		}
+	default:
+		return fmt.Errorf("listquery: unsupported operator %q", f.Operator)
+	}
+	return nil
+}
+
+func (t *SQLTranslator) Sort(s Sort) error {
+	t.orders = append(t.orders, fmt.Sprintf("%s %s", s.Column, strings.ToUpper(s.Direction.String())))
+	return nil
+}
+
+// likeEscaper escapes the wildcards of LIKE, so that the value of a filter is
+// matched literally.
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
+var sqlOperators = map[Operator]string{
+	OpEq:    "=",
+	OpNe:    "<>",
+	OpGt:    ">",
+	OpGte:   ">=",
+	OpLt:    "<",
+	OpLte:   "<=",
+	OpIn:    "IN",
+	OpNotIn: "NOT IN",
+}
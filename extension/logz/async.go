@@ -0,0 +1,171 @@
+package logz
+
+import (
+	"bufio"
+	"io"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// AsyncOptions is the options of the AsyncWriter.
+type AsyncOptions struct {
+	// QueueSize is the maximum number of pending records, records are dropped
+	// when the queue is full.
+	QueueSize int
+
+	// BufferSize is the size of the write buffer in bytes.
+	BufferSize int
+
+	// FlushInterval flushes the buffer periodically, the buffer is also
+	// flushed whenever the queue becomes empty.
+	FlushInterval time.Duration
+}
+
+var (
+	asyncDropped atomic.Uint64
+	asyncQueued  atomic.Int64
+)
+
+// AsyncWriter writes to the underlying writer in a background goroutine, so
+// that logging never blocks on disk I/O. Write never blocks, records are
+// dropped and counted when the queue is full.
+type AsyncWriter struct {
+	buf   *bufio.Writer
+	queue chan []byte
+	flush chan chan struct{}
+	done  chan struct{}
+
+	closeOnce sync.Once
+	mu        sync.RWMutex
+	closed    bool
+}
+
+// NewAsyncWriter starts an AsyncWriter writing to w.
+func NewAsyncWriter(w io.Writer, opts AsyncOptions) *AsyncWriter {
+	if opts.QueueSize <= 0 {
+		opts.QueueSize = 1024
+	}
+	if opts.BufferSize <= 0 {
+		opts.BufferSize = 256 * 1024
+	}
+	if opts.FlushInterval <= 0 {
+		opts.FlushInterval = time.Second
+	}
+
+	a := &AsyncWriter{
+		buf:   bufio.NewWriterSize(w, opts.BufferSize),
+		queue: make(chan []byte, opts.QueueSize),
+		flush: make(chan chan struct{}),
+		done:  make(chan struct{}),
+	}
+	go a.run(opts.FlushInterval)
+	return a
+}
+
+// Write queues a copy of p, the slog handlers reuse p after Write returns.
+func (a *AsyncWriter) Write(p []byte) (int, error) {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	if a.closed {
+		return 0, io.ErrClosedPipe
+	}
+
+	record := make([]byte, len(p))
+	copy(record, p)
+	select {
+	case a.queue <- record:
+		asyncQueued.Add(1)
+	default:
+		asyncDropped.Add(1)
+	}
+	return len(p), nil
+}
+
+// Flush blocks until the queued records are written to the underlying writer.
+func (a *AsyncWriter) Flush() {
+	ack := make(chan struct{})
+	select {
+	case a.flush <- ack:
+		<-ack
+	case <-a.done:
+	}
+}
+
+// Close writes the pending records and stops the background goroutine, the
+// underlying writer is not closed.
+func (a *AsyncWriter) Close() error {
+	a.closeOnce.Do(func() {
+		a.mu.Lock()
+		a.closed = true
+		close(a.queue)
+		a.mu.Unlock()
+	})
+	<-a.done
+	return nil
+}
+
+func (a *AsyncWriter) run(interval time.Duration) {
+	defer close(a.done)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case record, ok := <-a.queue:
+			if !ok {
+				_ = a.buf.Flush()
+				return
+			}
+			a.write(record)
+			if len(a.queue) == 0 {
+				_ = a.buf.Flush()
+			}
+		case ack := <-a.flush:
+			a.drain()
+			_ = a.buf.Flush()
+			close(ack)
+		case <-ticker.C:
+			_ = a.buf.Flush()
+		}
+	}
+}
+
+func (a *AsyncWriter) drain() {
+	for {
+		select {
+		case record, ok := <-a.queue:
+			if !ok {
+				return
+			}
+			a.write(record)
+		default:
+			return
+		}
+	}
+}
+
+func (a *AsyncWriter) write(record []byte) {
+	asyncQueued.Add(-1)
+	_, _ = a.buf.Write(record)
+}
+
+// Stats is the statistics of the logger.
+type Stats struct {
+	// AsyncDropped is the number of records dropped because the queue of an
+	// AsyncWriter was full.
+	AsyncDropped uint64
+
+	// AsyncQueued is the number of records waiting in the AsyncWriter queues.
+	AsyncQueued int64
+}
+
+// GetStats returns the statistics of the logger.
+func GetStats() Stats {
+	return Stats{
+		AsyncDropped: asyncDropped.Load(),
+		AsyncQueued:  asyncQueued.Load(),
+	}
+}
@@ -0,0 +1,118 @@
+package logz
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// consoleHandler writes human-readable records for local development:
+//
+//	2006-01-02 15:04:05.000 INFO  message key=value group.key=value
+type consoleHandler struct {
+	opts   slog.HandlerOptions
+	prefix string // group prefix of the attrs added later
+	attrs  []byte // preformatted attrs
+	mu     *sync.Mutex
+	w      io.Writer
+}
+
+func newConsoleHandler(w io.Writer, opts *slog.HandlerOptions) *consoleHandler {
+	h := &consoleHandler{w: w, mu: &sync.Mutex{}}
+	if opts != nil {
+		h.opts = *opts
+	}
+	return h
+}
+
+func (h *consoleHandler) Enabled(_ context.Context, lvl slog.Level) bool {
+	minLevel := slog.LevelInfo
+	if h.opts.Level != nil {
+		minLevel = h.opts.Level.Level()
+	}
+	return lvl >= minLevel
+}
+
+func (h *consoleHandler) Handle(_ context.Context, r slog.Record) error {
+	buf := bytes.Buffer{}
+	if !r.Time.IsZero() {
+		buf.WriteString(r.Time.Format("2006-01-02 15:04:05.000"))
+		buf.WriteByte(' ')
+	}
+	buf.WriteString(fmt.Sprintf("%-5s ", r.Level.String()))
+	buf.WriteString(r.Message)
+	buf.Write(h.attrs)
+	r.Attrs(func(a slog.Attr) bool {
+		appendConsoleAttr(&buf, h.prefix, a)
+		return true
+	})
+	if h.opts.AddSource && r.PC != 0 {
+		frame, _ := runtime.CallersFrames([]uintptr{r.PC}).Next()
+		buf.WriteString(" source=")
+		buf.WriteString(filepath.Base(frame.File))
+		buf.WriteByte(':')
+		buf.WriteString(strconv.Itoa(frame.Line))
+	}
+	buf.WriteByte('\n')
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	_, err := h.w.Write(buf.Bytes())
+	return err
+}
+
+func (h *consoleHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	buf := bytes.NewBuffer(append([]byte(nil), h.attrs...))
+	for _, a := range attrs {
+		appendConsoleAttr(buf, h.prefix, a)
+	}
+	rv := *h
+	rv.attrs = buf.Bytes()
+	return &rv
+}
+
+func (h *consoleHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	rv := *h
+	rv.prefix = h.prefix + name + "."
+	return &rv
+}
+
+func appendConsoleAttr(buf *bytes.Buffer, prefix string, a slog.Attr) {
+	a.Value = a.Value.Resolve()
+	if a.Equal(slog.Attr{}) {
+		return
+	}
+
+	if a.Value.Kind() == slog.KindGroup {
+		if a.Key != "" {
+			prefix = prefix + a.Key + "."
+		}
+		for _, ga := range a.Value.Group() {
+			appendConsoleAttr(buf, prefix, ga)
+		}
+		return
+	}
+
+	buf.WriteByte(' ')
+	buf.WriteString(prefix)
+	buf.WriteString(a.Key)
+	buf.WriteByte('=')
+	switch a.Value.Kind() {
+	case slog.KindString:
+		buf.WriteString(strconv.Quote(a.Value.String()))
+	case slog.KindTime:
+		buf.WriteString(a.Value.Time().Format(time.RFC3339Nano))
+	default:
+		buf.WriteString(fmt.Sprint(a.Value.Any()))
+	}
+}
@@ -0,0 +1,113 @@
+package logz
+
+import (
+	"context"
+	"log/slog"
+	"slices"
+	"sync"
+
+	"demo/extension/contextz"
+)
+
+// ContextExtractor extracts attributes from the context of a log record.
+type ContextExtractor func(ctx context.Context) []slog.Attr
+
+var (
+	extractorsMu sync.RWMutex
+	extractors   = map[string]ContextExtractor{}
+	// extractorNames keeps the registration order so that attributes are
+	// always written in the same order.
+	extractorNames []string
+)
+
+func init() {
+	RegisterContextExtractor("module", func(ctx context.Context) []slog.Attr {
+		if module := contextz.ModuleName(ctx); module != "" {
+			return []slog.Attr{slog.String("module", module)}
+		}
+		return nil
+	})
+	RegisterContextExtractor("request_id", func(ctx context.Context) []slog.Attr {
+		if id := contextz.RequestId(ctx); id != "" {
+			return []slog.Attr{slog.String("request_id", id)}
+		}
+		return nil
+	})
+	RegisterContextExtractor("principal", func(ctx context.Context) []slog.Attr {
+		principal, ok := contextz.GetPrincipal(ctx)
+		if !ok {
+			return nil
+		}
+		attrs := []slog.Attr{slog.String("user_id", principal.UserId)}
+		if principal.Tenant != "" {
+			attrs = append(attrs, slog.String("tenant", principal.Tenant))
+		}
+		return attrs
+	})
+	RegisterContextExtractor("trace", func(ctx context.Context) []slog.Attr {
+		if traceId := contextz.TraceId(ctx); traceId != "" {
+			return []slog.Attr{slog.String("trace_id", traceId), slog.String("span_id", contextz.SpanId(ctx))}
+		}
+		return nil
+	})
+}
+
+// RegisterContextExtractor registers an extractor whose attributes are added
+// to every record logged with a context, an extractor with the same name is
+// replaced. The returned func unregisters it and restores the replaced one.
+func RegisterContextExtractor(name string, extractor ContextExtractor) (unregister func()) {
+	extractorsMu.Lock()
+	defer extractorsMu.Unlock()
+
+	replaced, ok := extractors[name]
+	if !ok {
+		extractorNames = append(extractorNames, name)
+	}
+	extractors[name] = extractor
+
+	return func() {
+		extractorsMu.Lock()
+		defer extractorsMu.Unlock()
+
+		if ok {
+			extractors[name] = replaced
+			return
+		}
+		delete(extractors, name)
+		extractorNames = slices.DeleteFunc(extractorNames, func(n string) bool { return n == name })
+	}
+}
+
+// contextHandler adds the attributes of the registered extractors to records.
+type contextHandler struct {
+	next slog.Handler
+}
+
+func newContextHandler(next slog.Handler) slog.Handler {
+	return &contextHandler{next: next}
+}
+
+func (h *contextHandler) Enabled(ctx context.Context, lvl slog.Level) bool {
+	return h.next.Enabled(ctx, lvl)
+}
+
+func (h *contextHandler) Handle(ctx context.Context, r slog.Record) error {
+	if ctx != nil {
+		extractorsMu.RLock()
+		for _, name := range extractorNames {
+			if attrs := extractors[name](ctx); len(attrs) > 0 {
+				r.AddAttrs(attrs...)
+			}
+		}
+		extractorsMu.RUnlock()
+	}
+	return h.next.Handle(ctx, r)
+}
+
+func (h *contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &contextHandler{next: h.next.WithAttrs(attrs)}
+}
+
+func (h *contextHandler) WithGroup(name string) slog.Handler {
+	return &contextHandler{next: h.next.WithGroup(name)}
+}
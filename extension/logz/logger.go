@@ -2,15 +2,16 @@ package logz
 
 import (
 	"context"
-	"fmt"
 	"log/slog"
 	"os"
-
-	"demo/extension/contextz"
+	"runtime"
+	"sync/atomic"
+	"time"
 )
 
 var (
-	l *slog.Logger
+	l     atomic.Pointer[slog.Logger]
+	level = new(slog.LevelVar)
 
 	Group    = slog.Group
 	String   = slog.String
@@ -25,49 +26,66 @@ var (
 )
 
 func init() {
-	l = slog.New(slog.NewJSONHandler(os.Stdout, nil))
+	l.Store(slog.New(newContextHandler(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: level}))))
+}
+
+// Logger returns the underlying slog.Logger.
+func Logger() *slog.Logger {
+	return l.Load()
+}
+
+// SetLogger replaces the underlying slog.Logger.
+func SetLogger(logger *slog.Logger) {
+	l.Store(logger)
 }
 
 func Debug(ctx context.Context, msg string, args ...any) {
-	l.DebugContext(ctx, prefixWithModuleName(ctx, msg), args...)
+	log(ctx, slog.LevelDebug, msg, args...)
 }
 
 func DebugNoCtx(msg string, args ...any) {
-	l.Debug(msg, args...)
+	log(context.Background(), slog.LevelDebug, msg, args...)
 }
 
 func Info(ctx context.Context, msg string, args ...any) {
-	l.InfoContext(ctx, prefixWithModuleName(ctx, msg), args...)
+	log(ctx, slog.LevelInfo, msg, args...)
 }
 
 func InfoNoCtx(msg string, args ...any) {
-	l.Info(msg, args...)
+	log(context.Background(), slog.LevelInfo, msg, args...)
 }
 
 func Warn(ctx context.Context, msg string, args ...any) {
-	l.WarnContext(ctx, prefixWithModuleName(ctx, msg), args...)
+	log(ctx, slog.LevelWarn, msg, args...)
 }
 
 func WarnNoCtx(msg string, args ...any) {
-	l.Warn(msg, args...)
+	log(context.Background(), slog.LevelWarn, msg, args...)
 }
 
 func Error(ctx context.Context, msg string, args ...any) {
-	l.ErrorContext(ctx, prefixWithModuleName(ctx, msg), args...)
+	log(ctx, slog.LevelError, msg, args...)
 }
 
 func ErrorNoCtx(msg string, args ...any) {
-	l.Error(msg, args...)
+	log(context.Background(), slog.LevelError, msg, args...)
 }
 
 func Err(err error) slog.Attr {
 	return slog.Any("error", err)
 }
 
-func prefixWithModuleName(ctx context.Context, msg string) string {
-	module := contextz.ModuleName(ctx)
-	if module == "" {
-		return msg
+// log records the message with the caller of the exported logging function
+// as the source, see "Wrapping output methods" of log/slog.
+func log(ctx context.Context, lvl slog.Level, msg string, args ...any) {
+	logger := l.Load()
+	if !logger.Enabled(ctx, lvl) {
+		return
 	}
-	return fmt.Sprintf("[%s] %s", module, msg)
+
+	var pcs [1]uintptr
+	runtime.Callers(3, pcs[:])
+	r := slog.NewRecord(time.Now(), lvl, msg, pcs[0])
+	r.Add(args...)
+	_ = logger.Handler().Handle(ctx, r)
 }
@@ -0,0 +1,108 @@
+package logz
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"demo/extension/contextz"
+)
+
+func TestSetup(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "logs", "app.log")
+	err := Setup(Options{Level: "warn", Format: FormatJSON, Output: OutputFile, File: file, AddSource: true})
+	assert.NoError(t, err)
+	defer Close()
+
+	Info(context.Background(), "dropped")
+	Warn(context.Background(), "kept", String("key", "value"))
+
+	assert.NoError(t, SetLevel("debug"))
+	assert.Equal(t, "debug", Level())
+	DebugNoCtx("debug kept")
+	assert.NoError(t, Close())
+
+	content, err := os.ReadFile(file)
+	assert.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(string(content)), "\n")
+	assert.Len(t, lines, 2)
+
+	var record map[string]any
+	assert.NoError(t, json.Unmarshal([]byte(lines[0]), &record))
+	assert.Equal(t, "kept", record["msg"])
+	assert.Equal(t, "value", record["key"])
+	source := record["source"].(map[string]any)
+	assert.True(t, strings.HasSuffix(source["file"].(string), "logger_test.go"))
+}
+
+func TestSetupInvalidOptions(t *testing.T) {
+	assert.Error(t, Setup(Options{Level: "verbose"}))
+	assert.Error(t, Setup(Options{Format: "xml"}))
+	assert.Error(t, Setup(Options{Output: OutputFile}))
+	assert.Error(t, SetLevel("verbose"))
+}
+
+func TestConsoleFormat(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "console.log")
+	assert.NoError(t, Setup(Options{Format: FormatConsole, Output: OutputFile, File: file}))
+	defer Close()
+
+	Logger().With("service", "demo").WithGroup("req").Info("hello", "id", 1)
+	assert.NoError(t, Close())
+
+	content, err := os.ReadFile(file)
+	assert.NoError(t, err)
+	assert.Contains(t, string(content), `INFO  hello service="demo" req.id=1`)
+}
+
+type tenantKey struct{}
+
+func TestContextExtractors(t *testing.T) {
+	t.Cleanup(RegisterContextExtractor("tenant", func(ctx context.Context) []slog.Attr {
+		if tenant, ok := ctx.Value(tenantKey{}).(string); ok {
+			return []slog.Attr{slog.String("tenant", tenant)}
+		}
+		return nil
+	}))
+
+	file := filepath.Join(t.TempDir(), "context.log")
+	assert.NoError(t, Setup(Options{Output: OutputFile, File: file}))
+	defer Close()
+
+	ctx := contextz.WithModuleName(context.Background(), "order")
+	ctx = context.WithValue(ctx, tenantKey{}, "t1")
+	Info(ctx, "created")
+	InfoNoCtx("no context")
+	assert.NoError(t, Close())
+
+	content, err := os.ReadFile(file)
+	assert.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(string(content)), "\n")
+	assert.Len(t, lines, 2)
+
+	var record map[string]any
+	assert.NoError(t, json.Unmarshal([]byte(lines[0]), &record))
+	assert.Equal(t, "created", record["msg"])
+	assert.Equal(t, "order", record["module"])
+	assert.Equal(t, "t1", record["tenant"])
+
+	record = nil
+	assert.NoError(t, json.Unmarshal([]byte(lines[1]), &record))
+	assert.NotContains(t, record, "module")
+}
+
+func TestUnregisterContextExtractor(t *testing.T) {
+	names := slices.Clone(extractorNames)
+	unregister := RegisterContextExtractor("temporary", func(context.Context) []slog.Attr { return nil })
+	assert.Contains(t, extractorNames, "temporary")
+	unregister()
+	assert.Equal(t, names, extractorNames)
+	assert.NotContains(t, extractors, "temporary")
+}
@@ -0,0 +1,283 @@
+package logz
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// DefaultMask replaces the redacted values.
+const DefaultMask = "******"
+
+// RedactOptions is the options of the Redactor.
+type RedactOptions struct {
+	// Headers are the names of the headers whose values are masked, matched
+	// case-insensitively.
+	Headers []string
+
+	// Fields are the JSON body fields to mask. A field without a dot matches
+	// the key at any depth, a dotted path such as "user.id_card" matches from
+	// the root and "*" matches any key. Arrays are transparent, so
+	// "contacts.name" matches the name of every element of contacts.
+	Fields []string
+
+	// Patterns are regular expressions whose matches are masked in the
+	// remaining strings, for example phone numbers.
+	Patterns []string
+
+	// Mask replaces the redacted values, defaults to DefaultMask.
+	Mask string
+}
+
+// Redactor masks sensitive data in headers and bodies before they are logged.
+type Redactor struct {
+	headers  map[string]struct{}
+	keys     map[string]struct{}
+	paths    [][]string
+	patterns []*regexp.Regexp
+	mask     string
+}
+
+// NewRedactor compiles the options into a Redactor.
+func NewRedactor(opts RedactOptions) (*Redactor, error) {
+	r := &Redactor{
+		headers: make(map[string]struct{}, len(opts.Headers)),
+		keys:    make(map[string]struct{}),
+		mask:    opts.Mask,
+	}
+	if r.mask == "" {
+		r.mask = DefaultMask
+	}
+
+	for _, h := range opts.Headers {
+		r.headers[http.CanonicalHeaderKey(h)] = struct{}{}
+	}
+	for _, f := range opts.Fields {
+		if strings.Contains(f, ".") {
+			r.paths = append(r.paths, strings.Split(strings.ToLower(f), "."))
+		} else {
+			r.keys[strings.ToLower(f)] = struct{}{}
+		}
+	}
+	for _, p := range opts.Patterns {
+		re, err := regexp.Compile(p)
+		if err != nil {
+			return nil, err
+		}
+		r.patterns = append(r.patterns, re)
+	}
+
+	return r, nil
+}
+
+// Headers returns a copy of the headers with the configured values masked.
+func (r *Redactor) Headers(header http.Header) http.Header {
+	if r == nil {
+		return header
+	}
+
+	rv := make(http.Header, len(header))
+	for name, values := range header {
+		masked := make([]string, len(values))
+		_, sensitive := r.headers[http.CanonicalHeaderKey(name)]
+		for i, v := range values {
+			if sensitive {
+				masked[i] = r.mask
+			} else {
+				masked[i] = r.String(v)
+			}
+		}
+		rv[name] = masked
+	}
+	return rv
+}
+
+// Body masks the configured fields of a JSON, a form or a multipart body and
+// the patterns in any other content. The content type must keep its
+// parameters, the boundary of a multipart body in particular. A JSON or a
+// multipart body which can not be parsed, such as a truncated one, is
+// replaced by a placeholder since its fields can not be masked.
+func (r *Redactor) Body(contentType string, body []byte) string {
+	if r == nil {
+		return string(body)
+	}
+
+	switch {
+	case strings.Contains(contentType, "json"):
+		var v any
+		decoder := json.NewDecoder(bytes.NewReader(body))
+		decoder.UseNumber()
+		if err := decoder.Decode(&v); err != nil || decoder.More() {
+			return unparseable(body)
+		}
+		masked, err := json.Marshal(r.value(v, nil))
+		if err != nil {
+			return unparseable(body)
+		}
+		return string(masked)
+	case strings.Contains(contentType, "x-www-form-urlencoded"):
+		return r.Query(string(body))
+	case strings.Contains(contentType, "multipart/form-data"):
+		masked, err := r.multipart(contentType, body)
+		if err != nil {
+			return unparseable(body)
+		}
+		return masked
+	}
+	return r.String(string(body))
+}
+
+// multipart masks the fields of a multipart/form-data body by name like the
+// form bodies, the files are replaced by their names and sizes.
+func (r *Redactor) multipart(contentType string, body []byte) (string, error) {
+	_, params, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return "", err
+	}
+	boundary := params["boundary"]
+	if boundary == "" {
+		return "", errors.New("logz: multipart body without boundary")
+	}
+	// the reader ends a truncated body as if it were complete
+	if !bytes.Contains(body, []byte("--"+boundary+"--")) {
+		return "", io.ErrUnexpectedEOF
+	}
+
+	reader := multipart.NewReader(bytes.NewReader(body), boundary)
+	var b strings.Builder
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			return b.String(), nil
+		}
+		if err != nil {
+			return "", err
+		}
+		value, err := io.ReadAll(part)
+		if err != nil {
+			return "", err
+		}
+
+		if b.Len() > 0 {
+			b.WriteByte('&')
+		}
+		name := part.FormName()
+		switch {
+		case part.FileName() != "":
+			fmt.Fprintf(&b, "%s=[file %s, %d bytes]", name, r.String(part.FileName()), len(value))
+		case r.sensitive([]string{strings.ToLower(name)}):
+			b.WriteString(name + "=" + r.mask)
+		default:
+			b.WriteString(name + "=" + r.String(string(value)))
+		}
+	}
+}
+
+// unparseable describes a body whose fields could not be masked.
+func unparseable(body []byte) string {
+	return fmt.Sprintf("[unparseable body, %d bytes]", len(body))
+}
+
+// Query masks the values of the configured fields of a query string or a
+// form body, matched by name, and the patterns in the other values. The
+// values are decoded so that an encoded value can not bypass the patterns.
+func (r *Redactor) Query(query string) string {
+	if r == nil {
+		return query
+	}
+
+	values, err := url.ParseQuery(query)
+	if err != nil {
+		return r.String(query)
+	}
+
+	// keep the names in the order of the query
+	var b strings.Builder
+	for _, pair := range strings.Split(query, "&") {
+		key, _, _ := strings.Cut(pair, "=")
+		name, err := url.QueryUnescape(key)
+		if err != nil || values[name] == nil {
+			continue
+		}
+		sensitive := r.sensitive([]string{strings.ToLower(name)})
+		for _, v := range values[name] {
+			if b.Len() > 0 {
+				b.WriteByte('&')
+			}
+			if sensitive {
+				v = r.mask
+			} else {
+				v = r.String(v)
+			}
+			b.WriteString(name + "=" + v)
+		}
+		delete(values, name)
+	}
+	return b.String()
+}
+
+// String masks the patterns in s.
+func (r *Redactor) String(s string) string {
+	if r == nil {
+		return s
+	}
+	for _, re := range r.patterns {
+		s = re.ReplaceAllString(s, r.mask)
+	}
+	return s
+}
+
+func (r *Redactor) value(v any, path []string) any {
+	switch tv := v.(type) {
+	case map[string]any:
+		for k, child := range tv {
+			childPath := append(path[:len(path):len(path)], strings.ToLower(k))
+			if r.sensitive(childPath) {
+				tv[k] = r.mask
+				continue
+			}
+			tv[k] = r.value(child, childPath)
+		}
+		return tv
+	case []any:
+		for i, child := range tv {
+			tv[i] = r.value(child, path)
+		}
+		return tv
+	case string:
+		return r.String(tv)
+	default:
+		return v
+	}
+}
+
+func (r *Redactor) sensitive(path []string) bool {
+	if _, ok := r.keys[path[len(path)-1]]; ok {
+		return true
+	}
+
+	for _, p := range r.paths {
+		if len(p) != len(path) {
+			continue
+		}
+		matched := true
+		for i := range p {
+			if p[i] != "*" && p[i] != path[i] {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			return true
+		}
+	}
+	return false
+}
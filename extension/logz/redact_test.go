@@ -0,0 +1,109 @@
+package logz
+
+import (
+	"bytes"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRedactor(t *testing.T) {
+	r, err := NewRedactor(RedactOptions{
+		Headers:  []string{"authorization", "Cookie"},
+		Fields:   []string{"password", "user.id_card", "contacts.name", "*.token"},
+		Patterns: []string{`\b1[3-9]\d{9}\b`},
+	})
+	assert.NoError(t, err)
+
+	header := http.Header{
+		"Authorization": {"Bearer token"},
+		"Cookie":        {"session=abc"},
+		"X-Phone":       {"13800138000"},
+		"Accept":        {"application/json"},
+	}
+	masked := r.Headers(header)
+	assert.Equal(t, []string{DefaultMask}, masked["Authorization"])
+	assert.Equal(t, []string{DefaultMask}, masked["Cookie"])
+	assert.Equal(t, []string{DefaultMask}, masked["X-Phone"])
+	assert.Equal(t, []string{"application/json"}, masked["Accept"])
+	assert.Equal(t, "Bearer token", header.Get("Authorization"), "the original headers are untouched")
+
+	body := `{"password":"p","user":{"id_card":"110101","name":"bob","password":"q"},"id_card":"kept",` +
+		`"contacts":[{"name":"alice","phone":"call 13800138000"}],"amount":12.50,"auth":{"token":"t"}}`
+	assert.JSONEq(t,
+		`{"password":"******","user":{"id_card":"******","name":"bob","password":"******"},"id_card":"kept",`+
+			`"contacts":[{"name":"******","phone":"call ******"}],"amount":12.50,"auth":{"token":"******"}}`,
+		r.Body("application/json; charset=utf-8", []byte(body)))
+
+	assert.Equal(t, "phone=******&name=bob", r.Body("application/x-www-form-urlencoded", []byte("phone=13800138000&name=bob")))
+	assert.Equal(t, "password=******&name=bob&name=b b",
+		r.Body("application/x-www-form-urlencoded; charset=utf-8", []byte("password=secret&name=bob&name=b%20b")))
+	assert.Equal(t, "[unparseable body, 21 bytes]", r.Body("application/json", []byte(`{"password":"secret",`)))
+	assert.Equal(t, "[unparseable body, 16 bytes]", r.Body("application/json", []byte(`{} {"password":1`)))
+	assert.Equal(t, "call ******", r.Body("text/plain", []byte("call 13800138000")))
+}
+
+func TestRedactMultipart(t *testing.T) {
+	r, err := NewRedactor(RedactOptions{Fields: []string{"password"}, Patterns: []string{`\b1[3-9]\d{9}\b`}})
+	assert.NoError(t, err)
+
+	var body bytes.Buffer
+	w := multipart.NewWriter(&body)
+	assert.NoError(t, w.WriteField("username", "bob"))
+	assert.NoError(t, w.WriteField("Password", "hunter2"))
+	assert.NoError(t, w.WriteField("phone", "13800138000"))
+	file, err := w.CreateFormFile("avatar", "me.png")
+	assert.NoError(t, err)
+	_, err = file.Write([]byte("png data"))
+	assert.NoError(t, err)
+	assert.NoError(t, w.Close())
+
+	assert.Equal(t, "username=bob&Password=******&phone=******&avatar=[file me.png, 8 bytes]",
+		r.Body(w.FormDataContentType(), body.Bytes()))
+
+	truncated := body.Bytes()[:body.Len()/2]
+	assert.Equal(t, fmt.Sprintf("[unparseable body, %d bytes]", len(truncated)), r.Body(w.FormDataContentType(), truncated))
+	assert.Equal(t, fmt.Sprintf("[unparseable body, %d bytes]", body.Len()), r.Body("multipart/form-data", body.Bytes()))
+}
+
+func TestRedactorInvalidPattern(t *testing.T) {
+	_, err := NewRedactor(RedactOptions{Patterns: []string{"("}})
+	assert.Error(t, err)
+}
+
+func TestNilRedactor(t *testing.T) {
+	var r *Redactor
+	assert.Equal(t, "13800138000", r.String("13800138000"))
+	assert.Equal(t, `{"password":"p"}`, r.Body("application/json", []byte(`{"password":"p"}`)))
+}
+
+func TestRedactQuery(t *testing.T) {
+	r, err := NewRedactor(RedactOptions{
+		Fields:   []string{"password", "access_token", "code", "state"},
+		Patterns: []string{`\b1[3-9]\d{9}\b`},
+	})
+	assert.NoError(t, err)
+
+	testcases := []struct {
+		name  string
+		query string
+		want  string
+	}{
+		{name: "token", query: "access_token=abc&page=2", want: "access_token=******&page=2"},
+		{name: "case insensitive", query: "Password=p", want: "Password=******"},
+		{name: "oauth2 callback", query: "code=c1&state=s1", want: "code=******&state=******"},
+		{name: "repeated", query: "code=a&x=1&code=b", want: "code=******&code=******&x=1"},
+		{name: "encoded name", query: "pass%77ord=p", want: "password=******"},
+		{name: "encoded pattern", query: "phone=%2B86%2013800138000", want: "phone=+86 ******"},
+		{name: "invalid", query: "a=%zz&phone=13800138000", want: "a=%zz&phone=******"},
+		{name: "empty", query: "", want: ""},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.want, r.Query(tc.query))
+		})
+	}
+}
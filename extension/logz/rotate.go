@@ -0,0 +1,311 @@
+package logz
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const backupTimeFormat = "2006-01-02T15-04-05.000"
+
+// RotateOptions is the options of the RotatingFile.
+type RotateOptions struct {
+	// MaxSize is the maximum size in bytes of the file before it is rotated,
+	// zero disables rotation by size.
+	MaxSize int64
+
+	// Interval rotates the file once it has been open for the interval, zero
+	// disables rotation by age.
+	Interval time.Duration
+
+	// MaxBackups is the number of rotated files to retain, zero retains all.
+	MaxBackups int
+
+	// MaxAge removes rotated files older than MaxAge, zero retains all.
+	MaxAge time.Duration
+
+	// Compress compresses rotated files with gzip.
+	Compress bool
+}
+
+// RotatingFile is an io.WriteCloser which rotates the file by size and age.
+// Rotated files are named "<name>-<timestamp><ext>", or
+// "<name>-<timestamp>.<seq><ext>" if the file was rotated several times in a
+// millisecond, the cleanup of old files and compression happen in background.
+type RotatingFile struct {
+	filename string
+	opts     RotateOptions
+
+	mu       sync.Mutex
+	file     *os.File
+	size     int64
+	openedAt time.Time
+	now      func() time.Time
+
+	cleanupCh chan struct{}
+	done      chan struct{}
+}
+
+// NewRotatingFile opens the file for appending and starts the cleanup routine.
+func NewRotatingFile(filename string, opts RotateOptions) (*RotatingFile, error) {
+	f := &RotatingFile{
+		filename:  filename,
+		opts:      opts,
+		now:       time.Now,
+		cleanupCh: make(chan struct{}, 1),
+		done:      make(chan struct{}),
+	}
+	if err := f.open(); err != nil {
+		return nil, err
+	}
+
+	go f.cleanupLoop()
+	return f, nil
+}
+
+func (f *RotatingFile) Write(p []byte) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.file == nil {
+		return 0, os.ErrClosed
+	}
+
+	if f.shouldRotate(int64(len(p))) {
+		if err := f.rotate(); err != nil {
+			return 0, err
+		}
+	}
+
+	n, err := f.file.Write(p)
+	f.size += int64(n)
+	return n, err
+}
+
+// Rotate rotates the file immediately.
+func (f *RotatingFile) Rotate() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.rotate()
+}
+
+// Close closes the file and waits for the pending cleanup.
+func (f *RotatingFile) Close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.file == nil {
+		return nil
+	}
+	err := f.file.Close()
+	f.file = nil
+	close(f.cleanupCh)
+	<-f.done
+	return err
+}
+
+func (f *RotatingFile) shouldRotate(n int64) bool {
+	if f.opts.MaxSize > 0 && f.size > 0 && f.size+n > f.opts.MaxSize {
+		return true
+	}
+	if f.opts.Interval > 0 && f.now().Sub(f.openedAt) >= f.opts.Interval {
+		return true
+	}
+	return false
+}
+
+func (f *RotatingFile) open() error {
+	if err := os.MkdirAll(filepath.Dir(f.filename), 0o755); err != nil {
+		return err
+	}
+
+	file, err := os.OpenFile(f.filename, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		_ = file.Close()
+		return err
+	}
+
+	f.file = file
+	f.size = info.Size()
+	f.openedAt = f.now()
+	return nil
+}
+
+func (f *RotatingFile) rotate() error {
+	if f.file != nil {
+		if err := f.file.Close(); err != nil {
+			return err
+		}
+		f.file = nil
+	}
+
+	if err := os.Rename(f.filename, f.backupName(f.now())); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	if err := f.open(); err != nil {
+		return err
+	}
+
+	select {
+	case f.cleanupCh <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// backupName returns the name of the backup rotated at t, a sequence number is
+// appended if a backup of the same millisecond exists, so that it is not
+// renamed over.
+func (f *RotatingFile) backupName(t time.Time) string {
+	ext := filepath.Ext(f.filename)
+	base := strings.TrimSuffix(f.filename, ext) + "-" + t.Format(backupTimeFormat)
+	name := base + ext
+	for seq := 1; fileExists(name) || fileExists(name+".gz"); seq++ {
+		name = fmt.Sprintf("%s.%d%s", base, seq, ext)
+	}
+	return name
+}
+
+func fileExists(name string) bool {
+	_, err := os.Lstat(name)
+	return err == nil
+}
+
+func (f *RotatingFile) cleanupLoop() {
+	defer close(f.done)
+	for range f.cleanupCh {
+		if err := f.cleanup(); err != nil {
+			fmt.Fprintf(os.Stderr, "logz: cleanup rotated files of %s failed: %v\n", f.filename, err)
+		}
+	}
+}
+
+type backupFile struct {
+	path      string
+	timestamp time.Time
+	seq       int
+}
+
+// backups returns the rotated files, newest first.
+func (f *RotatingFile) backups() ([]backupFile, error) {
+	dir := filepath.Dir(f.filename)
+	ext := filepath.Ext(f.filename)
+	prefix := strings.TrimSuffix(filepath.Base(f.filename), ext) + "-"
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var files []backupFile
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasPrefix(name, prefix) {
+			continue
+		}
+		stamp := strings.TrimSuffix(strings.TrimSuffix(strings.TrimPrefix(name, prefix), ".gz"), ext)
+		ts, seq, ok := parseBackupStamp(stamp)
+		if !ok {
+			continue
+		}
+		files = append(files, backupFile{path: filepath.Join(dir, name), timestamp: ts, seq: seq})
+	}
+
+	sort.Slice(files, func(i, j int) bool {
+		if !files[i].timestamp.Equal(files[j].timestamp) {
+			return files[i].timestamp.After(files[j].timestamp)
+		}
+		return files[i].seq > files[j].seq
+	})
+	return files, nil
+}
+
+// parseBackupStamp parses the timestamp and the optional sequence number of a
+// backup name.
+func parseBackupStamp(stamp string) (time.Time, int, bool) {
+	if len(stamp) < len(backupTimeFormat) {
+		return time.Time{}, 0, false
+	}
+	ts, err := time.Parse(backupTimeFormat, stamp[:len(backupTimeFormat)])
+	if err != nil {
+		return time.Time{}, 0, false
+	}
+	rest := stamp[len(backupTimeFormat):]
+	if rest == "" {
+		return ts, 0, true
+	}
+	seq, err := strconv.Atoi(strings.TrimPrefix(rest, "."))
+	if !strings.HasPrefix(rest, ".") || err != nil || seq <= 0 {
+		return time.Time{}, 0, false
+	}
+	return ts, seq, true
+}
+
+func (f *RotatingFile) cleanup() error {
+	files, err := f.backups()
+	if err != nil {
+		return err
+	}
+
+	cutoff := f.now().Add(-f.opts.MaxAge)
+	for i, file := range files {
+		expired := f.opts.MaxAge > 0 && file.timestamp.Before(cutoff)
+		if (f.opts.MaxBackups > 0 && i >= f.opts.MaxBackups) || expired {
+			if err := os.Remove(file.path); err != nil && !os.IsNotExist(err) {
+				return err
+			}
+			continue
+		}
+
+		if f.opts.Compress && !strings.HasSuffix(file.path, ".gz") {
+			if err := compressFile(file.path); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func compressFile(path string) (err error) {
+	src, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	dst, err := os.OpenFile(path+".gz", os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = os.Remove(path + ".gz")
+		}
+	}()
+
+	gz := gzip.NewWriter(dst)
+	if _, err = io.Copy(gz, src); err != nil {
+		_ = dst.Close()
+		return err
+	}
+	if err = gz.Close(); err != nil {
+		_ = dst.Close()
+		return err
+	}
+	if err = dst.Close(); err != nil {
+		return err
+	}
+	return os.Remove(path)
+}
@@ -0,0 +1,142 @@
+package logz
+
+import (
+	"context"
+	"hash/fnv"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const samplerCounters = 4096
+
+// SamplingOptions logs the First records of a key in every Tick and then
+// every Thereafter-th record, Thereafter zero drops all the others.
+type SamplingOptions struct {
+	Tick       time.Duration
+	First      uint64
+	Thereafter uint64
+}
+
+var samplerDropped sync.Map // sampler name -> *atomic.Uint64
+
+// Sampler decides whether a record with a key should be logged, it is safe
+// for concurrent use. Keys are hashed into a fixed number of counters, so
+// distinct keys may occasionally share a counter.
+type Sampler struct {
+	opts      SamplingOptions
+	overrides map[string]SamplingOptions
+	counters  [samplerCounters]samplerCounter
+	dropped   *atomic.Uint64
+	now       func() time.Time
+}
+
+type samplerCounter struct {
+	resetAt atomic.Int64
+	count   atomic.Uint64
+}
+
+// NewSampler creates a sampler, the dropped records are reported by
+// SampledDropped under the name. overrides replace the options for some keys.
+func NewSampler(name string, opts SamplingOptions, overrides map[string]SamplingOptions) *Sampler {
+	dropped, _ := samplerDropped.LoadOrStore(name, new(atomic.Uint64))
+	return &Sampler{
+		opts:      normalizeSampling(opts),
+		overrides: overrides,
+		dropped:   dropped.(*atomic.Uint64),
+		now:       time.Now,
+	}
+}
+
+func normalizeSampling(opts SamplingOptions) SamplingOptions {
+	if opts.Tick <= 0 {
+		opts.Tick = time.Second
+	}
+	return opts
+}
+
+// Sample reports whether the record with the key should be logged.
+func (s *Sampler) Sample(key string) bool {
+	opts := s.opts
+	if override, ok := s.overrides[key]; ok {
+		opts = normalizeSampling(override)
+	}
+
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(key))
+	n := s.counters[h.Sum32()%samplerCounters].inc(s.now(), opts.Tick)
+
+	if n <= opts.First || (opts.Thereafter > 0 && (n-opts.First)%opts.Thereafter == 0) {
+		return true
+	}
+	s.dropped.Add(1)
+	return false
+}
+
+func (c *samplerCounter) inc(t time.Time, tick time.Duration) uint64 {
+	now := t.UnixNano()
+	resetAt := c.resetAt.Load()
+	if resetAt > now {
+		return c.count.Add(1)
+	}
+
+	c.count.Store(1)
+	if !c.resetAt.CompareAndSwap(resetAt, now+tick.Nanoseconds()) {
+		// another goroutine has reset the counter
+		return c.count.Add(1)
+	}
+	return 1
+}
+
+// SampledDropped returns the number of records dropped by the samplers
+// partitioned by the sampler name.
+func SampledDropped() map[string]uint64 {
+	rv := make(map[string]uint64)
+	samplerDropped.Range(func(key, value any) bool {
+		rv[key.(string)] = value.(*atomic.Uint64).Load()
+		return true
+	})
+	return rv
+}
+
+type skipSamplingKey struct{}
+
+// SkipSampling marks the records logged with the context to bypass the
+// sampling of the logger, for callers which have sampled them already.
+func SkipSampling(ctx context.Context) context.Context {
+	return context.WithValue(ctx, skipSamplingKey{}, true)
+}
+
+// samplingHandler samples the records by level and message, records of error
+// level and above are always kept.
+type samplingHandler struct {
+	next    slog.Handler
+	sampler *Sampler
+}
+
+func newSamplingHandler(next slog.Handler, sampler *Sampler) slog.Handler {
+	return &samplingHandler{next: next, sampler: sampler}
+}
+
+func (h *samplingHandler) Enabled(ctx context.Context, lvl slog.Level) bool {
+	return h.next.Enabled(ctx, lvl)
+}
+
+func (h *samplingHandler) Handle(ctx context.Context, r slog.Record) error {
+	if skip, _ := ctx.Value(skipSamplingKey{}).(bool); skip {
+		return h.next.Handle(ctx, r)
+	}
+	if r.Level < slog.LevelError && !h.sampler.Sample(r.Level.String()+r.Message) {
+		return nil
+	}
+	return h.next.Handle(ctx, r)
+}
+
+func (h *samplingHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &samplingHandler{next: h.next.WithAttrs(attrs), sampler: h.sampler}
+}
+
+func (h *samplingHandler) WithGroup(name string) slog.Handler {
+	return &samplingHandler{next: h.next.WithGroup(name), sampler: h.sampler}
+}
@@ -0,0 +1,54 @@
+package logz
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSampler(t *testing.T) {
+	s := NewSampler("test", SamplingOptions{Tick: time.Second, First: 2, Thereafter: 3},
+		map[string]SamplingOptions{"/quiet": {First: 1}})
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	s.now = func() time.Time { return now }
+
+	var kept []int
+	for i := 1; i <= 10; i++ {
+		if s.Sample("/orders") {
+			kept = append(kept, i)
+		}
+	}
+	assert.Equal(t, []int{1, 2, 5, 8}, kept)
+	assert.Equal(t, uint64(6), SampledDropped()["test"])
+
+	assert.True(t, s.Sample("/quiet"))
+	assert.False(t, s.Sample("/quiet"))
+
+	now = now.Add(time.Second)
+	assert.True(t, s.Sample("/orders"), "the counter is reset every tick")
+	assert.True(t, s.Sample("/quiet"))
+}
+
+func TestSamplingHandler(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "sampling.log")
+	assert.NoError(t, Setup(Options{Output: OutputFile, File: file, Sampling: &SamplingOptions{Tick: time.Hour, First: 1}}))
+	defer Close()
+
+	for i := 0; i < 3; i++ {
+		Info(context.Background(), "sampled")
+		Error(context.Background(), "always kept")
+		Info(SkipSampling(context.Background()), "not sampled")
+	}
+	assert.NoError(t, Close())
+
+	content, err := os.ReadFile(file)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, strings.Count(string(content), `"msg":"sampled"`))
+	assert.Equal(t, 3, strings.Count(string(content), `"msg":"always kept"`))
+	assert.Equal(t, 3, strings.Count(string(content), `"msg":"not sampled"`))
+}
@@ -0,0 +1,191 @@
+package logz
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+	"sync"
+)
+
+const (
+	FormatJSON    = "json"
+	FormatText    = "text"
+	FormatConsole = "console"
+
+	OutputStdout = "stdout"
+	OutputStderr = "stderr"
+	OutputFile   = "file"
+)
+
+// Options is the options of the logger.
+type Options struct {
+	Level     string // debug, info, warn or error
+	Format    string // json, text or console
+	Output    string // stdout, stderr or file
+	File      string // path of the log file if output is file
+	AddSource bool
+
+	// Rotate is the rotation of the log file if output is file.
+	Rotate RotateOptions
+
+	// Async writes the records in background if it is not nil.
+	Async *AsyncOptions
+
+	// Sampling samples the records by level and message if it is not nil,
+	// records of error level and above are always kept.
+	Sampling *SamplingOptions
+}
+
+// LoggerSampler is the name of the sampler of the logger in SampledDropped.
+const LoggerSampler = "logger"
+
+var (
+	mu     sync.Mutex
+	closer io.Closer
+)
+
+// Setup replaces the logger with one built from the options. The previous
+// output is closed if it was opened by Setup.
+func Setup(opts Options) error {
+	lvl, err := ParseLevel(opts.Level)
+	if err != nil {
+		return err
+	}
+
+	w, c, err := openOutput(opts)
+	if err != nil {
+		return err
+	}
+
+	handler, err := newHandler(opts.Format, w, &slog.HandlerOptions{Level: level, AddSource: opts.AddSource})
+	if err != nil {
+		if c != nil {
+			_ = c.Close()
+		}
+		return err
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	handler = newContextHandler(handler)
+	if opts.Sampling != nil {
+		handler = newSamplingHandler(handler, NewSampler(LoggerSampler, *opts.Sampling, nil))
+	}
+
+	level.Set(lvl)
+	SetLogger(slog.New(handler))
+
+	if closer != nil {
+		_ = closer.Close()
+	}
+	closer = c
+	return nil
+}
+
+// Close closes the output opened by Setup, the logger falls back to stdout.
+func Close() error {
+	mu.Lock()
+	defer mu.Unlock()
+
+	if closer == nil {
+		return nil
+	}
+
+	SetLogger(slog.New(newContextHandler(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: level}))))
+	err := closer.Close()
+	closer = nil
+	return err
+}
+
+// SetLevel changes the minimum level of the logger at runtime.
+func SetLevel(name string) error {
+	lvl, err := ParseLevel(name)
+	if err != nil {
+		return err
+	}
+	level.Set(lvl)
+	return nil
+}
+
+// Level returns the name of the current minimum level.
+func Level() string {
+	return strings.ToLower(level.Level().String())
+}
+
+// ParseLevel parses a level name, an empty name means info.
+func ParseLevel(name string) (slog.Level, error) {
+	var lvl slog.Level
+	if name == "" {
+		return slog.LevelInfo, nil
+	}
+	if err := lvl.UnmarshalText([]byte(name)); err != nil {
+		return lvl, fmt.Errorf("logz: unknown level %q", name)
+	}
+	return lvl, nil
+}
+
+func openOutput(opts Options) (io.Writer, io.Closer, error) {
+	var (
+		w io.Writer
+		c closers
+	)
+
+	switch opts.Output {
+	case "", OutputStdout:
+		w = os.Stdout
+	case OutputStderr:
+		w = os.Stderr
+	case OutputFile:
+		if opts.File == "" {
+			return nil, nil, fmt.Errorf("logz: file is required when output is %q", OutputFile)
+		}
+		f, err := NewRotatingFile(opts.File, opts.Rotate)
+		if err != nil {
+			return nil, nil, err
+		}
+		w, c = f, append(c, f)
+	default:
+		return nil, nil, fmt.Errorf("logz: unknown output %q", opts.Output)
+	}
+
+	if opts.Async != nil {
+		a := NewAsyncWriter(w, *opts.Async)
+		// the async writer must be closed first to flush the pending records
+		w, c = a, append(closers{a}, c...)
+	}
+
+	if len(c) == 0 {
+		return w, nil, nil
+	}
+	return w, c, nil
+}
+
+// closers closes all closers in order.
+type closers []io.Closer
+
+func (cs closers) Close() error {
+	var errs []error
+	for _, c := range cs {
+		if err := c.Close(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func newHandler(format string, w io.Writer, opts *slog.HandlerOptions) (slog.Handler, error) {
+	switch format {
+	case "", FormatJSON:
+		return slog.NewJSONHandler(w, opts), nil
+	case FormatText:
+		return slog.NewTextHandler(w, opts), nil
+	case FormatConsole:
+		return newConsoleHandler(w, opts), nil
+	default:
+		return nil, fmt.Errorf("logz: unknown format %q", format)
+	}
+}
@@ -0,0 +1,203 @@
+package logz
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRotatingFileBySize(t *testing.T) {
+	dir := t.TempDir()
+	filename := filepath.Join(dir, "app.log")
+
+	f, err := NewRotatingFile(filename, RotateOptions{MaxSize: 10, MaxBackups: 2, Compress: true})
+	assert.NoError(t, err)
+
+	var (
+		mu  sync.Mutex
+		now = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	)
+	f.now = func() time.Time {
+		mu.Lock()
+		defer mu.Unlock()
+		now = now.Add(time.Second)
+		return now
+	}
+
+	for _, line := range []string{"line-0001\n", "line-0002\n", "line-0003\n", "line-0004\n"} {
+		_, err := f.Write([]byte(line))
+		assert.NoError(t, err)
+	}
+	assert.NoError(t, f.Close())
+
+	content, err := os.ReadFile(filename)
+	assert.NoError(t, err)
+	assert.Equal(t, "line-0004\n", string(content))
+
+	backups, err := filepath.Glob(filepath.Join(dir, "app-*.log.gz"))
+	assert.NoError(t, err)
+	assert.Len(t, backups, 2)
+
+	gz, err := os.Open(backups[len(backups)-1])
+	assert.NoError(t, err)
+	defer gz.Close()
+	r, err := gzip.NewReader(gz)
+	assert.NoError(t, err)
+	content, err = io.ReadAll(r)
+	assert.NoError(t, err)
+	assert.Equal(t, "line-0003\n", string(content))
+}
+
+func TestRotatingFileByAge(t *testing.T) {
+	dir := t.TempDir()
+	filename := filepath.Join(dir, "app.log")
+
+	f, err := NewRotatingFile(filename, RotateOptions{Interval: time.Hour, MaxAge: 90 * time.Minute})
+	assert.NoError(t, err)
+
+	var (
+		mu  sync.Mutex
+		now = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	)
+	f.now = func() time.Time {
+		mu.Lock()
+		defer mu.Unlock()
+		return now
+	}
+	f.openedAt = now
+
+	for i := 0; i < 4; i++ {
+		_, err := f.Write([]byte("line\n"))
+		assert.NoError(t, err)
+		mu.Lock()
+		now = now.Add(time.Hour)
+		mu.Unlock()
+	}
+	// the rotation at 03:00 is the last one, its cleanup runs at 03:00 or
+	// 04:00 and removes the backups of 01:00, and of 02:00 in the latter case.
+	assert.NoError(t, f.Close())
+
+	backups, err := filepath.Glob(filepath.Join(dir, "app-*.log"))
+	assert.NoError(t, err)
+	assert.Contains(t, []int{1, 2}, len(backups), "backups older than max age are removed")
+}
+
+func TestRotatingFileSameMillisecond(t *testing.T) {
+	dir := t.TempDir()
+	filename := filepath.Join(dir, "app.log")
+
+	f, err := NewRotatingFile(filename, RotateOptions{MaxBackups: 2})
+	assert.NoError(t, err)
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	f.now = func() time.Time { return now }
+
+	for _, line := range []string{"line-0001\n", "line-0002\n", "line-0003\n"} {
+		_, err := f.Write([]byte(line))
+		assert.NoError(t, err)
+		assert.NoError(t, f.Rotate())
+	}
+	assert.NoError(t, f.Close())
+
+	// the backups of the same millisecond are kept apart, the oldest one is
+	// removed by MaxBackups
+	backups, err := f.backups()
+	assert.NoError(t, err)
+	var contents []string
+	for _, b := range backups {
+		content, err := os.ReadFile(b.path)
+		assert.NoError(t, err)
+		contents = append(contents, string(content))
+	}
+	assert.Equal(t, []string{"line-0003\n", "line-0002\n"}, contents)
+	assert.Equal(t, filepath.Join(dir, "app-2024-01-01T00-00-00.000.2.log"), backups[0].path)
+}
+
+func TestParseBackupStamp(t *testing.T) {
+	for stamp, seq := range map[string]int{
+		"2024-01-01T00-00-00.000":    0,
+		"2024-01-01T00-00-00.000.1":  1,
+		"2024-01-01T00-00-00.000.12": 12,
+		"2024-01-01T00-00-00.000.0":  -1,
+		"2024-01-01T00-00-00.000x1":  -1,
+		"2024-01-01T00-00-00.000.a":  -1,
+		"2024-01-01":                 -1,
+	} {
+		_, got, ok := parseBackupStamp(stamp)
+		if seq < 0 {
+			assert.False(t, ok, stamp)
+			continue
+		}
+		assert.True(t, ok, stamp)
+		assert.Equal(t, seq, got, stamp)
+	}
+}
+
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+type blockingWriter struct {
+	release chan struct{}
+	syncBuffer
+}
+
+func (w *blockingWriter) Write(p []byte) (int, error) {
+	<-w.release
+	return w.syncBuffer.Write(p)
+}
+
+func TestAsyncWriter(t *testing.T) {
+	var out syncBuffer
+	a := NewAsyncWriter(&out, AsyncOptions{QueueSize: 16, BufferSize: 1024, FlushInterval: time.Hour})
+
+	p := []byte("first\n")
+	_, err := a.Write(p)
+	assert.NoError(t, err)
+	copy(p, "xxxxx\n")
+	_, err = a.Write([]byte("second\n"))
+	assert.NoError(t, err)
+
+	a.Flush()
+	assert.Equal(t, "first\nsecond\n", out.String())
+
+	assert.NoError(t, a.Close())
+	_, err = a.Write([]byte("closed\n"))
+	assert.Error(t, err)
+}
+
+func TestAsyncWriterDropsWhenFull(t *testing.T) {
+	w := &blockingWriter{release: make(chan struct{})}
+	a := NewAsyncWriter(w, AsyncOptions{QueueSize: 2, BufferSize: 1, FlushInterval: time.Hour})
+
+	before := GetStats().AsyncDropped
+	for i := 0; i < 10; i++ {
+		_, err := a.Write([]byte("record\n"))
+		assert.NoError(t, err)
+	}
+	assert.Greater(t, GetStats().AsyncDropped, before)
+
+	close(w.release)
+	assert.NoError(t, a.Close())
+	assert.True(t, strings.HasPrefix(w.String(), "record\n"))
+}
@@ -0,0 +1,63 @@
+package metrics
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"demo/extension/errorx"
+)
+
+// GRPCOptions configures the metrics of the grpc server.
+type GRPCOptions struct {
+	// Duration defaults to the prometheus.DefBuckets.
+	Duration HistogramOptions
+
+	// SLO observes the calls if it is not nil, the route of the objectives
+	// matches the full method and their method matches "grpc".
+	SLO *SLO
+}
+
+// GRPCMetrics observes the calls of the grpc server, partitioned by their
+// code and outcome.
+type GRPCMetrics struct {
+	handled  *prometheus.CounterVec
+	duration *prometheus.HistogramVec
+	slo      *SLO
+}
+
+// NewGRPCMetrics returns the metrics of the grpc server registered in the
+// registry.
+func NewGRPCMetrics(subsystem string, registry prometheus.Registerer, opts GRPCOptions) (*GRPCMetrics, error) {
+	m := &GRPCMetrics{
+		handled: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Subsystem: subsystem,
+			Name:      "grpc_server_handled_total",
+			Help:      "How many gRPC calls completed, partitioned by method, code and outcome.",
+		}, []string{"method", "code", "outcome"}),
+		duration: prometheus.NewHistogramVec(
+			opts.Duration.withDefaultBuckets(prometheus.DefBuckets).opts(
+				subsystem, "grpc_server_handling_seconds", "The gRPC call latencies in seconds."),
+			[]string{"method", "outcome"},
+		),
+		slo: opts.SLO,
+	}
+
+	for _, c := range []prometheus.Collector{m.handled, m.duration} {
+		if err := registry.Register(c); err != nil {
+			return nil, fmt.Errorf("metrics: grpc collectors could not be registered: %w", err)
+		}
+	}
+	return m, nil
+}
+
+// Observe records a completed call of the full method.
+func (m *GRPCMetrics) Observe(method string, err error, elapsed time.Duration) {
+	_, code, _, _ := errorx.Explode(err)
+	outcome := Outcome(err)
+
+	m.handled.WithLabelValues(method, code.String(), outcome).Inc()
+	m.duration.WithLabelValues(method, outcome).Observe(elapsed.Seconds())
+	m.slo.Observe("grpc", method, outcome, elapsed)
+}
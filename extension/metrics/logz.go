@@ -0,0 +1,59 @@
+package metrics
+
+import (
+	"fmt"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"demo/extension/logz"
+)
+
+// NewLogCollectors returns the collectors exposing the statistics of logz.
+func NewLogCollectors() []prometheus.Collector {
+	return []prometheus.Collector{
+		prometheus.NewCounterFunc(prometheus.CounterOpts{
+			Subsystem: "logz",
+			Name:      "async_dropped_records_total",
+			Help:      "How many log records were dropped because the async writer queue was full.",
+		}, func() float64 {
+			return float64(logz.GetStats().AsyncDropped)
+		}),
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Subsystem: "logz",
+			Name:      "async_queued_records",
+			Help:      "How many log records are waiting in the async writer queue.",
+		}, func() float64 {
+			return float64(logz.GetStats().AsyncQueued)
+		}),
+		sampledDroppedCollector{},
+	}
+}
+
+var sampledDroppedDesc = prometheus.NewDesc(
+	prometheus.BuildFQName("", "logz", "sampled_dropped_records_total"),
+	"How many log records were dropped by sampling, partitioned by sampler.",
+	[]string{"sampler"}, nil,
+)
+
+// sampledDroppedCollector exposes the records dropped by the logz samplers.
+type sampledDroppedCollector struct{}
+
+func (sampledDroppedCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- sampledDroppedDesc
+}
+
+func (sampledDroppedCollector) Collect(ch chan<- prometheus.Metric) {
+	for name, dropped := range logz.SampledDropped() {
+		ch <- prometheus.MustNewConstMetric(sampledDroppedDesc, prometheus.CounterValue, float64(dropped), name)
+	}
+}
+
+// RegisterLogMetrics registers the logz collectors in the registry.
+func RegisterLogMetrics(reg prometheus.Registerer) error {
+	for _, c := range NewLogCollectors() {
+		if err := reg.Register(c); err != nil {
+			return fmt.Errorf("metrics: logz collectors could not be registered: %w", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,328 @@
+package metrics
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/expfmt"
+	"github.com/stretchr/testify/assert"
+
+	"demo/extension/errorx"
+)
+
+func gathered(t *testing.T, reg *prometheus.Registry) map[string]bool {
+	families, err := reg.Gather()
+	assert.NoError(t, err)
+	names := make(map[string]bool, len(families))
+	for _, f := range families {
+		names[f.GetName()] = true
+	}
+	return names
+}
+
+func TestRegistry(t *testing.T) {
+	reg := NewRegistry()
+	names := gathered(t, reg)
+	assert.True(t, names["go_goroutines"])
+	assert.True(t, names["process_start_time_seconds"])
+
+	opts := prometheus.CounterOpts{Name: "orders_total", Help: "How many orders were created."}
+	orders, err := Register(reg, prometheus.NewCounterVec(opts, []string{"status"}))
+	assert.NoError(t, err)
+	orders.WithLabelValues("paid").Inc()
+
+	// an equal collector shares the registered one
+	again, err := Register(reg, prometheus.NewCounterVec(opts, []string{"status"}))
+	assert.NoError(t, err)
+	assert.Same(t, orders, again)
+
+	_, err = Register(reg, prometheus.NewCounter(prometheus.CounterOpts{Name: "orders_total", Help: "How many orders were created."}))
+	assert.Error(t, err)
+
+	assert.NoError(t, RegisterLogMetrics(reg))
+	assert.True(t, gathered(t, reg)["logz_async_queued_records"])
+}
+
+func TestPrometheusInstances(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	serve := func(reg *prometheus.Registry) *gin.Engine {
+		p, err := NewPrometheus("demo", reg, Options{})
+		assert.NoError(t, err)
+		engine := gin.New()
+		p.Use(engine)
+		engine.GET("/hello", func(c *gin.Context) { c.String(http.StatusOK, "hello") })
+		return engine
+	}
+
+	// two instances do not conflict as each one has its own registry
+	first, second := NewRegistry(), NewRegistry()
+	e1, e2 := serve(first), serve(second)
+
+	e1.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/hello", nil))
+
+	rec := httptest.NewRecorder()
+	e1.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	assert.Contains(t, rec.Body.String(), `demo_requests_total{code="200",method="GET",outcome="success",url="/hello"} 1`)
+	assert.Contains(t, rec.Body.String(), "go_goroutines")
+
+	rec = httptest.NewRecorder()
+	e2.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	assert.NotContains(t, rec.Body.String(), `url="/hello"`)
+}
+
+type pushGateway struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	failures int
+	pushes   []pushRequest
+}
+
+type pushRequest struct {
+	method, path, user, password, body string
+}
+
+func newPushGateway(failures int) *pushGateway {
+	g := &pushGateway{failures: failures}
+	g.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		g.mu.Lock()
+		defer g.mu.Unlock()
+
+		if g.failures > 0 {
+			g.failures--
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		user, password, _ := r.BasicAuth()
+		g.pushes = append(g.pushes, pushRequest{r.Method, r.URL.Path, user, password, string(body)})
+		w.WriteHeader(http.StatusOK)
+	}))
+	return g
+}
+
+func (g *pushGateway) requests() []pushRequest {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return append([]pushRequest(nil), g.pushes...)
+}
+
+func TestPusher(t *testing.T) {
+	gateway := newPushGateway(2)
+	defer gateway.Close()
+
+	reg := prometheus.NewRegistry()
+	counter := MustRegister(reg, prometheus.NewCounter(prometheus.CounterOpts{Name: "jobs_total", Help: "How many jobs ran."}))
+	counter.Inc()
+
+	pusher, err := NewPusher(reg, PushOptions{
+		URL:        gateway.URL,
+		Job:        "demo",
+		Grouping:   map[string]string{"instance": "node-1", "zone": "a"},
+		Username:   "user",
+		Password:   "secret",
+		MaxRetries: 2,
+		Backoff:    time.Millisecond,
+	})
+	assert.NoError(t, err)
+
+	// the first two pushes fail and are retried
+	assert.NoError(t, pusher.Push(context.Background()))
+	pushes := gateway.requests()
+	assert.Len(t, pushes, 1)
+	assert.Equal(t, http.MethodPut, pushes[0].method)
+	// the order of the grouping labels in the path is not defined
+	assert.True(t, strings.HasPrefix(pushes[0].path, "/metrics/job/demo/"))
+	assert.Contains(t, pushes[0].path, "/instance/node-1")
+	assert.Contains(t, pushes[0].path, "/zone/a")
+	assert.Equal(t, "user", pushes[0].user)
+	assert.Equal(t, "secret", pushes[0].password)
+
+	gateway.mu.Lock()
+	gateway.failures = 3
+	gateway.mu.Unlock()
+	assert.Error(t, pusher.Push(context.Background()))
+}
+
+func TestPusherLifecycle(t *testing.T) {
+	gateway := newPushGateway(0)
+	defer gateway.Close()
+
+	reg := prometheus.NewRegistry()
+	counter := MustRegister(reg, prometheus.NewCounter(prometheus.CounterOpts{Name: "jobs_total", Help: "How many jobs ran."}))
+
+	pusher, err := NewPusher(reg, PushOptions{URL: gateway.URL, Job: "demo", Interval: 10 * time.Millisecond})
+	assert.NoError(t, err)
+	pusher.Start()
+	time.Sleep(35 * time.Millisecond)
+
+	counter.Add(5)
+	assert.NoError(t, pusher.Stop(context.Background()))
+	pushed := len(gateway.requests())
+	assert.GreaterOrEqual(t, pushed, 2)
+
+	// the final push has the last values, and no push happens after Stop
+	pushes := gateway.requests()
+	var family dto.MetricFamily
+	decoder := expfmt.NewDecoder(strings.NewReader(pushes[len(pushes)-1].body), expfmt.FmtProtoDelim)
+	assert.NoError(t, decoder.Decode(&family))
+	assert.Equal(t, "jobs_total", family.GetName())
+	assert.Equal(t, 5.0, family.GetMetric()[0].GetCounter().GetValue())
+	time.Sleep(30 * time.Millisecond)
+	assert.Len(t, gateway.requests(), pushed)
+}
+
+func TestPrometheusHistograms(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	reg := prometheus.NewRegistry()
+	p, err := NewPrometheus("demo", reg, Options{
+		Duration:     HistogramOptions{Buckets: []float64{0.1, 1}, NativeBucketFactor: 1.1, NativeMaxBuckets: 100},
+		ResponseSize: HistogramOptions{Buckets: []float64{10, 1000}},
+	})
+	assert.NoError(t, err)
+
+	engine := gin.New()
+	engine.Use(p.HandlerFunc())
+	var inFlight float64
+	engine.GET("/orders/:id", func(c *gin.Context) {
+		inFlight = gaugeValue(t, reg, "demo_requests_in_flight")
+		c.String(http.StatusOK, "order")
+	})
+	engine.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/orders/1", nil))
+
+	assert.Equal(t, 1.0, inFlight)
+	assert.Equal(t, 0.0, gaugeValue(t, reg, "demo_requests_in_flight"))
+
+	families, err := reg.Gather()
+	assert.NoError(t, err)
+	histograms := make(map[string]*dto.Metric)
+	for _, f := range families {
+		if f.GetType() == dto.MetricType_HISTOGRAM {
+			histograms[f.GetName()] = f.GetMetric()[0]
+		}
+	}
+
+	duration := histograms["demo_request_duration_seconds"]
+	assert.Len(t, duration.GetHistogram().GetBucket(), 2)
+	assert.NotNil(t, duration.GetHistogram().Schema, "the native histogram is exposed")
+
+	size := histograms["demo_response_size_bytes"]
+	assert.Len(t, size.GetHistogram().GetBucket(), 2)
+	assert.Nil(t, size.GetHistogram().Schema)
+	labels := make(map[string]string)
+	for _, l := range size.GetLabel() {
+		labels[l.GetName()] = l.GetValue()
+	}
+	assert.Equal(t, map[string]string{"code": "200", "method": "GET", "url": "/orders/:id"}, labels)
+
+	assert.Len(t, histograms["demo_request_size_bytes"].GetHistogram().GetBucket(), len(DefaultSizeBuckets))
+}
+
+func gaugeValue(t *testing.T, reg *prometheus.Registry, name string) float64 {
+	families, err := reg.Gather()
+	assert.NoError(t, err)
+	for _, f := range families {
+		if f.GetName() == name {
+			return f.GetMetric()[0].GetGauge().GetValue()
+		}
+	}
+	return -1
+}
+
+func TestOutcome(t *testing.T) {
+	assert.Equal(t, OutcomeSuccess, Outcome(nil))
+	assert.Equal(t, OutcomeBizFault, Outcome(errorx.ErrResourceNotFound))
+	assert.Equal(t, OutcomeBizFault, Outcome(errorx.ErrIllegalArgument.WithWrap(io.EOF)))
+	assert.Equal(t, OutcomeSysFault, Outcome(errorx.ErrInternalServer.Wrap(io.EOF)))
+	assert.Equal(t, OutcomeSysFault, Outcome(io.EOF))
+	assert.Equal(t, OutcomeBizFault, Outcome(errorx.NewErrorWithMessage(1, "custom", "")))
+	assert.Equal(t, OutcomeSysFault, Outcome(errorx.NewErrorWithMessage(1, "custom", "").WithError(io.EOF)))
+
+	assert.Equal(t, OutcomeSuccess, StatusOutcome(http.StatusNoContent))
+	assert.Equal(t, OutcomeBizFault, StatusOutcome(http.StatusNotFound))
+	assert.Equal(t, OutcomeSysFault, StatusOutcome(http.StatusBadGateway))
+}
+
+func TestSLO(t *testing.T) {
+	_, err := NewSLO("demo", SLOOptions{Objectives: []Objective{{Name: "empty"}}})
+	assert.Error(t, err)
+	_, err = NewSLO("demo", SLOOptions{Windows: []time.Duration{time.Second}})
+	assert.Error(t, err)
+
+	slo, err := NewSLO("demo", SLOOptions{
+		Objectives: []Objective{
+			{Name: "orders", Route: "/orders/:id", Availability: 0.9, Latency: 100 * time.Millisecond, LatencyTarget: 0.5},
+			{Name: "all", Availability: 0.99},
+		},
+		Windows:    []time.Duration{time.Minute, 10 * time.Minute},
+		Resolution: 10 * time.Second,
+	})
+	assert.NoError(t, err)
+	now := time.Unix(1700000000, 0)
+	slo.now = func() time.Time { return now }
+
+	reg := prometheus.NewRegistry()
+	reg.MustRegister(slo)
+
+	// 10 minutes ago, only in the long window
+	now = now.Add(-5 * time.Minute)
+	slo.Observe(http.MethodGet, "/orders/:id", OutcomeSysFault, time.Millisecond)
+	now = now.Add(5 * time.Minute)
+	slo.Observe(http.MethodGet, "/orders/:id", OutcomeSuccess, time.Second)
+	slo.Observe(http.MethodGet, "/orders/:id", OutcomeBizFault, time.Millisecond)
+	slo.Observe(http.MethodGet, "/hello", OutcomeSuccess, time.Millisecond)
+
+	families, err := reg.Gather()
+	assert.NoError(t, err)
+	values := make(map[string]float64)
+	for _, f := range families {
+		for _, m := range f.GetMetric() {
+			key := f.GetName()
+			for _, l := range m.GetLabel() {
+				key += "," + l.GetValue()
+			}
+			values[key] = m.GetGauge().GetValue()
+		}
+	}
+
+	assert.Equal(t, 0.9, values["demo_slo_objective,orders,availability"])
+	assert.Equal(t, 2.0, values["demo_slo_requests,orders,1m0s"])
+	assert.Equal(t, 3.0, values["demo_slo_requests,orders,10m0s"])
+	assert.Equal(t, 4.0, values["demo_slo_requests,all,10m0s"])
+	assert.Equal(t, 0.0, values["demo_slo_burn_rate,orders,availability,1m0s"], "business faults do not burn the budget")
+	assert.InDelta(t, 1.0/3/0.1, values["demo_slo_burn_rate,orders,availability,10m0s"], 1e-9)
+	assert.InDelta(t, 0.5, values["demo_slo_sli,orders,latency,1m0s"], 1e-9)
+	assert.InDelta(t, 1, values["demo_slo_burn_rate,orders,latency,1m0s"], 1e-9)
+	assert.InDelta(t, 1-1.0/3/0.1, values["demo_slo_error_budget_remaining,orders,availability"], 1e-9)
+}
+
+func TestGRPCMetrics(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	m, err := NewGRPCMetrics("demo", reg, GRPCOptions{})
+	assert.NoError(t, err)
+	_, err = NewGRPCMetrics("demo", reg, GRPCOptions{})
+	assert.Error(t, err, "the collectors are registered once")
+
+	m.Observe("/demo.Orders/Get", nil, time.Millisecond)
+	m.Observe("/demo.Orders/Get", errorx.ErrResourceNotFound, time.Millisecond)
+	m.Observe("/demo.Orders/Get", errorx.ErrInternalServer.Wrap(io.EOF), time.Millisecond)
+
+	rec := httptest.NewRecorder()
+	Handler(reg).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	body := rec.Body.String()
+	assert.Contains(t, body, `demo_grpc_server_handled_total{code="OK",method="/demo.Orders/Get",outcome="success"} 1`)
+	assert.Contains(t, body, `outcome="biz_fault"} 1`)
+	assert.Contains(t, body, `outcome="sys_fault"} 1`)
+	assert.Contains(t, body, `demo_grpc_server_handling_seconds_count{method="/demo.Orders/Get",outcome="success"} 1`)
+}
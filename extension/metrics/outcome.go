@@ -0,0 +1,54 @@
+package metrics
+
+import (
+	"errors"
+
+	"demo/extension/errorx"
+)
+
+// Outcomes of the requests, the business faults are caused by the callers
+// and do not count against the availability.
+const (
+	OutcomeSuccess  = "success"
+	OutcomeBizFault = "biz_fault"
+	OutcomeSysFault = "sys_fault"
+)
+
+// Outcome classifies err by its errorx level. The errors below LevelError
+// are business faults and the others are system faults, the errors without a
+// level are business faults if errorx.IsBizFault reports so. The errors which
+// are not errorx errors are system faults.
+func Outcome(err error) string {
+	if err == nil {
+		return OutcomeSuccess
+	}
+
+	var ex errorx.Error
+	if !errors.As(err, &ex) {
+		return OutcomeSysFault
+	}
+
+	switch level := ex.Level(); {
+	case level == 0:
+		if errorx.IsBizFault(ex) {
+			return OutcomeBizFault
+		}
+		return OutcomeSysFault
+	case level >= errorx.LevelError:
+		return OutcomeSysFault
+	default:
+		return OutcomeBizFault
+	}
+}
+
+// StatusOutcome classifies the http status of a response without error.
+func StatusOutcome(status int) string {
+	switch {
+	case status >= 500:
+		return OutcomeSysFault
+	case status >= 400:
+		return OutcomeBizFault
+	default:
+		return OutcomeSuccess
+	}
+}
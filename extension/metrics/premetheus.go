@@ -1,60 +1,127 @@
 package metrics
 
 import (
-	"bytes"
-	"context"
 	"fmt"
-	"io/ioutil"
 	"net/http"
-	"os"
 	"strconv"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
-
-	"demo/extension/logz"
 )
 
 var defaultMetricPath = "/metrics"
 
-// Standard default metrics
+// IDs of the standard metrics.
+const (
+	reqCntID   = "reqCnt"
+	reqDurID   = "reqDur"
+	resSzID    = "resSz"
+	reqSzID    = "reqSz"
+	inFlightID = "inFlight"
+)
+
+// DefaultSizeBuckets are the buckets of the request and response sizes, from
+// 100 bytes to 100 megabytes.
+var DefaultSizeBuckets = prometheus.ExponentialBuckets(100, 10, 7)
+
+// Options configures the histograms of the standard metrics.
+type Options struct {
+	// Duration defaults to the prometheus.DefBuckets.
+	Duration HistogramOptions
+	// RequestSize and ResponseSize default to the DefaultSizeBuckets.
+	RequestSize  HistogramOptions
+	ResponseSize HistogramOptions
+
+	// SLO observes the requests if it is not nil, it is registered by the
+	// caller.
+	SLO *SLO
+}
+
+// HistogramOptions configures the buckets of a histogram.
+type HistogramOptions struct {
+	// Buckets are the upper bounds of the classic buckets.
+	Buckets []float64
+
+	// NativeBucketFactor greater than 1 exposes a native histogram in
+	// addition to the classic buckets, the bucket boundaries grow by the
+	// factor, for example 1.1.
+	NativeBucketFactor float64
+	// NativeMaxBuckets limits the buckets of the native histogram, the
+	// resolution is reduced once it is reached. Zero means no limit.
+	NativeMaxBuckets uint32
+	// NativeMinResetDuration is the minimum time between resets of the native
+	// histogram when it reaches NativeMaxBuckets.
+	NativeMinResetDuration time.Duration
+}
+
+func (o HistogramOptions) withDefaultBuckets(buckets []float64) HistogramOptions {
+	if len(o.Buckets) == 0 {
+		o.Buckets = buckets
+	}
+	return o
+}
+
+func (o HistogramOptions) opts(subsystem, name, help string) prometheus.HistogramOpts {
+	return prometheus.HistogramOpts{
+		Subsystem:                       subsystem,
+		Name:                            name,
+		Help:                            help,
+		Buckets:                         o.Buckets,
+		NativeHistogramBucketFactor:     o.NativeBucketFactor,
+		NativeHistogramMaxBucketNumber:  o.NativeMaxBuckets,
+		NativeHistogramMinResetDuration: o.NativeMinResetDuration,
+	}
+}
+
+// standardMetrics returns the definitions of the standard metrics, they are
+// created for each instance so that the collectors of an instance are never
+// shared with another one.
 //
 //	counter, counter_vec, gauge, gauge_vec,
 //	histogram, histogram_vec, summary, summary_vec
-var reqCnt = &Metric{
-	ID:          "reqCnt",
-	Name:        "requests_total",
-	Description: "How many HTTP requests processed, partitioned by status code and HTTP method.",
-	Type:        "counter_vec",
-	Args:        []string{"code", "method", "url"}}
-
-var reqDur = &Metric{
-	ID:          "reqDur",
-	Name:        "request_duration_seconds",
-	Description: "The HTTP request latencies in seconds.",
-	Type:        "histogram_vec",
-	Args:        []string{"code", "method", "url"},
-}
-
-var resSz = &Metric{
-	ID:          "resSz",
-	Name:        "response_size_bytes",
-	Description: "The HTTP response sizes in bytes.",
-	Type:        "summary"}
-
-var reqSz = &Metric{
-	ID:          "reqSz",
-	Name:        "request_size_bytes",
-	Description: "The HTTP request sizes in bytes.",
-	Type:        "summary"}
-
-var standardMetrics = []*Metric{
-	reqCnt,
-	reqDur,
-	resSz,
-	reqSz,
+func standardMetrics(opts Options) []*Metric {
+	return []*Metric{
+		{
+			ID:          reqCntID,
+			Name:        "requests_total",
+			Description: "How many HTTP requests processed, partitioned by status code, HTTP method and outcome.",
+			Type:        "counter_vec",
+			Args:        []string{"code", "method", "url", "outcome"},
+		},
+		{
+			ID:          reqDurID,
+			Name:        "request_duration_seconds",
+			Description: "The HTTP request latencies in seconds.",
+			Type:        "histogram_vec",
+			Args:        []string{"code", "method", "url", "outcome"},
+			Histogram:   opts.Duration.withDefaultBuckets(prometheus.DefBuckets),
+		},
+		{
+			ID:          resSzID,
+			Name:        "response_size_bytes",
+			Description: "The HTTP response sizes in bytes.",
+			Type:        "histogram_vec",
+			Args:        []string{"code", "method", "url"},
+			Histogram:   opts.ResponseSize.withDefaultBuckets(DefaultSizeBuckets),
+		},
+		{
+			ID:          reqSzID,
+			Name:        "request_size_bytes",
+			Description: "The HTTP request sizes in bytes.",
+			Type:        "histogram_vec",
+			Args:        []string{"code", "method", "url"},
+			Histogram:   opts.RequestSize.withDefaultBuckets(DefaultSizeBuckets),
+		},
+		{
+			ID:          inFlightID,
+			Name:        "requests_in_flight",
+			Description: "How many HTTP requests are being served.",
+			Type:        "gauge_vec",
+			Args:        []string{"method", "url"},
+		},
+	}
 }
 
 /*
@@ -87,16 +154,19 @@ type Metric struct {
 	Description     string
 	Type            string
 	Args            []string
+
+	// Histogram configures the buckets of the histogram types.
+	Histogram HistogramOptions
 }
 
 // Prometheus contains the metrics gathered by the instance and its path
 type Prometheus struct {
-	reqCnt        *prometheus.CounterVec
-	reqDur        *prometheus.HistogramVec
-	reqSz, resSz  prometheus.Summary
-	router        *gin.Engine
-	listenAddress string
-	Ppg           PrometheusPushGateway
+	reqCnt       *prometheus.CounterVec
+	reqDur       *prometheus.HistogramVec
+	reqSz, resSz *prometheus.HistogramVec
+	inFlight     *prometheus.GaugeVec
+	registry     *prometheus.Registry
+	slo          *SLO
 
 	MetricsList []*Metric
 	MetricsPath string
@@ -107,145 +177,49 @@ type Prometheus struct {
 	URLLabelFromContext string
 }
 
-// PrometheusPushGateway contains the configuration for pushing to a Prometheus pushgateway (optional)
-type PrometheusPushGateway struct {
-
-	// Push interval in seconds
-	PushIntervalSeconds time.Duration
-
-	// Push Gateway URL in format http://domain:port
-	// where JOBNAME can be any string of your choice
-	PushGatewayURL string
-
-	// Local metrics URL where metrics are fetched from, this could be ommited in the future
-	// if implemented using prometheus common/expfmt instead
-	MetricsURL string
-
-	// pushgateway job name, defaults to "gin"
-	Job string
-}
-
-// NewPrometheus generates a new set of metrics with a certain subsystem name
-func NewPrometheus(subsystem string, customMetricsList ...[]*Metric) *Prometheus {
+// NewPrometheus generates a new set of metrics with a certain subsystem name,
+// they are registered in the registry and exposed from it.
+func NewPrometheus(subsystem string, registry *prometheus.Registry, opts Options, customMetricsList ...[]*Metric) (*Prometheus, error) {
 
 	var metricsList []*Metric
 
 	if len(customMetricsList) > 1 {
-		panic("Too many args. NewPrometheus( string, <optional []*Metric> ).")
+		panic("Too many args. NewPrometheus( string, *prometheus.Registry, Options, <optional []*Metric> ).")
 	} else if len(customMetricsList) == 1 {
 		metricsList = customMetricsList[0]
 	}
 
-	for _, metric := range standardMetrics {
-		metricsList = append(metricsList, metric)
-	}
+	metricsList = append(metricsList, standardMetrics(opts)...)
 
 	p := &Prometheus{
 		MetricsList: metricsList,
 		MetricsPath: defaultMetricPath,
+		registry:    registry,
+		slo:         opts.SLO,
 		ReqCntURLLabelMappingFn: func(c *gin.Context) string {
 			return c.FullPath()
 		},
 	}
 
-	p.registerMetrics(subsystem)
-
-	return p
-}
-
-// SetPushGateway sends metrics to a remote pushgateway exposed on pushGatewayURL
-// every pushIntervalSeconds. Metrics are fetched from metricsURL
-func (p *Prometheus) SetPushGateway(pushGatewayURL, metricsURL string, pushIntervalSeconds time.Duration) {
-	p.Ppg.PushGatewayURL = pushGatewayURL
-	p.Ppg.MetricsURL = metricsURL
-	p.Ppg.PushIntervalSeconds = pushIntervalSeconds
-	p.startPushTicker()
-}
-
-// SetPushGatewayJob job name, defaults to "gin"
-func (p *Prometheus) SetPushGatewayJob(j string) {
-	p.Ppg.Job = j
-}
-
-// SetListenAddress for exposing metrics on address. If not set, it will be exposed at the
-// same address of the gin engine that is being used
-func (p *Prometheus) SetListenAddress(address string) {
-	p.listenAddress = address
-	if p.listenAddress != "" {
-		p.router = gin.Default()
+	if err := p.registerMetrics(subsystem); err != nil {
+		return nil, err
 	}
+	return p, nil
 }
 
-// SetListenAddressWithRouter for using a separate router to expose metrics. (this keeps things like GET /metrics out of
-// your content's access log).
-func (p *Prometheus) SetListenAddressWithRouter(listenAddress string, r *gin.Engine) {
-	p.listenAddress = listenAddress
-	if len(p.listenAddress) > 0 {
-		p.router = r
-	}
+// Registry returns the registry of the metrics.
+func (p *Prometheus) Registry() *prometheus.Registry {
+	return p.registry
 }
 
 // SetMetricsPath set metrics paths
 func (p *Prometheus) SetMetricsPath(e *gin.Engine) {
-
-	if p.listenAddress != "" {
-		p.router.GET(p.MetricsPath, prometheusHandler())
-		p.runServer()
-	} else {
-		e.GET(p.MetricsPath, prometheusHandler())
-	}
+	e.GET(p.MetricsPath, p.prometheusHandler())
 }
 
 // SetMetricsPathWithAuth set metrics paths with authentication
 func (p *Prometheus) SetMetricsPathWithAuth(e *gin.Engine, accounts gin.Accounts) {
-
-	if p.listenAddress != "" {
-		p.router.GET(p.MetricsPath, gin.BasicAuth(accounts), prometheusHandler())
-		p.runServer()
-	} else {
-		e.GET(p.MetricsPath, gin.BasicAuth(accounts), prometheusHandler())
-	}
-
-}
-
-func (p *Prometheus) runServer() {
-	if p.listenAddress != "" {
-		go p.router.Run(p.listenAddress)
-	}
-}
-
-func (p *Prometheus) getMetrics() []byte {
-	response, _ := http.Get(p.Ppg.MetricsURL)
-
-	defer response.Body.Close()
-	body, _ := ioutil.ReadAll(response.Body)
-
-	return body
-}
-
-func (p *Prometheus) getPushGatewayURL() string {
-	h, _ := os.Hostname()
-	if p.Ppg.Job == "" {
-		p.Ppg.Job = "gin"
-	}
-	return p.Ppg.PushGatewayURL + "/metrics/job/" + p.Ppg.Job + "/instance/" + h
-}
-
-func (p *Prometheus) sendMetricsToPushGateway(metrics []byte) {
-	req, err := http.NewRequest("POST", p.getPushGatewayURL(), bytes.NewBuffer(metrics))
-	client := &http.Client{}
-	if _, err = client.Do(req); err != nil {
-		logz.Error(context.Background(), "Error sending to push gateway", logz.Err(err))
-	}
-}
-
-func (p *Prometheus) startPushTicker() {
-	ticker := time.NewTicker(time.Second * p.Ppg.PushIntervalSeconds)
-	go func() {
-		for range ticker.C {
-			p.sendMetricsToPushGateway(p.getMetrics())
-		}
-	}()
+	e.GET(p.MetricsPath, gin.BasicAuth(accounts), p.prometheusHandler())
 }
 
 // NewMetric associates prometheus.Collector based on Metric.Type
@@ -288,20 +262,12 @@ func NewMetric(m *Metric, subsystem string) prometheus.Collector {
 		)
 	case "histogram_vec":
 		metric = prometheus.NewHistogramVec(
-			prometheus.HistogramOpts{
-				Subsystem: subsystem,
-				Name:      m.Name,
-				Help:      m.Description,
-			},
+			m.Histogram.opts(subsystem, m.Name, m.Description),
 			m.Args,
 		)
 	case "histogram":
 		metric = prometheus.NewHistogram(
-			prometheus.HistogramOpts{
-				Subsystem: subsystem,
-				Name:      m.Name,
-				Help:      m.Description,
-			},
+			m.Histogram.opts(subsystem, m.Name, m.Description),
 		)
 	case "summary_vec":
 		metric = prometheus.NewSummaryVec(
@@ -324,25 +290,28 @@ func NewMetric(m *Metric, subsystem string) prometheus.Collector {
 	return metric
 }
 
-func (p *Prometheus) registerMetrics(subsystem string) {
+func (p *Prometheus) registerMetrics(subsystem string) error {
 
 	for _, metricDef := range p.MetricsList {
 		metric := NewMetric(metricDef, subsystem)
-		if err := prometheus.Register(metric); err != nil {
-			logz.Error(context.Background(), fmt.Sprintf("%s counld not be registred in Prometheus", metricDef.Name), logz.Err(err))
+		if err := p.registry.Register(metric); err != nil {
+			return fmt.Errorf("metrics: %s could not be registered: %w", metricDef.Name, err)
 		}
-		switch metricDef {
-		case reqCnt:
+		switch metricDef.ID {
+		case reqCntID:
 			p.reqCnt = metric.(*prometheus.CounterVec)
-		case reqDur:
+		case reqDurID:
 			p.reqDur = metric.(*prometheus.HistogramVec)
-		case resSz:
-			p.resSz = metric.(prometheus.Summary)
-		case reqSz:
-			p.reqSz = metric.(prometheus.Summary)
+		case resSzID:
+			p.resSz = metric.(*prometheus.HistogramVec)
+		case reqSzID:
+			p.reqSz = metric.(*prometheus.HistogramVec)
+		case inFlightID:
+			p.inFlight = metric.(*prometheus.GaugeVec)
 		}
 		metricDef.MetricCollector = metric
 	}
+	return nil
 }
 
 // Use adds the middleware to a gin engine.
@@ -367,6 +336,11 @@ func (p *Prometheus) HandlerFunc() gin.HandlerFunc {
 
 		start := time.Now()
 		reqSz := computeApproximateRequestSize(c.Request)
+		url := p.ReqCntURLLabelMappingFn(c)
+
+		inFlight := p.inFlight.WithLabelValues(c.Request.Method, url)
+		inFlight.Inc()
+		defer inFlight.Dec()
 
 		c.Next()
 
@@ -375,11 +349,17 @@ func (p *Prometheus) HandlerFunc() gin.HandlerFunc {
 		}
 
 		status := strconv.Itoa(c.Writer.Status())
-		elapsed := float64(time.Since(start)) / float64(time.Second)
-		resSz := float64(c.Writer.Size())
+		elapsed := time.Since(start)
+		resSz := float64(max(c.Writer.Size(), 0))
+		outcome := StatusOutcome(c.Writer.Status())
+		if err := c.Errors.Last(); err != nil {
+			outcome = Outcome(err.Err)
+		}
 
-		url := p.ReqCntURLLabelMappingFn(c)
-		p.reqDur.WithLabelValues(status, c.Request.Method, url).Observe(elapsed)
+		p.slo.Observe(c.Request.Method, url, outcome, elapsed)
+		p.reqDur.WithLabelValues(status, c.Request.Method, url, outcome).Observe(elapsed.Seconds())
+		p.reqSz.WithLabelValues(status, c.Request.Method, url).Observe(float64(reqSz))
+		p.resSz.WithLabelValues(status, c.Request.Method, url).Observe(resSz)
 		// jlambert Oct 2018 - sidecar specific mod
 		if len(p.URLLabelFromContext) > 0 {
 			u, found := c.Get(p.URLLabelFromContext)
@@ -388,19 +368,23 @@ func (p *Prometheus) HandlerFunc() gin.HandlerFunc {
 			}
 			url = u.(string)
 		}
-		p.reqCnt.WithLabelValues(status, c.Request.Method, url).Inc()
-		p.reqSz.Observe(float64(reqSz))
-		p.resSz.Observe(resSz)
+		p.reqCnt.WithLabelValues(status, c.Request.Method, url, outcome).Inc()
 	}
 }
 
-func prometheusHandler() gin.HandlerFunc {
-	h := promhttp.Handler()
+func (p *Prometheus) prometheusHandler() gin.HandlerFunc {
+	h := Handler(p.registry)
 	return func(c *gin.Context) {
 		h.ServeHTTP(c.Writer, c.Request)
 	}
 }
 
+// Handler exposes the metrics of the registry, for example on the admin
+// listener instead of the engine of the api.
+func Handler(registry *prometheus.Registry) http.Handler {
+	return promhttp.InstrumentMetricHandler(registry, promhttp.HandlerFor(registry, promhttp.HandlerOpts{}))
+}
+
 // From https://github.com/DanielHeckrath/gin-prometheus/blob/master/gin_prometheus.go
 func computeApproximateRequestSize(r *http.Request) int {
 	s := 0
@@ -0,0 +1,173 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/push"
+
+	"demo/extension/logz"
+)
+
+// PushOptions is the options of the Pusher.
+type PushOptions struct {
+	// URL of the push gateway in format http://domain:port.
+	URL string
+
+	// Job is the job label of the pushed metrics.
+	Job string
+
+	// Grouping are the labels of the group in addition to the job, the
+	// instance label defaults to the hostname.
+	Grouping map[string]string
+
+	// Interval between the pushes, defaults to 15 seconds.
+	Interval time.Duration
+
+	// Username and Password are sent with basic authentication if Username
+	// is not empty.
+	Username string
+	Password string
+
+	// Timeout of a push request, defaults to 10 seconds.
+	Timeout time.Duration
+
+	// MaxRetries is how many times a failed push is retried, the delay
+	// starts at Backoff and doubles after each retry.
+	MaxRetries int
+	Backoff    time.Duration
+
+	// Client defaults to an http.Client with the timeout.
+	Client *http.Client
+}
+
+// Pusher pushes the metrics gathered from a registry to a push gateway, the
+// whole group of the job is replaced by each push.
+type Pusher struct {
+	opts   PushOptions
+	pusher *push.Pusher
+
+	startOnce sync.Once
+	stopOnce  sync.Once
+	started   bool
+	stop      chan struct{}
+	done      chan struct{}
+}
+
+func NewPusher(gatherer prometheus.Gatherer, opts PushOptions) (*Pusher, error) {
+	if opts.URL == "" || opts.Job == "" {
+		return nil, errors.New("metrics: push gateway url and job are required")
+	}
+	if opts.Interval <= 0 {
+		opts.Interval = 15 * time.Second
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = 10 * time.Second
+	}
+	if opts.Backoff <= 0 {
+		opts.Backoff = time.Second
+	}
+	if opts.Client == nil {
+		opts.Client = &http.Client{Timeout: opts.Timeout}
+	}
+
+	pusher := push.New(opts.URL, opts.Job).Gatherer(gatherer).Client(opts.Client)
+	if _, ok := opts.Grouping["instance"]; !ok {
+		if hostname, err := os.Hostname(); err == nil {
+			pusher = pusher.Grouping("instance", hostname)
+		}
+	}
+	for name, value := range opts.Grouping {
+		pusher = pusher.Grouping(name, value)
+	}
+	if opts.Username != "" {
+		pusher = pusher.BasicAuth(opts.Username, opts.Password)
+	}
+	if err := pusher.Error(); err != nil {
+		return nil, err
+	}
+
+	return &Pusher{
+		opts:   opts,
+		pusher: pusher,
+		stop:   make(chan struct{}),
+		done:   make(chan struct{}),
+	}, nil
+}
+
+// Push pushes the metrics, the failed pushes are retried with backoff until
+// MaxRetries is reached or the context is done.
+func (p *Pusher) Push(ctx context.Context) error {
+	backoff := p.opts.Backoff
+	for attempt := 0; ; attempt++ {
+		err := p.pusher.PushContext(ctx)
+		if err == nil || attempt >= p.opts.MaxRetries {
+			return err
+		}
+
+		logz.Warn(ctx, "[metrics] push failed, retrying", logz.Err(err), logz.Int("attempt", attempt+1))
+		select {
+		case <-ctx.Done():
+			return errors.Join(err, ctx.Err())
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+}
+
+// Start pushes the metrics every interval until Stop is called.
+func (p *Pusher) Start() {
+	p.startOnce.Do(p.start)
+}
+
+func (p *Pusher) start() {
+	p.started = true
+	go func() {
+		defer close(p.done)
+
+		ticker := time.NewTicker(p.opts.Interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-p.stop:
+				return
+			case <-ticker.C:
+				ctx, cancel := context.WithCancel(context.Background())
+				go func() {
+					select {
+					case <-p.stop:
+						cancel()
+					case <-ctx.Done():
+					}
+				}()
+				if err := p.Push(ctx); err != nil {
+					logz.Error(ctx, "[metrics] push failed", logz.Err(err))
+				}
+				cancel()
+			}
+		}
+	}()
+}
+
+// Stop stops the pushes started by Start and pushes the metrics a last time,
+// so that the final values are not lost at shutdown.
+func (p *Pusher) Stop(ctx context.Context) error {
+	p.stopOnce.Do(func() {
+		close(p.stop)
+	})
+	p.startOnce.Do(func() {})
+	if p.started {
+		select {
+		case <-p.done:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return p.Push(ctx)
+}
@@ -0,0 +1,54 @@
+package metrics
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+)
+
+// NewRegistry returns a registry with the Go runtime and the process
+// collectors, the metrics of an application are registered in it instead of
+// the global default registry.
+func NewRegistry() *prometheus.Registry {
+	reg := prometheus.NewRegistry()
+	reg.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+	return reg
+}
+
+// Register registers the collector and returns it, so that a typed metric can
+// be created and registered at once:
+//
+//	orders, err := metrics.Register(reg, prometheus.NewCounterVec(opts, []string{"status"}))
+//
+// If an equal collector is already registered, the registered one is returned
+// so that the callers share the same series.
+func Register[T prometheus.Collector](reg prometheus.Registerer, c T) (T, error) {
+	err := reg.Register(c)
+	if err == nil {
+		return c, nil
+	}
+
+	var are prometheus.AlreadyRegisteredError
+	if errors.As(err, &are) {
+		if existing, ok := are.ExistingCollector.(T); ok {
+			return existing, nil
+		}
+		return c, fmt.Errorf("metrics: collector is registered with the type %T", are.ExistingCollector)
+	}
+	return c, err
+}
+
+// MustRegister is like Register but panics if the collector can not be
+// registered.
+func MustRegister[T prometheus.Collector](reg prometheus.Registerer, c T) T {
+	c, err := Register(reg, c)
+	if err != nil {
+		panic(err)
+	}
+	return c
+}
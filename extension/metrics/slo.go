@@ -0,0 +1,236 @@
+package metrics
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// DefaultSLOWindows are the rolling windows of the burn rates, the pairs
+// 5m/1h and 30m/6h are used by the multi-window burn rate alerts.
+var DefaultSLOWindows = []time.Duration{5 * time.Minute, 30 * time.Minute, time.Hour, 6 * time.Hour}
+
+// Objective is a service level objective of a route, or of all the routes if
+// Route is empty.
+type Objective struct {
+	Name string
+	// Method and Route match the method and the route label of the requests,
+	// the empty values match any.
+	Method string
+	Route  string
+
+	// Availability is the target ratio of the requests without system fault,
+	// for example 0.999. Zero disables the availability objective.
+	Availability float64
+
+	// Latency is the threshold of the fast requests and LatencyTarget is the
+	// target ratio of them, for example 300ms and 0.99. Zero disables the
+	// latency objective.
+	Latency       time.Duration
+	LatencyTarget float64
+}
+
+func (o Objective) matches(method, route string) bool {
+	return (o.Method == "" || o.Method == method) && (o.Route == "" || o.Route == route)
+}
+
+// SLOOptions configures the SLO calculator.
+type SLOOptions struct {
+	Objectives []Objective
+	// Windows default to DefaultSLOWindows.
+	Windows []time.Duration
+	// Resolution is the granularity of the windows, defaults to 10s.
+	Resolution time.Duration
+}
+
+// SLO tracks the objectives over rolling windows and exposes their service
+// level indicators and burn rates as gauges. A burn rate of 1 consumes the
+// error budget exactly at the end of the window.
+type SLO struct {
+	objectives []*objectiveSeries
+	windows    []time.Duration
+	resolution time.Duration
+	now        func() time.Time
+
+	objectiveDesc *prometheus.Desc
+	requestsDesc  *prometheus.Desc
+	sliDesc       *prometheus.Desc
+	burnRateDesc  *prometheus.Desc
+	budgetDesc    *prometheus.Desc
+}
+
+// NewSLO returns the SLO calculator of the objectives, it must be registered
+// to expose the gauges.
+func NewSLO(subsystem string, opts SLOOptions) (*SLO, error) {
+	if opts.Resolution <= 0 {
+		opts.Resolution = 10 * time.Second
+	}
+	if len(opts.Windows) == 0 {
+		opts.Windows = DefaultSLOWindows
+	}
+	windows := slices.Clone(opts.Windows)
+	slices.Sort(windows)
+	if windows[0] < opts.Resolution {
+		return nil, fmt.Errorf("metrics: slo window %s is shorter than the resolution %s", windows[0], opts.Resolution)
+	}
+
+	s := &SLO{
+		windows:    windows,
+		resolution: opts.Resolution,
+		now:        time.Now,
+		objectiveDesc: prometheus.NewDesc(
+			prometheus.BuildFQName("", subsystem, "slo_objective"),
+			"The target ratio of the service level objective.",
+			[]string{"objective", "sli"}, nil,
+		),
+		requestsDesc: prometheus.NewDesc(
+			prometheus.BuildFQName("", subsystem, "slo_requests"),
+			"How many requests of the objective were observed in the window.",
+			[]string{"objective", "window"}, nil,
+		),
+		sliDesc: prometheus.NewDesc(
+			prometheus.BuildFQName("", subsystem, "slo_sli"),
+			"The ratio of the good requests of the objective in the window.",
+			[]string{"objective", "sli", "window"}, nil,
+		),
+		burnRateDesc: prometheus.NewDesc(
+			prometheus.BuildFQName("", subsystem, "slo_burn_rate"),
+			"How fast the error budget of the objective is consumed in the window.",
+			[]string{"objective", "sli", "window"}, nil,
+		),
+		budgetDesc: prometheus.NewDesc(
+			prometheus.BuildFQName("", subsystem, "slo_error_budget_remaining"),
+			"The ratio of the error budget of the objective left in the longest window.",
+			[]string{"objective", "sli"}, nil,
+		),
+	}
+
+	slots := int(windows[len(windows)-1] / opts.Resolution)
+	for _, o := range opts.Objectives {
+		if o.Name == "" {
+			return nil, errors.New("metrics: slo objective requires a name")
+		}
+		if o.Availability == 0 && o.Latency == 0 {
+			return nil, fmt.Errorf("metrics: slo objective %s has neither availability nor latency", o.Name)
+		}
+		if o.Availability < 0 || o.Availability >= 1 || o.Latency > 0 && (o.LatencyTarget <= 0 || o.LatencyTarget >= 1) {
+			return nil, fmt.Errorf("metrics: slo objective %s requires targets between 0 and 1", o.Name)
+		}
+		s.objectives = append(s.objectives, &objectiveSeries{Objective: o, buckets: make([]sloBucket, slots)})
+	}
+	return s, nil
+}
+
+// Observe records a request of the route with its outcome and duration in
+// the matching objectives.
+func (s *SLO) Observe(method, route, outcome string, elapsed time.Duration) {
+	if s == nil {
+		return
+	}
+
+	slot := s.now().UnixNano() / int64(s.resolution)
+	for _, o := range s.objectives {
+		if o.matches(method, route) {
+			o.observe(slot, outcome == OutcomeSysFault, o.Latency > 0 && elapsed > o.Latency)
+		}
+	}
+}
+
+func (s *SLO) Describe(ch chan<- *prometheus.Desc) {
+	ch <- s.objectiveDesc
+	ch <- s.requestsDesc
+	ch <- s.sliDesc
+	ch <- s.burnRateDesc
+	ch <- s.budgetDesc
+}
+
+func (s *SLO) Collect(ch chan<- prometheus.Metric) {
+	slot := s.now().UnixNano() / int64(s.resolution)
+	for _, o := range s.objectives {
+		type sli struct {
+			name   string
+			target float64
+			bad    func(sloBucket) uint64
+		}
+		var slis []sli
+		if o.Availability > 0 {
+			slis = append(slis, sli{"availability", o.Availability, func(b sloBucket) uint64 { return b.errors }})
+		}
+		if o.Latency > 0 {
+			slis = append(slis, sli{"latency", o.LatencyTarget, func(b sloBucket) uint64 { return b.slow }})
+		}
+		for _, i := range slis {
+			ch <- prometheus.MustNewConstMetric(s.objectiveDesc, prometheus.GaugeValue, i.target, o.Name, i.name)
+		}
+
+		for n, w := range s.windows {
+			sum := o.sum(slot, int64(w/s.resolution))
+			window := w.String()
+			ch <- prometheus.MustNewConstMetric(s.requestsDesc, prometheus.GaugeValue, float64(sum.total), o.Name, window)
+
+			for _, i := range slis {
+				var badRatio float64
+				if sum.total > 0 {
+					badRatio = float64(i.bad(sum)) / float64(sum.total)
+					ch <- prometheus.MustNewConstMetric(s.sliDesc, prometheus.GaugeValue, 1-badRatio, o.Name, i.name, window)
+				}
+				burnRate := badRatio / (1 - i.target)
+				ch <- prometheus.MustNewConstMetric(s.burnRateDesc, prometheus.GaugeValue, burnRate, o.Name, i.name, window)
+				if n == len(s.windows)-1 {
+					ch <- prometheus.MustNewConstMetric(s.budgetDesc, prometheus.GaugeValue, 1-burnRate, o.Name, i.name)
+				}
+			}
+		}
+	}
+}
+
+// sloBucket counts the requests of a slot of the resolution.
+type sloBucket struct {
+	slot                int64
+	total, errors, slow uint64
+}
+
+// objectiveSeries is a ring of the buckets covering the longest window.
+type objectiveSeries struct {
+	Objective
+
+	mu      sync.Mutex
+	buckets []sloBucket
+}
+
+func (o *objectiveSeries) observe(slot int64, failed, slow bool) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	b := &o.buckets[slot%int64(len(o.buckets))]
+	if b.slot != slot {
+		*b = sloBucket{slot: slot}
+	}
+	b.total++
+	if failed {
+		b.errors++
+	}
+	if slow {
+		b.slow++
+	}
+}
+
+// sum adds the buckets of the last slots up to the current one.
+func (o *objectiveSeries) sum(current, slots int64) sloBucket {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	var rv sloBucket
+	for _, b := range o.buckets {
+		if b.slot > current-slots && b.slot <= current {
+			rv.total += b.total
+			rv.errors += b.errors
+			rv.slow += b.slow
+		}
+	}
+	return rv
+}
@@ -0,0 +1,220 @@
+package oauth2
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"demo/extension/errorx"
+	"demo/extension/oauth2/oauth2test"
+)
+
+func newTestProvider(t *testing.T) (*oauth2test.Server, *Provider) {
+	server := oauth2test.NewServer("client", "secret")
+	t.Cleanup(server.Close)
+
+	provider, err := NewProvider(Config{
+		Name:         "mock",
+		ClientID:     "client",
+		ClientSecret: "secret",
+		RedirectURL:  "http://localhost/auth/oauth2/mock/callback",
+		Scopes:       []string{"email", "profile"},
+		Issuer:       server.Issuer(),
+	})
+	assert.NoError(t, err)
+	return server, provider
+}
+
+// authorize follows the authorization URL like a user agent and returns the
+// query of the redirect to the callback.
+func authorize(t *testing.T, authURL string) url.Values {
+	client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}}
+	resp, err := client.Get(authURL)
+	assert.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusFound, resp.StatusCode)
+
+	location, err := url.Parse(resp.Header.Get("Location"))
+	assert.NoError(t, err)
+	return location.Query()
+}
+
+func assertCode(t *testing.T, expected errorx.Error, err error) {
+	t.Helper()
+	var ex errorx.Error
+	if assert.True(t, errors.As(err, &ex), "%v", err) {
+		assert.Equal(t, expected.Code, ex.Code, "%v", err)
+	}
+}
+
+func TestLoginFlow(t *testing.T) {
+	_, provider := newTestProvider(t)
+	codec := NewStateCodec([]byte("state-key"), time.Minute)
+	ctx := context.Background()
+
+	st := codec.New(provider.Name())
+	cookie, err := codec.Encode(st)
+	assert.NoError(t, err)
+
+	authURL, err := provider.AuthCodeURL(ctx, st)
+	assert.NoError(t, err)
+	q, _ := url.Parse(authURL)
+	assert.Equal(t, "openid email profile", q.Query().Get("scope"))
+	assert.Equal(t, S256Challenge(st.Verifier), q.Query().Get("code_challenge"))
+
+	callback := authorize(t, authURL)
+	got, err := codec.Decode(cookie, "mock", callback.Get("state"))
+	assert.NoError(t, err)
+
+	identity, err := provider.Authenticate(ctx, callback.Get("code"), got)
+	assert.NoError(t, err)
+	assert.Equal(t, "mock", identity.Provider)
+	assert.Equal(t, "user-1", identity.Subject)
+	assert.Equal(t, "user-1@example.com", identity.Email)
+	assert.Equal(t, "Test User", identity.Name)
+
+	// the code can only be used once
+	_, err = provider.Authenticate(ctx, callback.Get("code"), got)
+	assertCode(t, errorx.ErrOAuth2AuthorizationFailed, err)
+}
+
+func TestLoginFlowRejectsWrongVerifierAndNonce(t *testing.T) {
+	_, provider := newTestProvider(t)
+	codec := NewStateCodec([]byte("state-key"), time.Minute)
+	ctx := context.Background()
+
+	st := codec.New(provider.Name())
+	authURL, err := provider.AuthCodeURL(ctx, st)
+	assert.NoError(t, err)
+	callback := authorize(t, authURL)
+
+	wrongVerifier := *st
+	wrongVerifier.Verifier = NewVerifier()
+	_, err = provider.Authenticate(ctx, callback.Get("code"), &wrongVerifier)
+	assertCode(t, errorx.ErrOAuth2AuthorizationFailed, err)
+
+	st = codec.New(provider.Name())
+	authURL, err = provider.AuthCodeURL(ctx, st)
+	assert.NoError(t, err)
+	callback = authorize(t, authURL)
+
+	wrongNonce := *st
+	wrongNonce.Nonce = "other"
+	_, err = provider.Authenticate(ctx, callback.Get("code"), &wrongNonce)
+	assertCode(t, errorx.ErrInvalidAuthenticationState, err)
+}
+
+func TestStateCodec(t *testing.T) {
+	codec := NewStateCodec([]byte("state-key"), time.Minute)
+	st := codec.New("mock")
+	cookie, err := codec.Encode(st)
+	assert.NoError(t, err)
+
+	_, err = codec.Decode(cookie, "mock", "other")
+	assertCode(t, errorx.ErrInvalidAuthenticationState, err)
+	_, err = codec.Decode(cookie, "github", st.State)
+	assertCode(t, errorx.ErrInvalidAuthenticationState, err)
+	_, err = NewStateCodec([]byte("other-key"), time.Minute).Decode(cookie, "mock", st.State)
+	assertCode(t, errorx.ErrInvalidAuthenticationState, err)
+	_, err = codec.Decode("garbage", "mock", st.State)
+	assertCode(t, errorx.ErrInvalidAuthenticationState, err)
+
+	codec.now = func() time.Time { return time.Now().Add(2 * time.Minute) }
+	_, err = codec.Decode(cookie, "mock", st.State)
+	assertCode(t, errorx.ErrInvalidAuthenticationState, err)
+}
+
+func TestNewProviderValidation(t *testing.T) {
+	_, err := NewProvider(Config{Name: "mock", ClientID: "client"})
+	assert.Error(t, err)
+	_, err = NewProvider(Config{Name: "mock", ClientID: "client", RedirectURL: "http://localhost/callback"})
+	assert.Error(t, err)
+
+	// a plain OAuth2 provider without discovery
+	provider, err := NewProvider(Config{
+		Name:        "plain",
+		ClientID:    "client",
+		RedirectURL: "http://localhost/callback",
+		Endpoint:    Endpoint{AuthURL: "https://example.com/authorize?prompt=none", TokenURL: "https://example.com/token"},
+	})
+	assert.NoError(t, err)
+	authURL, err := provider.AuthCodeURL(context.Background(), NewStateCodec(nil, 0).New("plain"))
+	assert.NoError(t, err)
+	q, _ := url.Parse(authURL)
+	assert.Equal(t, "none", q.Query().Get("prompt"))
+	assert.Empty(t, q.Query().Get("nonce"))
+}
+
+func TestLoginFlowWithResolvedRedirectURI(t *testing.T) {
+	server := oauth2test.NewServer("client", "secret")
+	t.Cleanup(server.Close)
+	provider, err := NewProvider(Config{
+		Name:         "mock",
+		ClientID:     "client",
+		ClientSecret: "secret",
+		RedirectURL:  "/auth/oauth2/mock/callback",
+		Issuer:       server.Issuer(),
+	})
+	assert.NoError(t, err)
+	codec := NewStateCodec([]byte("state-key"), time.Minute)
+	ctx := context.Background()
+
+	st := codec.New(provider.Name())
+	st.RedirectURI = "https://app.example.com" + provider.RedirectURL()
+	cookie, err := codec.Encode(st)
+	assert.NoError(t, err)
+
+	authURL, err := provider.AuthCodeURL(ctx, st)
+	assert.NoError(t, err)
+	q, _ := url.Parse(authURL)
+	assert.Equal(t, "https://app.example.com/auth/oauth2/mock/callback", q.Query().Get("redirect_uri"))
+
+	callback := authorize(t, authURL)
+	got, err := codec.Decode(cookie, "mock", callback.Get("state"))
+	assert.NoError(t, err)
+	assert.Equal(t, st.RedirectURI, got.RedirectURI)
+
+	// the token request sends the redirect uri of the authorization request
+	identity, err := provider.Authenticate(ctx, callback.Get("code"), got)
+	assert.NoError(t, err)
+	assert.Equal(t, "user-1", identity.Subject)
+}
+
+func TestDiscoveryDoesNotBlockOtherLogins(t *testing.T) {
+	release := make(chan struct{})
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		<-release
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"issuer":%q,"authorization_endpoint":%q,"token_endpoint":%q}`,
+			server.URL, server.URL+"/authorize", server.URL+"/token")
+	}))
+	t.Cleanup(server.Close)
+	t.Cleanup(func() { close(release) })
+
+	provider, err := NewProvider(Config{Name: "slow", ClientID: "client", RedirectURL: "/callback", Issuer: server.URL})
+	assert.NoError(t, err)
+	st := NewStateCodec(nil, 0).New("slow")
+
+	// a login waits for the slow issuer
+	go provider.AuthCodeURL(context.Background(), st)
+	time.Sleep(20 * time.Millisecond)
+
+	// another login gives up after its own deadline instead of waiting for
+	// the first one to release the provider
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	_, err = provider.AuthCodeURL(ctx, st)
+	assert.Error(t, err)
+	assert.Less(t, time.Since(start), time.Second)
+}
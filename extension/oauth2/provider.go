@@ -0,0 +1,384 @@
+package oauth2
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"demo/extension/errorx"
+	"demo/extension/jwt"
+)
+
+// Endpoint is the endpoints of an authorization server. They are discovered
+// from the issuer of an OpenID Connect provider when AuthURL is empty.
+type Endpoint struct {
+	AuthURL     string `json:"authorization_endpoint"`
+	TokenURL    string `json:"token_endpoint"`
+	UserInfoURL string `json:"userinfo_endpoint"`
+	JWKSURL     string `json:"jwks_uri"`
+}
+
+// Config is the configuration of a provider.
+type Config struct {
+	Name         string
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+	Scopes       []string
+
+	// Issuer enables OpenID Connect, the ID tokens are verified against it.
+	Issuer string
+
+	Endpoint Endpoint
+
+	// HTTPClient is used for the requests to the provider.
+	HTTPClient *http.Client
+}
+
+// Token is the response of the token endpoint.
+type Token struct {
+	AccessToken  string `json:"access_token"`
+	TokenType    string `json:"token_type"`
+	RefreshToken string `json:"refresh_token,omitempty"`
+	IDToken      string `json:"id_token,omitempty"`
+	ExpiresIn    int64  `json:"expires_in,omitempty"`
+	Scope        string `json:"scope,omitempty"`
+}
+
+// Identity is the user authenticated by a provider.
+type Identity struct {
+	Provider string         `json:"provider"`
+	Subject  string         `json:"subject"`
+	Email    string         `json:"email,omitempty"`
+	Name     string         `json:"name,omitempty"`
+	Claims   map[string]any `json:"claims,omitempty"`
+}
+
+// Provider runs the authorization code flow with PKCE against an OAuth2 or
+// OpenID Connect provider.
+type Provider struct {
+	cfg    Config
+	client *http.Client
+
+	mu       sync.Mutex
+	endpoint *Endpoint
+	keys     jwt.KeySet
+}
+
+func NewProvider(cfg Config) (*Provider, error) {
+	if cfg.Name == "" || cfg.ClientID == "" || cfg.RedirectURL == "" {
+		return nil, fmt.Errorf("oauth2: name, client id and redirect url are required")
+	}
+	if cfg.Issuer == "" && (cfg.Endpoint.AuthURL == "" || cfg.Endpoint.TokenURL == "") {
+		return nil, fmt.Errorf("oauth2: provider %s requires an issuer or the auth and token urls", cfg.Name)
+	}
+
+	p := &Provider{cfg: cfg, client: cfg.HTTPClient}
+	if p.client == nil {
+		p.client = &http.Client{Timeout: 10 * time.Second}
+	}
+	if cfg.Endpoint.AuthURL != "" {
+		p.setEndpoint(cfg.Endpoint)
+	}
+	return p, nil
+}
+
+func (p *Provider) Name() string {
+	return p.cfg.Name
+}
+
+// RedirectURL returns the configured redirect url, a path such as
+// /api/v1/auth/oauth2/google/callback is resolved against the origin of the
+// login request by the caller and kept in AuthState.RedirectURI.
+func (p *Provider) RedirectURL() string {
+	return p.cfg.RedirectURL
+}
+
+func (p *Provider) redirectURI(st *AuthState) string {
+	if st.RedirectURI != "" {
+		return st.RedirectURI
+	}
+	return p.cfg.RedirectURL
+}
+
+// OIDC reports whether the provider issues ID tokens.
+func (p *Provider) OIDC() bool {
+	return p.cfg.Issuer != ""
+}
+
+// AuthCodeURL returns the URL of the authorization endpoint to redirect the
+// user to, the state, nonce and PKCE challenge are bound to the request.
+func (p *Provider) AuthCodeURL(ctx context.Context, st *AuthState) (string, error) {
+	endpoint, err := p.discover(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	scopes := p.cfg.Scopes
+	if p.OIDC() && !slices.Contains(scopes, "openid") {
+		scopes = append([]string{"openid"}, scopes...)
+	}
+
+	q := url.Values{
+		"response_type":         {"code"},
+		"client_id":             {p.cfg.ClientID},
+		"redirect_uri":          {p.redirectURI(st)},
+		"state":                 {st.State},
+		"code_challenge":        {S256Challenge(st.Verifier)},
+		"code_challenge_method": {"S256"},
+	}
+	if len(scopes) > 0 {
+		q.Set("scope", strings.Join(scopes, " "))
+	}
+	if p.OIDC() {
+		q.Set("nonce", st.Nonce)
+	}
+
+	sep := "?"
+	if strings.Contains(endpoint.AuthURL, "?") {
+		sep = "&"
+	}
+	return endpoint.AuthURL + sep + q.Encode(), nil
+}
+
+// Exchange exchanges the authorization code for the tokens.
+func (p *Provider) Exchange(ctx context.Context, code, verifier string) (*Token, error) {
+	return p.exchange(ctx, code, verifier, p.cfg.RedirectURL)
+}
+
+func (p *Provider) exchange(ctx context.Context, code, verifier, redirectURI string) (*Token, error) {
+	endpoint, err := p.discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {redirectURI},
+		"client_id":     {p.cfg.ClientID},
+		"code_verifier": {verifier},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint.TokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, errorx.ErrOAuth2AuthorizationFailed.Wrap(err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if p.cfg.ClientSecret != "" {
+		req.SetBasicAuth(url.QueryEscape(p.cfg.ClientID), url.QueryEscape(p.cfg.ClientSecret))
+	}
+
+	var token Token
+	if err := p.do(req, &token); err != nil {
+		return nil, errorx.ErrOAuth2AuthorizationFailed.Wrap(err)
+	}
+	if token.AccessToken == "" {
+		return nil, errorx.ErrOAuth2AuthorizationFailed.WithMessage("the token response has no access token")
+	}
+	return &token, nil
+}
+
+// VerifyIDToken verifies the ID token with the keys of the provider and checks
+// that it is issued to this client for the nonce of the login.
+func (p *Provider) VerifyIDToken(ctx context.Context, raw, nonce string) (jwt.Claims, error) {
+	if _, err := p.discover(ctx); err != nil {
+		return nil, err
+	}
+	p.mu.Lock()
+	keys := p.keys
+	p.mu.Unlock()
+	if keys == nil {
+		return nil, errorx.ErrOAuth2AuthorizationFailed.WithMessage("the provider has no jwks url")
+	}
+
+	token, err := jwt.Verify(ctx, raw, keys, jwt.VerifyOptions{
+		Algorithms:     []string{jwt.RS256, jwt.ES256},
+		Issuer:         p.cfg.Issuer,
+		Audience:       p.cfg.ClientID,
+		RequireExpires: true,
+		Leeway:         time.Minute,
+	})
+	if err != nil {
+		return nil, errorx.ErrOAuth2AuthorizationFailed.WithMessage("invalid id token").Wrap(err)
+	}
+	if token.Claims.String("nonce") != nonce {
+		return nil, errorx.ErrInvalidAuthenticationState.WithMessage("nonce mismatch")
+	}
+	return token.Claims, nil
+}
+
+// UserInfo requests the claims of the user from the userinfo endpoint.
+func (p *Provider) UserInfo(ctx context.Context, accessToken string) (map[string]any, error) {
+	endpoint, err := p.discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if endpoint.UserInfoURL == "" {
+		return nil, errorx.ErrOAuth2UserInfoFailed.WithMessage("the provider has no userinfo url")
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint.UserInfoURL, nil)
+	if err != nil {
+		return nil, errorx.ErrOAuth2UserInfoFailed.Wrap(err)
+	}
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+	req.Header.Set("Accept", "application/json")
+
+	var claims map[string]any
+	if err := p.do(req, &claims); err != nil {
+		return nil, errorx.ErrOAuth2UserInfoFailed.Wrap(err)
+	}
+	return claims, nil
+}
+
+// Authenticate completes a login: it exchanges the code, verifies the ID
+// token of an OpenID Connect provider and merges the userinfo claims.
+func (p *Provider) Authenticate(ctx context.Context, code string, st *AuthState) (*Identity, error) {
+	token, err := p.exchange(ctx, code, st.Verifier, p.redirectURI(st))
+	if err != nil {
+		return nil, err
+	}
+
+	claims := make(map[string]any)
+	if p.OIDC() {
+		if token.IDToken == "" {
+			return nil, errorx.ErrOAuth2AuthorizationFailed.WithMessage("the token response has no id token")
+		}
+		idClaims, err := p.VerifyIDToken(ctx, token.IDToken, st.Nonce)
+		if err != nil {
+			return nil, err
+		}
+		for k, v := range idClaims {
+			claims[k] = v
+		}
+	}
+
+	if endpoint, _ := p.discover(ctx); endpoint.UserInfoURL != "" {
+		info, err := p.UserInfo(ctx, token.AccessToken)
+		if err != nil {
+			return nil, err
+		}
+		// the subject of the userinfo must be the one of the ID token
+		if sub, ok := claims["sub"]; ok && info["sub"] != sub {
+			return nil, errorx.ErrOAuth2UserInfoFailed.WithMessage("userinfo subject mismatch")
+		}
+		for k, v := range info {
+			claims[k] = v
+		}
+	}
+
+	identity := &Identity{Provider: p.cfg.Name, Claims: claims}
+	identity.Subject = claimString(claims, "sub", "id")
+	identity.Email = claimString(claims, "email")
+	identity.Name = claimString(claims, "name", "preferred_username", "login")
+	if identity.Subject == "" {
+		return nil, errorx.ErrOAuth2UserInfoFailed.WithMessage("the user has no subject")
+	}
+	return identity, nil
+}
+
+// discover returns the endpoints, fetching the OpenID Connect discovery
+// document of the issuer on the first call. A failed discovery is retried by
+// the next call. The document is fetched without holding the lock, so that
+// the logins are not serialized behind a slow issuer, the first document
+// fetched is kept if several calls race.
+func (p *Provider) discover(ctx context.Context) (Endpoint, error) {
+	p.mu.Lock()
+	endpoint := p.endpoint
+	p.mu.Unlock()
+	if endpoint != nil {
+		return *endpoint, nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimSuffix(p.cfg.Issuer, "/")+"/.well-known/openid-configuration", nil)
+	if err != nil {
+		return Endpoint{}, errorx.ErrOAuth2AuthorizationFailed.Wrap(err)
+	}
+
+	var doc struct {
+		Endpoint
+		Issuer string `json:"issuer"`
+	}
+	if err := p.do(req, &doc); err != nil {
+		return Endpoint{}, errorx.ErrOAuth2AuthorizationFailed.WithMessage("discovery failed").Wrap(err)
+	}
+	if doc.Issuer != p.cfg.Issuer {
+		return Endpoint{}, errorx.ErrOAuth2AuthorizationFailed.WithMessage("discovery issuer mismatch")
+	}
+
+	// explicitly configured endpoints take precedence
+	discovered := doc.Endpoint
+	if p.cfg.Endpoint.TokenURL != "" {
+		discovered.TokenURL = p.cfg.Endpoint.TokenURL
+	}
+	if p.cfg.Endpoint.UserInfoURL != "" {
+		discovered.UserInfoURL = p.cfg.Endpoint.UserInfoURL
+	}
+	if p.cfg.Endpoint.JWKSURL != "" {
+		discovered.JWKSURL = p.cfg.Endpoint.JWKSURL
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.endpoint == nil {
+		p.setEndpoint(discovered)
+	}
+	return *p.endpoint, nil
+}
+
+// setEndpoint must be called with p.mu held, except by NewProvider.
+func (p *Provider) setEndpoint(endpoint Endpoint) {
+	p.endpoint = &endpoint
+	if endpoint.JWKSURL != "" {
+		p.keys = jwt.NewRemoteKeySet(endpoint.JWKSURL, jwt.RemoteOptions{Client: p.client})
+	}
+}
+
+// do sends the request and decodes the json response, the OAuth2 error
+// response is returned as an error.
+func (p *Provider) do(req *http.Request, v any) error {
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		var oauthErr struct {
+			Error       string `json:"error"`
+			Description string `json:"error_description"`
+		}
+		if json.Unmarshal(body, &oauthErr) == nil && oauthErr.Error != "" {
+			return fmt.Errorf("%s: %s %s", req.URL.Path, oauthErr.Error, oauthErr.Description)
+		}
+		return fmt.Errorf("%s: unexpected status %s", req.URL.Path, resp.Status)
+	}
+	return json.Unmarshal(body, v)
+}
+
+func claimString(claims map[string]any, names ...string) string {
+	for _, name := range names {
+		switch v := claims[name].(type) {
+		case string:
+			if v != "" {
+				return v
+			}
+		case float64:
+			// numeric ids such as the GitHub user id
+			return fmt.Sprintf("%.0f", v)
+		}
+	}
+	return ""
+}
@@ -0,0 +1,121 @@
+package oauth2
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+	"time"
+
+	"demo/extension/errorx"
+)
+
+// AuthState is the state of a login between the redirect to the provider and
+// the callback, it is kept by the user agent in a signed cookie.
+type AuthState struct {
+	Provider  string `json:"p"`
+	State     string `json:"s"`
+	Nonce     string `json:"n"`
+	Verifier  string `json:"v"`
+	ExpiresAt int64  `json:"exp"`
+
+	// RedirectURI is the redirect uri of the login if it is resolved from the
+	// request, the token request must send the same one.
+	RedirectURI string `json:"r,omitempty"`
+}
+
+// NewVerifier returns a random PKCE code verifier of 43 characters.
+func NewVerifier() string {
+	return randomString(32)
+}
+
+// S256Challenge returns the S256 PKCE code challenge of the verifier.
+func S256Challenge(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+func randomString(n int) string {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// StateCodec creates the AuthState of the logins and signs them with
+// HMAC-SHA256, so that the callback can trust the state it receives back.
+type StateCodec struct {
+	key []byte
+	ttl time.Duration
+	now func() time.Time
+}
+
+// NewStateCodec creates a StateCodec, ttl bounds the duration of a login and
+// defaults to 10 minutes.
+func NewStateCodec(key []byte, ttl time.Duration) *StateCodec {
+	if ttl <= 0 {
+		ttl = 10 * time.Minute
+	}
+	return &StateCodec{key: key, ttl: ttl, now: time.Now}
+}
+
+// New creates the state of a login with the provider.
+func (c *StateCodec) New(provider string) *AuthState {
+	return &AuthState{
+		Provider:  provider,
+		State:     randomString(16),
+		Nonce:     randomString(16),
+		Verifier:  NewVerifier(),
+		ExpiresAt: c.now().Add(c.ttl).Unix(),
+	}
+}
+
+// Encode signs the state into a cookie value.
+func (c *StateCodec) Encode(st *AuthState) (string, error) {
+	payload, err := json.Marshal(st)
+	if err != nil {
+		return "", err
+	}
+	enc := base64.RawURLEncoding
+	return enc.EncodeToString(payload) + "." + enc.EncodeToString(c.sign(payload)), nil
+}
+
+// Decode verifies the cookie value and that it is the state of the provider
+// returned by the callback, any failure is ErrInvalidAuthenticationState.
+func (c *StateCodec) Decode(value, provider, state string) (*AuthState, error) {
+	encoded, mac, ok := strings.Cut(value, ".")
+	if !ok {
+		return nil, errorx.ErrInvalidAuthenticationState.WithMessage("malformed state")
+	}
+	enc := base64.RawURLEncoding
+	payload, err := enc.DecodeString(encoded)
+	if err != nil {
+		return nil, errorx.ErrInvalidAuthenticationState.WithMessage("malformed state")
+	}
+	signature, err := enc.DecodeString(mac)
+	if err != nil || !hmac.Equal(signature, c.sign(payload)) {
+		return nil, errorx.ErrInvalidAuthenticationState.WithMessage("state signature mismatch")
+	}
+
+	var st AuthState
+	if err := json.Unmarshal(payload, &st); err != nil {
+		return nil, errorx.ErrInvalidAuthenticationState.WithMessage("malformed state")
+	}
+	if c.now().Unix() >= st.ExpiresAt {
+		return nil, errorx.ErrInvalidAuthenticationState.WithMessage("state is expired")
+	}
+	if st.Provider != provider || subtle.ConstantTimeCompare([]byte(st.State), []byte(state)) != 1 {
+		return nil, errorx.ErrInvalidAuthenticationState.WithMessage("state mismatch")
+	}
+	return &st, nil
+}
+
+func (c *StateCodec) sign(payload []byte) []byte {
+	mac := hmac.New(sha256.New, c.key)
+	mac.Write(payload)
+	return mac.Sum(nil)
+}
@@ -0,0 +1,207 @@
+package pagination
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"strings"
+	"time"
+
+	"demo/extension/errorx"
+)
+
+var (
+	ErrCursorMalformed = errors.New("cursor is malformed")
+	ErrCursorSignature = errors.New("cursor signature mismatch")
+	ErrCursorExpired   = errors.New("cursor is expired")
+	ErrCursorQuery     = errors.New("cursor does not belong to this query")
+)
+
+// CursorOptions is the options of the CursorCodec.
+type CursorOptions struct {
+	// SignKey is the HMAC-SHA256 key used to sign the cursor, required.
+	SignKey []byte
+
+	// EncryptKey enables AES-256-GCM encryption of the cursor payload when
+	// it is not empty, the key material is derived with sha256.
+	EncryptKey []byte
+
+	// TTL is the lifetime of a cursor, zero means the cursor never expires.
+	TTL time.Duration
+}
+
+// CursorCodec encodes and decodes tamper-proof cursors.
+//
+// The wire format is base64url(payload) "." base64url(hmac(payload)), the
+// payload is the json envelope or, if encryption is enabled, nonce||ciphertext
+// of the json envelope.
+type CursorCodec struct {
+	signKey []byte
+	aead    cipher.AEAD
+	ttl     time.Duration
+	now     func() time.Time
+}
+
+type cursorEnvelope struct {
+	Value       json.RawMessage `json:"v"`
+	ExpiresAt   int64           `json:"exp,omitempty"`
+	Fingerprint string          `json:"fp,omitempty"`
+}
+
+// NewCursorCodec creates a CursorCodec with the given options.
+func NewCursorCodec(opts CursorOptions) (*CursorCodec, error) {
+	if len(opts.SignKey) == 0 {
+		return nil, errors.New("pagination: cursor sign key is required")
+	}
+
+	codec := &CursorCodec{
+		signKey: opts.SignKey,
+		ttl:     opts.TTL,
+		now:     time.Now,
+	}
+
+	if len(opts.EncryptKey) > 0 {
+		key := sha256.Sum256(opts.EncryptKey)
+		block, err := aes.NewCipher(key[:])
+		if err != nil {
+			return nil, err
+		}
+		codec.aead, err = cipher.NewGCM(block)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return codec, nil
+}
+
+// Encode signs the value into a cursor, the query is fingerprinted so that the
+// cursor can only be used with the same filter set, nil means no binding.
+func (c *CursorCodec) Encode(value any, query any) (Cursor, error) {
+	raw, err := json.Marshal(value)
+	if err != nil {
+		return "", err
+	}
+
+	envelope := cursorEnvelope{Value: raw}
+	if c.ttl > 0 {
+		envelope.ExpiresAt = c.now().Add(c.ttl).Unix()
+	}
+	if query != nil {
+		if envelope.Fingerprint, err = Fingerprint(query); err != nil {
+			return "", err
+		}
+	}
+
+	payload, err := json.Marshal(envelope)
+	if err != nil {
+		return "", err
+	}
+
+	if c.aead != nil {
+		nonce := make([]byte, c.aead.NonceSize())
+		if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+			return "", err
+		}
+		payload = c.aead.Seal(nonce, nonce, payload, nil)
+	}
+
+	return Cursor(base64.RawURLEncoding.EncodeToString(payload) + "." +
+		base64.RawURLEncoding.EncodeToString(c.sign(payload))), nil
+}
+
+// Decode verifies the cursor and decodes it into value. An empty cursor is
+// accepted and leaves value untouched. Any failure is reported as
+// errorx.ErrIllegalArgument.
+func (c *CursorCodec) Decode(cursor Cursor, value any, query any) error {
+	if cursor.IsEmpty() {
+		return nil
+	}
+
+	if err := c.decode(cursor, value, query); err != nil {
+		return errorx.ErrIllegalArgument.WithReason("specify cursor is invalid").Wrap(err)
+	}
+	return nil
+}
+
+func (c *CursorCodec) decode(cursor Cursor, value any, query any) error {
+	encodedPayload, encodedMAC, ok := strings.Cut(string(cursor), ".")
+	if !ok {
+		return ErrCursorMalformed
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(encodedPayload)
+	if err != nil {
+		return ErrCursorMalformed
+	}
+	mac, err := base64.RawURLEncoding.DecodeString(encodedMAC)
+	if err != nil {
+		return ErrCursorMalformed
+	}
+	if !hmac.Equal(mac, c.sign(payload)) {
+		return ErrCursorSignature
+	}
+
+	if c.aead != nil {
+		size := c.aead.NonceSize()
+		if len(payload) < size {
+			return ErrCursorMalformed
+		}
+		payload, err = c.aead.Open(nil, payload[:size], payload[size:], nil)
+		if err != nil {
+			return ErrCursorMalformed
+		}
+	}
+
+	var envelope cursorEnvelope
+	decoder := json.NewDecoder(bytes.NewReader(payload))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&envelope); err != nil {
+		return ErrCursorMalformed
+	}
+
+	if envelope.ExpiresAt > 0 && c.now().Unix() > envelope.ExpiresAt {
+		return ErrCursorExpired
+	}
+
+	if query != nil || envelope.Fingerprint != "" {
+		fingerprint, err := Fingerprint(query)
+		if err != nil {
+			return err
+		}
+		if !hmac.Equal([]byte(fingerprint), []byte(envelope.Fingerprint)) {
+			return ErrCursorQuery
+		}
+	}
+
+	return json.Unmarshal(envelope.Value, value)
+}
+
+func (c *CursorCodec) sign(payload []byte) []byte {
+	h := hmac.New(sha256.New, c.signKey)
+	h.Write(payload)
+	return h.Sum(nil)
+}
+
+// Fingerprint returns a stable digest of the query, the query is serialized
+// with encoding/json so struct field order and map key order are stable.
+func Fingerprint(query any) (string, error) {
+	if query == nil {
+		return "", nil
+	}
+
+	raw, err := json.Marshal(query)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(raw)
+	return hex.EncodeToString(sum[:16]), nil
+}
@@ -0,0 +1,80 @@
+package pagination
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"demo/extension/errorx"
+)
+
+type cursorValue struct {
+	ID int64 `json:"id"`
+}
+
+type cursorQuery struct {
+	Status string `json:"status"`
+}
+
+func TestCursorCodec(t *testing.T) {
+	testcases := []struct {
+		name string
+		opts CursorOptions
+	}{
+		{name: "signed", opts: CursorOptions{SignKey: []byte("secret")}},
+		{name: "signed and encrypted", opts: CursorOptions{SignKey: []byte("secret"), EncryptKey: []byte("key")}},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			codec, err := NewCursorCodec(tc.opts)
+			assert.NoError(t, err)
+
+			cursor, err := codec.Encode(cursorValue{ID: 42}, cursorQuery{Status: "active"})
+			assert.NoError(t, err)
+
+			var value cursorValue
+			assert.NoError(t, codec.Decode(cursor, &value, cursorQuery{Status: "active"}))
+			assert.Equal(t, int64(42), value.ID)
+
+			err = codec.Decode(cursor, &value, cursorQuery{Status: "deleted"})
+			assert.ErrorIs(t, err, ErrCursorQuery)
+			assert.True(t, errorx.ErrIllegalArgument.Is(err))
+
+			err = codec.Decode(cursor+"x", &value, cursorQuery{Status: "active"})
+			assert.True(t, errorx.ErrIllegalArgument.Is(err))
+		})
+	}
+}
+
+func TestCursorCodecRejectsCraftedCursor(t *testing.T) {
+	codec, err := NewCursorCodec(CursorOptions{SignKey: []byte("secret")})
+	assert.NoError(t, err)
+
+	crafted := NewCursorX(cursorValue{ID: 1})
+	err = codec.Decode(crafted, &cursorValue{}, nil)
+	assert.ErrorIs(t, err, ErrCursorMalformed)
+
+	other, err := NewCursorCodec(CursorOptions{SignKey: []byte("other")})
+	assert.NoError(t, err)
+	cursor, err := other.Encode(cursorValue{ID: 1}, nil)
+	assert.NoError(t, err)
+	err = codec.Decode(cursor, &cursorValue{}, nil)
+	assert.ErrorIs(t, err, ErrCursorSignature)
+}
+
+func TestCursorCodecExpiry(t *testing.T) {
+	codec, err := NewCursorCodec(CursorOptions{SignKey: []byte("secret"), TTL: time.Minute})
+	assert.NoError(t, err)
+
+	now := time.Now()
+	codec.now = func() time.Time { return now }
+	cursor, err := codec.Encode(cursorValue{ID: 1}, nil)
+	assert.NoError(t, err)
+	assert.NoError(t, codec.Decode(cursor, &cursorValue{}, nil))
+
+	codec.now = func() time.Time { return now.Add(2 * time.Minute) }
+	err = codec.Decode(cursor, &cursorValue{}, nil)
+	assert.ErrorIs(t, err, ErrCursorExpired)
+}
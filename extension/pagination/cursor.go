@@ -5,11 +5,14 @@ import (
 	"encoding/json"
 	"fmt"
 
-	"scan/extension/errorx"
+	"demo/extension/errorx"
 )
 
 type Cursor string
 
+// NewCursor encodes the value into an unsigned cursor.
+//
+// Deprecated: unsigned cursors can be crafted by clients, use CursorCodec.Encode.
 func NewCursor(value any) (Cursor, error) {
 	result, err := json.Marshal(value)
 	if err != nil {
@@ -19,6 +22,7 @@ func NewCursor(value any) (Cursor, error) {
 	return Cursor(base64.RawStdEncoding.EncodeToString(result)), nil
 }
 
+// Deprecated: use CursorCodec.Encode.
 func NewCursorX(value any) Cursor {
 	result, err := NewCursor(value)
 	if err != nil {
@@ -27,6 +31,9 @@ func NewCursorX(value any) Cursor {
 	return result
 }
 
+// Decode decodes an unsigned cursor into value.
+//
+// Deprecated: unsigned cursors can be crafted by clients, use CursorCodec.Decode.
 func (c Cursor) Decode(value any) error {
 	if c == "" {
 		return nil
@@ -56,21 +63,25 @@ type CursorPagination struct {
 	// Before Cursor `json:"before" form:"before"`
 	After Cursor `json:"after" form:"after"`
 	Limit int    `json:"limit" form:"limit"`
+
+	opts Options // set by BindCursor, defaults are used if empty
 }
 
 func (p CursorPagination) SafeLimit() int {
+	opts := p.opts.normalize()
 	if p.Limit == 0 {
-		return DEFAULT_PAGE_SIZE
+		return opts.DefaultPageSize
 	}
-	if p.Limit > MAX_PAGE_SIZE {
-		return MAX_PAGE_SIZE
+	if p.Limit > opts.MaxPageSize {
+		return opts.MaxPageSize
 	}
 	return p.Limit
 }
 
 func (p CursorPagination) Validate() error {
-	if p.Limit > MAX_PAGE_SIZE {
-		return errorx.ErrIllegalArgument.WithReason(fmt.Sprintf("limit must be less than or equal to %d", MAX_PAGE_SIZE))
+	opts := p.opts.normalize()
+	if p.Limit > opts.MaxPageSize {
+		return errorx.ErrIllegalArgument.WithReason(fmt.Sprintf("limit must be less than or equal to %d", opts.MaxPageSize))
 	}
 	if p.Limit < 0 {
 		return errorx.ErrIllegalArgument.WithReason("limit must be greater than or equal to 0")
@@ -0,0 +1,101 @@
+package pagination
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"demo/extension/errorx"
+)
+
+// Options controls how pagination params are resolved from a request.
+type Options struct {
+	DefaultPageSize int
+	MaxPageSize     int
+}
+
+// DefaultOptions returns the options with DEFAULT_PAGE_SIZE and MAX_PAGE_SIZE.
+func DefaultOptions() Options {
+	return Options{DefaultPageSize: DEFAULT_PAGE_SIZE, MaxPageSize: MAX_PAGE_SIZE}
+}
+
+func (o Options) normalize() Options {
+	if o.MaxPageSize <= 0 {
+		o.MaxPageSize = MAX_PAGE_SIZE
+	}
+	if o.DefaultPageSize <= 0 {
+		o.DefaultPageSize = DEFAULT_PAGE_SIZE
+	}
+	if o.DefaultPageSize > o.MaxPageSize {
+		o.DefaultPageSize = o.MaxPageSize
+	}
+	return o
+}
+
+// BindLimitOffset binds the "page" and "page_size" query params, an absent page
+// size falls back to the default, a page size over the maximum is rejected.
+func BindLimitOffset(c *gin.Context, opts Options) (LimitOffsetPagination, error) {
+	opts = opts.normalize()
+
+	p := LimitOffsetPagination{opts: opts}
+	if err := c.ShouldBindQuery(&p); err != nil {
+		return p, errorx.ErrIllegalArgument.WithReason("invalid pagination params").Wrap(err)
+	}
+
+	switch {
+	case p.PrimitivePageSize == 0:
+		p.PrimitivePageSize = uint64(opts.DefaultPageSize)
+	case p.PrimitivePageSize > uint64(opts.MaxPageSize):
+		return p, errorx.ErrIllegalArgument.WithReason(fmt.Sprintf("page_size must be less than or equal to %d", opts.MaxPageSize))
+	}
+	if p.PrimitivePage == 0 {
+		p.PrimitivePage = 1
+	}
+
+	return p, nil
+}
+
+// BindCursor binds the "after" and "limit" query params, an absent limit falls
+// back to the default, a limit over the maximum is rejected.
+func BindCursor(c *gin.Context, opts Options) (CursorPagination, error) {
+	opts = opts.normalize()
+
+	p := CursorPagination{opts: opts}
+	if err := c.ShouldBindQuery(&p); err != nil {
+		return p, errorx.ErrIllegalArgument.WithReason("invalid pagination params").Wrap(err)
+	}
+
+	switch {
+	case p.Limit < 0:
+		return p, errorx.ErrIllegalArgument.WithReason("limit must be greater than or equal to 0")
+	case p.Limit == 0:
+		p.Limit = opts.DefaultPageSize
+	case p.Limit > opts.MaxPageSize:
+		return p, errorx.ErrIllegalArgument.WithReason(fmt.Sprintf("limit must be less than or equal to %d", opts.MaxPageSize))
+	}
+
+	return p, nil
+}
+
+// SetLinkHeader writes the links as a Link header, nothing is written if all
+// links are empty.
+func SetLinkHeader(c *gin.Context, links Links) {
+	if header := links.Header(); header != "" {
+		c.Header("Link", header)
+	}
+}
+
+// RenderPage writes the page with its links to the response.
+func RenderPage[T any](c *gin.Context, page Page[T]) {
+	page = page.WithLinks(c.Request.URL)
+	SetLinkHeader(c, page.Links)
+	c.JSON(http.StatusOK, page)
+}
+
+// RenderCursorPage writes the cursor page with its links to the response.
+func RenderCursorPage[T any](c *gin.Context, page CursorPage[T]) {
+	page = page.WithLinks(c.Request.URL)
+	SetLinkHeader(c, page.Links)
+	c.JSON(http.StatusOK, page)
+}
@@ -8,22 +8,21 @@ const (
 type LimitOffsetPagination struct {
 	PrimitivePage     uint64 `json:"page" form:"page"`           // 页码
 	PrimitivePageSize uint64 `json:"page_size" form:"page_size"` // 每页数量
+
+	opts Options // 由 BindLimitOffset 设置，为空时使用默认值
 }
 
 func (p LimitOffsetPagination) Offset() uint64 {
-	if p.PrimitivePage == 0 || p.PrimitivePage == 1 {
-		return 0
-	}
-
-	return (p.PrimitivePage - 1) * p.PrimitivePageSize
+	return (p.Page() - 1) * p.PageSize()
 }
 
 func (p LimitOffsetPagination) PageSize() uint64 {
+	opts := p.opts.normalize()
 	switch {
 	case p.PrimitivePageSize == 0:
-		return uint64(DEFAULT_PAGE_SIZE)
-	case p.PrimitivePageSize > 100:
-		return uint64(MAX_PAGE_SIZE)
+		return uint64(opts.DefaultPageSize)
+	case p.PrimitivePageSize > uint64(opts.MaxPageSize):
+		return uint64(opts.MaxPageSize)
 	default:
 		return p.PrimitivePageSize
 	}
@@ -0,0 +1,140 @@
+package pagination
+
+import (
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// Links are the navigation links of a paged result, empty links are omitted.
+type Links struct {
+	Self  string `json:"self,omitempty"`
+	First string `json:"first,omitempty"`
+	Prev  string `json:"prev,omitempty"`
+	Next  string `json:"next,omitempty"`
+	Last  string `json:"last,omitempty"`
+}
+
+// Header formats the links as the value of a RFC 8288 Link header.
+func (l Links) Header() string {
+	var parts []string
+	for _, link := range []struct{ rel, href string }{
+		{"first", l.First},
+		{"prev", l.Prev},
+		{"next", l.Next},
+		{"last", l.Last},
+	} {
+		if link.href != "" {
+			parts = append(parts, "<"+link.href+`>; rel="`+link.rel+`"`)
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+// Page is the response of a limit/offset paged list.
+type Page[T any] struct {
+	Items      []T    `json:"items"`
+	Total      uint64 `json:"total"`
+	Page       uint64 `json:"page"`
+	PageSize   uint64 `json:"page_size"`
+	TotalPages uint64 `json:"total_pages"`
+	Links      Links  `json:"links"`
+}
+
+// NewPage creates a Page from the items of the current page and the total
+// count of all items.
+func NewPage[T any](items []T, total uint64, p LimitOffsetPagination) Page[T] {
+	if items == nil {
+		items = []T{}
+	}
+
+	return Page[T]{
+		Items:      items,
+		Total:      total,
+		Page:       p.Page(),
+		PageSize:   p.PageSize(),
+		TotalPages: p.TotalPage(total),
+	}
+}
+
+// WithLinks fills the links of the page based on the request url, the
+// "page" and "page_size" query parameters are replaced.
+func (p Page[T]) WithLinks(u *url.URL) Page[T] {
+	link := func(page uint64) string {
+		query := u.Query()
+		query.Set("page", strconv.FormatUint(page, 10))
+		query.Set("page_size", strconv.FormatUint(p.PageSize, 10))
+		rv := *u
+		rv.RawQuery = query.Encode()
+		return rv.String()
+	}
+
+	p.Links = Links{
+		Self:  link(p.Page),
+		First: link(1),
+		Last:  link(p.TotalPages),
+	}
+	if p.Page > 1 {
+		p.Links.Prev = link(p.Page - 1)
+	}
+	if p.Page < p.TotalPages {
+		p.Links.Next = link(p.Page + 1)
+	}
+	return p
+}
+
+// CursorPage is the response of a cursor paged list.
+type CursorPage[T any] struct {
+	Items   []T     `json:"items"`
+	Next    Cursor  `json:"next,omitempty"`
+	HasMore bool    `json:"has_more"`
+	Limit   int     `json:"limit"`
+	Total   *uint64 `json:"total,omitempty"`
+	Links   Links   `json:"links"`
+}
+
+// NewCursorPage creates a CursorPage, next is the cursor of the following
+// page and should be empty on the last page.
+func NewCursorPage[T any](items []T, next Cursor, p CursorPagination) CursorPage[T] {
+	if items == nil {
+		items = []T{}
+	}
+
+	return CursorPage[T]{
+		Items:   items,
+		Next:    next,
+		HasMore: !next.IsEmpty(),
+		Limit:   p.SafeLimit(),
+	}
+}
+
+// WithTotal sets the optional total count of all items.
+func (p CursorPage[T]) WithTotal(total uint64) CursorPage[T] {
+	p.Total = &total
+	return p
+}
+
+// WithLinks fills the links of the page based on the request url, the
+// "after" and "limit" query parameters are replaced.
+func (p CursorPage[T]) WithLinks(u *url.URL) CursorPage[T] {
+	link := func(after Cursor) string {
+		query := u.Query()
+		query.Del("after")
+		if !after.IsEmpty() {
+			query.Set("after", after.String())
+		}
+		query.Set("limit", strconv.Itoa(p.Limit))
+		rv := *u
+		rv.RawQuery = query.Encode()
+		return rv.String()
+	}
+
+	p.Links = Links{
+		Self:  link(Cursor(u.Query().Get("after"))),
+		First: link(""),
+	}
+	if p.HasMore {
+		p.Links.Next = link(p.Next)
+	}
+	return p
+}
@@ -0,0 +1,101 @@
+package pagination
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+
+	"demo/extension/errorx"
+)
+
+func newTestContext(target string) *gin.Context {
+	gin.SetMode(gin.TestMode)
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest("GET", target, nil)
+	return c
+}
+
+func TestBindLimitOffset(t *testing.T) {
+	opts := Options{DefaultPageSize: 20, MaxPageSize: 50}
+
+	p, err := BindLimitOffset(newTestContext("/items"), opts)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(1), p.Page())
+	assert.Equal(t, uint64(20), p.PageSize())
+
+	p, err = BindLimitOffset(newTestContext("/items?page=3&page_size=50"), opts)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(100), p.Offset())
+	assert.Equal(t, uint64(50), p.PageSize())
+
+	_, err = BindLimitOffset(newTestContext("/items?page_size=51"), opts)
+	assert.True(t, errorx.ErrIllegalArgument.Is(err))
+
+	_, err = BindLimitOffset(newTestContext("/items?page=abc"), opts)
+	assert.True(t, errorx.ErrIllegalArgument.Is(err))
+}
+
+func TestLimitOffset(t *testing.T) {
+	// the offset follows the defaulted and clamped page size
+	p := LimitOffsetPagination{PrimitivePage: 3}
+	assert.Equal(t, uint64(2*DEFAULT_PAGE_SIZE), p.Offset())
+
+	p = LimitOffsetPagination{PrimitivePage: 2, PrimitivePageSize: 5000}
+	assert.Equal(t, uint64(MAX_PAGE_SIZE), p.PageSize())
+	assert.Equal(t, uint64(MAX_PAGE_SIZE), p.Offset())
+
+	p = LimitOffsetPagination{PrimitivePage: 0, PrimitivePageSize: 5}
+	assert.Equal(t, uint64(0), p.Offset())
+}
+
+func TestBindCursor(t *testing.T) {
+	opts := Options{DefaultPageSize: 20, MaxPageSize: 50}
+
+	p, err := BindCursor(newTestContext("/items?after=abc"), opts)
+	assert.NoError(t, err)
+	assert.Equal(t, Cursor("abc"), p.After)
+	assert.Equal(t, 20, p.SafeLimit())
+
+	_, err = BindCursor(newTestContext("/items?limit=-1"), opts)
+	assert.True(t, errorx.ErrIllegalArgument.Is(err))
+
+	_, err = BindCursor(newTestContext("/items?limit=100"), opts)
+	assert.True(t, errorx.ErrIllegalArgument.Is(err))
+}
+
+func TestPageLinks(t *testing.T) {
+	c := newTestContext("/items?page=2&page_size=10&status=active")
+	p, err := BindLimitOffset(c, DefaultOptions())
+	assert.NoError(t, err)
+
+	page := NewPage([]string{"a"}, 35, p).WithLinks(c.Request.URL)
+	assert.Equal(t, uint64(4), page.TotalPages)
+	assert.Equal(t, "/items?page=1&page_size=10&status=active", page.Links.Prev)
+	assert.Equal(t, "/items?page=3&page_size=10&status=active", page.Links.Next)
+	assert.Equal(t, "/items?page=4&page_size=10&status=active", page.Links.Last)
+	assert.Equal(t,
+		`</items?page=1&page_size=10&status=active>; rel="first", `+
+			`</items?page=1&page_size=10&status=active>; rel="prev", `+
+			`</items?page=3&page_size=10&status=active>; rel="next", `+
+			`</items?page=4&page_size=10&status=active>; rel="last"`,
+		page.Links.Header())
+
+	empty := NewPage[string](nil, 0, p)
+	assert.NotNil(t, empty.Items)
+}
+
+func TestCursorPageLinks(t *testing.T) {
+	c := newTestContext("/items?limit=5")
+	p, err := BindCursor(c, DefaultOptions())
+	assert.NoError(t, err)
+
+	page := NewCursorPage([]int{1, 2}, "next", p).WithLinks(c.Request.URL)
+	assert.True(t, page.HasMore)
+	assert.Equal(t, "/items?after=next&limit=5", page.Links.Next)
+
+	last := NewCursorPage([]int{1}, "", p).WithLinks(c.Request.URL)
+	assert.False(t, last.HasMore)
+	assert.Empty(t, last.Links.Next)
+}
@@ -0,0 +1,128 @@
+// Package password hashes the passwords with argon2id or bcrypt, the hashes
+// are self describing so that the parameters can be changed without
+// invalidating the stored ones.
+package password
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+var (
+	ErrMismatch    = errors.New("password: hash and password mismatch")
+	ErrInvalidHash = errors.New("password: hash is invalid")
+)
+
+// Hasher hashes a password.
+type Hasher interface {
+	Hash(password string) (string, error)
+}
+
+// Argon2id hashes into the PHC string format
+// $argon2id$v=19$m=65536,t=1,p=4$salt$key.
+type Argon2id struct {
+	// Memory is in KiB.
+	Memory  uint32
+	Time    uint32
+	Threads uint8
+	KeyLen  uint32
+}
+
+// DefaultArgon2id returns the parameters recommended by RFC 9106 for
+// environments with limited memory.
+func DefaultArgon2id() Argon2id {
+	return Argon2id{Memory: 64 * 1024, Time: 3, Threads: 4, KeyLen: 32}
+}
+
+func (a Argon2id) Hash(password string) (string, error) {
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+	key := argon2.IDKey([]byte(password), salt, a.Time, a.Memory, a.Threads, a.KeyLen)
+
+	enc := base64.RawStdEncoding
+	return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2.Version, a.Memory, a.Time, a.Threads, enc.EncodeToString(salt), enc.EncodeToString(key)), nil
+}
+
+// Bcrypt hashes with bcrypt, which truncates the passwords to 72 bytes.
+type Bcrypt struct {
+	Cost int
+}
+
+func (b Bcrypt) Hash(password string) (string, error) {
+	cost := b.Cost
+	if cost == 0 {
+		cost = bcrypt.DefaultCost
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), cost)
+	if err != nil {
+		return "", err
+	}
+	return string(hash), nil
+}
+
+// Hash hashes the password with the default argon2id parameters.
+func Hash(password string) (string, error) {
+	return DefaultArgon2id().Hash(password)
+}
+
+// Verify checks the password against an argon2id or a bcrypt hash, it fails
+// with ErrMismatch if the password is wrong.
+func Verify(hash, password string) error {
+	switch {
+	case strings.HasPrefix(hash, "$argon2id$"):
+		return verifyArgon2id(hash, password)
+	case strings.HasPrefix(hash, "$2a$"), strings.HasPrefix(hash, "$2b$"), strings.HasPrefix(hash, "$2y$"):
+		err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
+		if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+			return ErrMismatch
+		}
+		if err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidHash, err)
+		}
+		return nil
+	default:
+		return ErrInvalidHash
+	}
+}
+
+func verifyArgon2id(hash, password string) error {
+	// "", "argon2id", "v=19", "m=65536,t=3,p=4", salt, key
+	parts := strings.Split(hash, "$")
+	if len(parts) != 6 {
+		return ErrInvalidHash
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return ErrInvalidHash
+	}
+	var a Argon2id
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &a.Memory, &a.Time, &a.Threads); err != nil {
+		return ErrInvalidHash
+	}
+	enc := base64.RawStdEncoding
+	salt, err := enc.DecodeString(parts[4])
+	if err != nil {
+		return ErrInvalidHash
+	}
+	key, err := enc.DecodeString(parts[5])
+	if err != nil || len(key) == 0 {
+		return ErrInvalidHash
+	}
+
+	actual := argon2.IDKey([]byte(password), salt, a.Time, a.Memory, a.Threads, uint32(len(key)))
+	if subtle.ConstantTimeCompare(actual, key) != 1 {
+		return ErrMismatch
+	}
+	return nil
+}
@@ -0,0 +1,36 @@
+package password
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestArgon2id(t *testing.T) {
+	hasher := Argon2id{Memory: 1024, Time: 1, Threads: 1, KeyLen: 32}
+	hash, err := hasher.Hash("secret")
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(hash, "$argon2id$v=19$m=1024,t=1,p=1$"))
+
+	assert.NoError(t, Verify(hash, "secret"))
+	assert.ErrorIs(t, Verify(hash, "wrong"), ErrMismatch)
+
+	other, err := hasher.Hash("secret")
+	assert.NoError(t, err)
+	assert.NotEqual(t, hash, other, "the salt is random")
+}
+
+func TestBcrypt(t *testing.T) {
+	hash, err := Bcrypt{Cost: 4}.Hash("secret")
+	assert.NoError(t, err)
+
+	assert.NoError(t, Verify(hash, "secret"))
+	assert.ErrorIs(t, Verify(hash, "wrong"), ErrMismatch)
+}
+
+func TestInvalidHash(t *testing.T) {
+	assert.ErrorIs(t, Verify("plain", "plain"), ErrInvalidHash)
+	assert.ErrorIs(t, Verify("$argon2id$v=19$m=1024$salt$key", "secret"), ErrInvalidHash)
+	assert.ErrorIs(t, Verify("$2a$04$short", "secret"), ErrInvalidHash)
+}
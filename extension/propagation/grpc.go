@@ -0,0 +1,53 @@
+package propagation
+
+import (
+	"context"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+
+	"demo/extension/contextz"
+	"demo/extension/tracing"
+)
+
+// UnaryClientInterceptor traces the call with a client span and injects the
+// values of the context into the outgoing grpc metadata.
+func UnaryClientInterceptor() grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		ctx, span := tracing.Start(ctx, method, tracing.WithSpanKind(tracing.SpanKindClient))
+		defer span.End()
+		span.SetAttribute("rpc.system", "grpc")
+
+		err := invoker(outgoingContext(ctx), method, req, reply, cc, opts...)
+		span.RecordError(err)
+		return err
+	}
+}
+
+// StreamClientInterceptor injects the values of the context into the outgoing
+// grpc metadata.
+func StreamClientInterceptor() grpc.StreamClientInterceptor {
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		return streamer(outgoingContext(ctx), desc, cc, method, opts...)
+	}
+}
+
+func outgoingContext(ctx context.Context) context.Context {
+	md, _ := metadata.FromOutgoingContext(ctx)
+	var kv []string
+
+	if requestId := contextz.RequestId(ctx); requestId != "" && len(md.Get(RequestIdMetadataKey)) == 0 {
+		kv = append(kv, RequestIdMetadataKey, requestId)
+	}
+	if sc := tracing.SpanFromContext(ctx).SpanContext(); sc.IsValid() && len(md.Get(tracing.TraceparentHeader)) == 0 {
+		kv = append(kv, tracing.TraceparentHeader, sc.Traceparent())
+		if sc.Tracestate != "" {
+			kv = append(kv, tracing.TracestateHeader, sc.Tracestate)
+		}
+	}
+
+	if len(kv) == 0 {
+		return ctx
+	}
+	return metadata.AppendToOutgoingContext(ctx, kv...)
+}
@@ -0,0 +1,56 @@
+package propagation
+
+import (
+	"net/http"
+
+	"demo/extension/contextz"
+	"demo/extension/tracing"
+)
+
+// Transport is a http.RoundTripper which injects the values of the request
+// context into the outbound request headers, the request is traced by a
+// client span whose context is sent as the traceparent header.
+type Transport struct {
+	// Base is the underlying RoundTripper, http.DefaultTransport if nil.
+	Base http.RoundTripper
+}
+
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	ctx, span := tracing.Start(req.Context(), "HTTP "+req.Method, tracing.WithSpanKind(tracing.SpanKindClient))
+	defer span.End()
+	span.SetAttribute("http.method", req.Method)
+	span.SetAttribute("http.url", req.URL.Scheme+"://"+req.URL.Host+req.URL.Path)
+
+	// a RoundTripper must not modify the request
+	req = req.Clone(ctx)
+	if requestId := contextz.RequestId(ctx); requestId != "" && req.Header.Get(RequestIdHeader) == "" {
+		req.Header.Set(RequestIdHeader, requestId)
+	}
+	tracing.Inject(ctx, req.Header)
+
+	resp, err := base.RoundTrip(req)
+	if err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+	span.SetAttribute("http.status_code", resp.StatusCode)
+	if resp.StatusCode >= http.StatusInternalServerError {
+		span.SetStatus(tracing.StatusError, resp.Status)
+	}
+	return resp, nil
+}
+
+// NewHTTPClient returns a http.Client which propagates the request context.
+func NewHTTPClient(base *http.Client) *http.Client {
+	if base == nil {
+		base = &http.Client{}
+	}
+	client := *base
+	client.Transport = &Transport{Base: base.Transport}
+	return &client
+}
@@ -0,0 +1,67 @@
+package propagation
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/metadata"
+
+	"demo/extension/contextz"
+	"demo/extension/tracing"
+)
+
+func TestValidRequestId(t *testing.T) {
+	assert.True(t, ValidRequestId("abc-123_x.y:z"))
+	assert.False(t, ValidRequestId(""))
+	assert.False(t, ValidRequestId("abc 123"))
+	assert.False(t, ValidRequestId("<script>"))
+	assert.False(t, ValidRequestId(strings.Repeat("a", 129)))
+
+	assert.Equal(t, "abc", RequestIdOrNew("abc"))
+	assert.Len(t, RequestIdOrNew("a b"), 32)
+}
+
+func TestTransport(t *testing.T) {
+	var received, traceparent string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r.Header.Get(RequestIdHeader)
+		traceparent = r.Header.Get(tracing.TraceparentHeader)
+	}))
+	defer srv.Close()
+
+	client := NewHTTPClient(nil)
+	ctx := contextz.WithRequestId(context.Background(), "req-1")
+	ctx, span := tracing.Start(ctx, "test")
+	defer span.End()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL, nil)
+	assert.NoError(t, err)
+	resp, err := client.Do(req)
+	assert.NoError(t, err)
+	resp.Body.Close()
+
+	assert.Equal(t, "req-1", received)
+	sc, err := tracing.ParseTraceparent(traceparent)
+	assert.NoError(t, err)
+	assert.Equal(t, span.SpanContext().TraceID, sc.TraceID)
+	assert.NotEqual(t, span.SpanContext().SpanID, sc.SpanID, "the client span is the parent")
+	assert.Empty(t, req.Header.Get(RequestIdHeader), "the original request is not modified")
+}
+
+func TestOutgoingContext(t *testing.T) {
+	ctx := outgoingContext(contextz.WithRequestId(context.Background(), "req-1"))
+	md, ok := metadata.FromOutgoingContext(ctx)
+	assert.True(t, ok)
+	assert.Equal(t, []string{"req-1"}, md.Get(RequestIdMetadataKey))
+
+	ctx, span := tracing.Start(context.Background(), "test")
+	md, _ = metadata.FromOutgoingContext(outgoingContext(ctx))
+	assert.Equal(t, []string{span.SpanContext().Traceparent()}, md.Get(tracing.TraceparentHeader))
+
+	ctx = outgoingContext(context.Background())
+	_, ok = metadata.FromOutgoingContext(ctx)
+	assert.False(t, ok)
+}
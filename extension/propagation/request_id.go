@@ -0,0 +1,50 @@
+package propagation
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+)
+
+const (
+	// RequestIdHeader is the http header carrying the request id.
+	RequestIdHeader = "X-Request-ID"
+
+	// RequestIdMetadataKey is the grpc metadata key carrying the request id.
+	RequestIdMetadataKey = "x-request-id"
+
+	maxRequestIdLength = 128
+)
+
+// NewRequestId generates a random request id of 32 hex characters.
+func NewRequestId() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	return hex.EncodeToString(b)
+}
+
+// ValidRequestId reports whether a request id received from a client can be
+// accepted: at most 128 characters of letters, digits and "-_.:".
+func ValidRequestId(id string) bool {
+	if id == "" || len(id) > maxRequestIdLength {
+		return false
+	}
+	for _, c := range id {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9':
+		case c == '-', c == '_', c == '.', c == ':':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// RequestIdOrNew returns id if it is valid, or a new request id.
+func RequestIdOrNew(id string) string {
+	if ValidRequestId(id) {
+		return id
+	}
+	return NewRequestId()
+}
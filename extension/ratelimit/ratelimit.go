@@ -0,0 +1,208 @@
+// Package ratelimit limits the rate of the requests of a key, such as the ip
+// or the user of a request, with a token bucket or a sliding window. The
+// states are kept in a kv.KV, so that the instances sharing a Redis store
+// share the limits as well.
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+
+	"demo/extension/kv"
+)
+
+// The algorithms of the Limiter.
+const (
+	// TokenBucket allows a burst of requests and refills the bucket at the
+	// rate, it is implemented as the generic cell rate algorithm which keeps
+	// a single timestamp per key.
+	TokenBucket = "token_bucket"
+	// SlidingWindow counts the requests of the current and the previous fixed
+	// windows, weighting the previous one by its overlap with the sliding
+	// window. The rejected requests are counted as well, so that a client
+	// retrying without backing off stays limited.
+	SlidingWindow = "sliding_window"
+)
+
+// casRetries bounds the retries of a token bucket updated concurrently.
+const casRetries = 8
+
+// ErrContended is returned if the state of a key is updated concurrently
+// too often.
+var ErrContended = errors.New("ratelimit: too many concurrent updates")
+
+// Options is the options of the Limiter.
+type Options struct {
+	// Algorithm is TokenBucket or SlidingWindow, defaults to TokenBucket.
+	Algorithm string
+
+	// Limit is the number of requests allowed per Period.
+	Limit  int
+	Period time.Duration
+
+	// Burst is the capacity of the token bucket, defaults to Limit. It is
+	// ignored by the sliding window.
+	Burst int
+
+	// Prefix is prepended to the keys of the states.
+	Prefix string
+}
+
+// Result is the decision on a request and the quota left after it.
+type Result struct {
+	Allowed bool
+
+	// Limit is the quota, the capacity of the bucket or the requests allowed
+	// in a window.
+	Limit     int
+	Remaining int
+
+	// Reset is when the bucket is full again, or when the current window
+	// ends.
+	Reset time.Duration
+
+	// RetryAfter is when a rejected request would be allowed.
+	RetryAfter time.Duration
+}
+
+type Limiter struct {
+	store kv.KV
+	opts  Options
+	now   func() time.Time
+}
+
+func New(store kv.KV, opts Options) (*Limiter, error) {
+	if opts.Algorithm == "" {
+		opts.Algorithm = TokenBucket
+	}
+	if opts.Algorithm != TokenBucket && opts.Algorithm != SlidingWindow {
+		return nil, fmt.Errorf("ratelimit: unknown algorithm %q", opts.Algorithm)
+	}
+	if opts.Limit <= 0 || opts.Period <= 0 {
+		return nil, errors.New("ratelimit: limit and period must be positive")
+	}
+	if opts.Burst <= 0 {
+		opts.Burst = opts.Limit
+	}
+	return &Limiter{store: store, opts: opts, now: time.Now}, nil
+}
+
+// Policy describes the quota in the format of the RateLimit-Policy header,
+// such as 10;w=60 or 10;w=60;burst=20.
+func (l *Limiter) Policy() string {
+	policy := fmt.Sprintf("%d;w=%d", l.opts.Limit, int64(math.Ceil(l.opts.Period.Seconds())))
+	if l.opts.Algorithm == TokenBucket && l.opts.Burst != l.opts.Limit {
+		policy += fmt.Sprintf(";burst=%d", l.opts.Burst)
+	}
+	return policy
+}
+
+// Allow takes a request of the key from the quota.
+func (l *Limiter) Allow(ctx context.Context, key string) (Result, error) {
+	if l.opts.Algorithm == SlidingWindow {
+		return l.slidingWindow(ctx, l.opts.Prefix+key)
+	}
+	return l.tokenBucket(ctx, l.opts.Prefix+key)
+}
+
+// tokenBucket keeps the theoretical arrival time of the next request, a
+// request is allowed if it is no later than the burst ahead of now.
+func (l *Limiter) tokenBucket(ctx context.Context, key string) (Result, error) {
+	interval := l.opts.Period / time.Duration(l.opts.Limit)
+	tolerance := interval * time.Duration(l.opts.Burst)
+
+	for i := 0; i < casRetries; i++ {
+		old, err := l.store.Get(ctx, key)
+		if errors.Is(err, kv.ErrNil) {
+			old = nil
+		} else if err != nil {
+			return Result{}, err
+		}
+
+		now := l.now()
+		tat := now
+		if old != nil {
+			n, err := strconv.ParseInt(string(old), 10, 64)
+			if err != nil {
+				return Result{}, fmt.Errorf("ratelimit: invalid state of %s: %w", key, err)
+			}
+			if t := time.Unix(0, n); t.After(now) {
+				tat = t
+			}
+		}
+
+		next := tat.Add(interval)
+		if ahead := next.Sub(now); ahead > tolerance {
+			return Result{
+				Limit:      l.opts.Burst,
+				Reset:      tat.Sub(now),
+				RetryAfter: ahead - tolerance,
+			}, nil
+		}
+
+		ok, err := l.store.CompareAndSwap(ctx, key, old, []byte(strconv.FormatInt(next.UnixNano(), 10)), next.Sub(now))
+		if err != nil {
+			return Result{}, err
+		}
+		if ok {
+			return Result{
+				Allowed:   true,
+				Limit:     l.opts.Burst,
+				Remaining: int((tolerance - next.Sub(now)) / interval),
+				Reset:     next.Sub(now),
+			}, nil
+		}
+	}
+	return Result{}, ErrContended
+}
+
+func (l *Limiter) slidingWindow(ctx context.Context, key string) (Result, error) {
+	now := l.now()
+	period := l.opts.Period
+	window := now.UnixNano() / int64(period)
+	elapsed := time.Duration(now.UnixNano() % int64(period))
+
+	current, err := l.store.Incr(ctx, key+":"+strconv.FormatInt(window, 10), 2*period)
+	if err != nil {
+		return Result{}, err
+	}
+	previous, err := l.count(ctx, key+":"+strconv.FormatInt(window-1, 10))
+	if err != nil {
+		return Result{}, err
+	}
+
+	limit := int64(l.opts.Limit)
+	count := float64(previous)*(1-float64(elapsed)/float64(period)) + float64(current)
+	rv := Result{Limit: l.opts.Limit, Reset: period - elapsed}
+	if count <= float64(limit) {
+		rv.Allowed = true
+		rv.Remaining = max(int(limit-int64(math.Ceil(count))), 0)
+		return rv, nil
+	}
+
+	// wait until the previous window has slid out enough, or the current
+	// window has become the previous one and slid out enough
+	if current <= limit {
+		overlap := 1 - float64(limit-current)/float64(previous)
+		rv.RetryAfter = time.Duration(math.Ceil(overlap*float64(period))) - elapsed
+	} else {
+		overlap := 1 - float64(limit)/float64(current)
+		rv.RetryAfter = period - elapsed + time.Duration(math.Ceil(overlap*float64(period)))
+	}
+	return rv, nil
+}
+
+func (l *Limiter) count(ctx context.Context, key string) (int64, error) {
+	value, err := l.store.Get(ctx, key)
+	if errors.Is(err, kv.ErrNil) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	return strconv.ParseInt(string(value), 10, 64)
+}
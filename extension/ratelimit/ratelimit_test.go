@@ -0,0 +1,89 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"demo/extension/kv"
+)
+
+func newLimiter(t *testing.T, opts Options) (*Limiter, *time.Time) {
+	l, err := New(kv.NewMemory(), opts)
+	assert.NoError(t, err)
+	now := time.Unix(0, 0).Add(1000 * time.Hour)
+	l.now = func() time.Time { return now }
+	return l, &now
+}
+
+func TestTokenBucket(t *testing.T) {
+	ctx := context.Background()
+	l, now := newLimiter(t, Options{Limit: 1, Period: time.Second, Burst: 3, Prefix: "ip:"})
+	assert.Equal(t, "1;w=1;burst=3", l.Policy())
+
+	for remaining := 2; remaining >= 0; remaining-- {
+		rv, err := l.Allow(ctx, "1.2.3.4")
+		assert.NoError(t, err)
+		assert.True(t, rv.Allowed)
+		assert.Equal(t, 3, rv.Limit)
+		assert.Equal(t, remaining, rv.Remaining)
+	}
+	rv, err := l.Allow(ctx, "1.2.3.4")
+	assert.NoError(t, err)
+	assert.False(t, rv.Allowed)
+	assert.Equal(t, time.Second, rv.RetryAfter)
+	assert.Equal(t, 3*time.Second, rv.Reset)
+
+	rv, err = l.Allow(ctx, "5.6.7.8")
+	assert.NoError(t, err)
+	assert.True(t, rv.Allowed, "the keys are limited separately")
+
+	// a token is refilled per second
+	*now = now.Add(time.Second)
+	rv, err = l.Allow(ctx, "1.2.3.4")
+	assert.NoError(t, err)
+	assert.True(t, rv.Allowed)
+	assert.Equal(t, 0, rv.Remaining)
+	rv, err = l.Allow(ctx, "1.2.3.4")
+	assert.NoError(t, err)
+	assert.False(t, rv.Allowed)
+}
+
+func TestSlidingWindow(t *testing.T) {
+	ctx := context.Background()
+	l, now := newLimiter(t, Options{Algorithm: SlidingWindow, Limit: 4, Period: time.Minute})
+	assert.Equal(t, "4;w=60", l.Policy())
+
+	for remaining := 3; remaining >= 0; remaining-- {
+		rv, err := l.Allow(ctx, "alice")
+		assert.NoError(t, err)
+		assert.True(t, rv.Allowed)
+		assert.Equal(t, remaining, rv.Remaining)
+		assert.Equal(t, time.Minute, rv.Reset)
+	}
+	rv, err := l.Allow(ctx, "alice")
+	assert.NoError(t, err)
+	assert.False(t, rv.Allowed)
+	// the 5 requests of the window must slide out to 4
+	assert.Equal(t, 72*time.Second, rv.RetryAfter)
+
+	// half of the previous window overlaps the sliding window
+	*now = now.Add(90 * time.Second)
+	rv, err = l.Allow(ctx, "alice")
+	assert.NoError(t, err)
+	assert.True(t, rv.Allowed)
+	assert.Equal(t, 0, rv.Remaining)
+	rv, err = l.Allow(ctx, "alice")
+	assert.NoError(t, err)
+	assert.False(t, rv.Allowed)
+	assert.Equal(t, 6*time.Second, rv.RetryAfter)
+}
+
+func TestOptions(t *testing.T) {
+	_, err := New(kv.NewMemory(), Options{Limit: 1})
+	assert.Error(t, err)
+	_, err = New(kv.NewMemory(), Options{Algorithm: "leaky_bucket", Limit: 1, Period: time.Second})
+	assert.Error(t, err)
+}
@@ -0,0 +1,114 @@
+// Package session keeps the server side sessions in a key value store, the
+// id of a session is the only value sent to the client.
+package session
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"demo/extension/contextz"
+	"demo/extension/kv"
+)
+
+// Options is the options of the Manager.
+type Options struct {
+	// TTL is the lifetime of a session, defaults to 24 hours.
+	TTL time.Duration
+
+	// Prefix is prepended to the keys of the sessions, defaults to "session:".
+	Prefix string
+}
+
+// record is the stored form of a session.
+type record struct {
+	Session   contextz.Session    `json:"session"`
+	Principal *contextz.Principal `json:"principal,omitempty"`
+}
+
+// Manager creates, loads and destroys the sessions.
+type Manager struct {
+	store kv.KV
+	opts  Options
+}
+
+func NewManager(store kv.KV, opts Options) *Manager {
+	if opts.TTL <= 0 {
+		opts.TTL = 24 * time.Hour
+	}
+	if opts.Prefix == "" {
+		opts.Prefix = "session:"
+	}
+	return &Manager{store: store, opts: opts}
+}
+
+// TTL returns the lifetime of the sessions.
+func (m *Manager) TTL() time.Duration {
+	return m.opts.TTL
+}
+
+// Create starts a session of the principal with a new random id.
+func (m *Manager) Create(ctx context.Context, principal *contextz.Principal, values map[string]any) (*contextz.Session, error) {
+	id, err := newID()
+	if err != nil {
+		return nil, err
+	}
+
+	r := record{
+		Session: contextz.Session{
+			Id:        id,
+			Values:    values,
+			ExpiresAt: time.Now().Add(m.opts.TTL),
+		},
+		Principal: principal,
+	}
+	if principal != nil {
+		r.Session.UserId = principal.UserId
+	}
+
+	data, err := json.Marshal(r)
+	if err != nil {
+		return nil, err
+	}
+	if err := m.store.Set(ctx, m.opts.Prefix+id, data, m.opts.TTL); err != nil {
+		return nil, err
+	}
+	return &r.Session, nil
+}
+
+// LoadSession returns the session and its principal, a nil session if it
+// does not exist or is expired.
+func (m *Manager) LoadSession(ctx context.Context, id string) (*contextz.Session, *contextz.Principal, error) {
+	data, err := m.store.Get(ctx, m.opts.Prefix+id)
+	if errors.Is(err, kv.ErrNil) {
+		return nil, nil, nil
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var r record
+	if err := json.Unmarshal(data, &r); err != nil {
+		return nil, nil, err
+	}
+	if r.Session.Expired(time.Now()) {
+		return nil, nil, nil
+	}
+	return &r.Session, r.Principal, nil
+}
+
+// Destroy deletes the session, a missing session is ignored.
+func (m *Manager) Destroy(ctx context.Context, id string) error {
+	return m.store.Del(ctx, m.opts.Prefix+id)
+}
+
+func newID() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
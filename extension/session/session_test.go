@@ -0,0 +1,38 @@
+package session
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"demo/extension/contextz"
+	"demo/extension/kv"
+)
+
+func TestManager(t *testing.T) {
+	ctx := context.Background()
+	m := NewManager(kv.NewMemory(), Options{TTL: time.Hour})
+
+	s, err := m.Create(ctx, &contextz.Principal{UserId: "u1", Roles: []string{"admin"}}, map[string]any{"theme": "dark"})
+	assert.NoError(t, err)
+	assert.NotEmpty(t, s.Id)
+	assert.Equal(t, "u1", s.UserId)
+
+	loaded, principal, err := m.LoadSession(ctx, s.Id)
+	assert.NoError(t, err)
+	assert.Equal(t, s.Id, loaded.Id)
+	assert.Equal(t, "dark", loaded.Values["theme"])
+	assert.Equal(t, []string{"admin"}, principal.Roles)
+
+	assert.NoError(t, m.Destroy(ctx, s.Id))
+	loaded, principal, err = m.LoadSession(ctx, s.Id)
+	assert.NoError(t, err)
+	assert.Nil(t, loaded)
+	assert.Nil(t, principal)
+
+	loaded, _, err = m.LoadSession(ctx, "unknown")
+	assert.NoError(t, err)
+	assert.Nil(t, loaded)
+}
@@ -0,0 +1,82 @@
+// Package throttle counts the failures of a key, such as the login attempts
+// of an account or an ip, and rejects the key once it failed too often.
+package throttle
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"time"
+
+	"demo/extension/errorx"
+	"demo/extension/kv"
+)
+
+// Options is the options of the Throttle.
+type Options struct {
+	// Max is the number of failures allowed within the window.
+	Max int
+
+	// Window starts with the first failure, the key is rejected until it
+	// ends once Max is reached.
+	Window time.Duration
+
+	// Prefix is prepended to the keys of the counters.
+	Prefix string
+}
+
+type Throttle struct {
+	store kv.KV
+	opts  Options
+}
+
+func New(store kv.KV, opts Options) *Throttle {
+	return &Throttle{store: store, opts: opts}
+}
+
+// Check fails with ErrRejected if one of the keys failed Max times.
+func (t *Throttle) Check(ctx context.Context, keys ...string) error {
+	if t.opts.Max <= 0 {
+		return nil
+	}
+	for _, key := range keys {
+		n, err := t.failures(ctx, key)
+		if err != nil {
+			return errorx.ErrInternalServer.Wrap(err)
+		}
+		if n >= t.opts.Max {
+			return errorx.ErrRejected.WithMessageF("too many failed attempts, retry in %s", t.opts.Window)
+		}
+	}
+	return nil
+}
+
+// Fail counts a failure of each of the keys.
+func (t *Throttle) Fail(ctx context.Context, keys ...string) error {
+	for _, key := range keys {
+		if _, err := t.store.Incr(ctx, t.opts.Prefix+key, t.opts.Window); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Reset forgets the failures of the keys.
+func (t *Throttle) Reset(ctx context.Context, keys ...string) error {
+	prefixed := make([]string, 0, len(keys))
+	for _, key := range keys {
+		prefixed = append(prefixed, t.opts.Prefix+key)
+	}
+	return t.store.Del(ctx, prefixed...)
+}
+
+func (t *Throttle) failures(ctx context.Context, key string) (int, error) {
+	value, err := t.store.Get(ctx, t.opts.Prefix+key)
+	if errors.Is(err, kv.ErrNil) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	return strconv.Atoi(string(value))
+}
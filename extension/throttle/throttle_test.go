@@ -0,0 +1,39 @@
+package throttle
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"demo/extension/errorx"
+	"demo/extension/kv"
+)
+
+func TestThrottle(t *testing.T) {
+	ctx := context.Background()
+	th := New(kv.NewMemory(), Options{Max: 2, Window: 50 * time.Millisecond, Prefix: "login:"})
+
+	assert.NoError(t, th.Check(ctx, "alice"))
+	assert.NoError(t, th.Fail(ctx, "alice"))
+	assert.NoError(t, th.Check(ctx, "alice"))
+	assert.NoError(t, th.Fail(ctx, "alice"))
+
+	var ex errorx.Error
+	assert.True(t, errors.As(th.Check(ctx, "bob", "alice"), &ex))
+	assert.Equal(t, errorx.ErrRejected.Code, ex.Code)
+	assert.NoError(t, th.Check(ctx, "bob"))
+
+	// the lock ends with the window
+	time.Sleep(60 * time.Millisecond)
+	assert.NoError(t, th.Check(ctx, "alice"))
+
+	assert.NoError(t, th.Fail(ctx, "alice"))
+	assert.NoError(t, th.Fail(ctx, "alice"))
+	keys := []string{"alice"}
+	assert.NoError(t, th.Reset(ctx, keys...))
+	assert.Equal(t, []string{"alice"}, keys)
+	assert.NoError(t, th.Check(ctx, "alice"))
+}
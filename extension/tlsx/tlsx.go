@@ -0,0 +1,184 @@
+// Package tlsx builds the tls configs of the servers, the certificates and
+// the client CAs are reloaded when their files change.
+package tlsx
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"demo/extension/logz"
+)
+
+// Options configures the Reloader.
+type Options struct {
+	CertFile string
+	KeyFile  string
+
+	// ClientCAFile verifies the client certificates by the ClientAuth.
+	ClientCAFile string
+	ClientAuth   tls.ClientAuthType
+
+	// MinVersion defaults to TLS 1.2.
+	MinVersion uint16
+	// NextProtos are the ALPN protocols, such as h2 and http/1.1.
+	NextProtos []string
+
+	// ReloadInterval is the minimum interval between the checks of the files,
+	// defaults to 30s.
+	ReloadInterval time.Duration
+}
+
+// Reloader serves the certificate and the client CAs of the files, the files
+// are checked during the handshakes and reloaded if they were modified. The
+// previous config is kept if the modified files are invalid.
+type Reloader struct {
+	opts Options
+	now  func() time.Time
+
+	mu      sync.Mutex
+	checked time.Time
+	mtimes  []time.Time
+
+	config atomic.Pointer[tls.Config]
+}
+
+// NewReloader loads the files, they must be valid.
+func NewReloader(opts Options) (*Reloader, error) {
+	if opts.MinVersion == 0 {
+		opts.MinVersion = tls.VersionTLS12
+	}
+	if opts.ReloadInterval <= 0 {
+		opts.ReloadInterval = 30 * time.Second
+	}
+
+	r := &Reloader{opts: opts, now: time.Now}
+	mtimes, err := r.modTimes()
+	if err != nil {
+		return nil, err
+	}
+	if err := r.load(); err != nil {
+		return nil, err
+	}
+	r.mtimes = mtimes
+	r.checked = r.now()
+	return r, nil
+}
+
+// Config returns the tls config of a server.
+func (r *Reloader) Config() *tls.Config {
+	return &tls.Config{
+		MinVersion: r.opts.MinVersion,
+		NextProtos: r.opts.NextProtos,
+		GetCertificate: func(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+			return &r.current().Certificates[0], nil
+		},
+		GetConfigForClient: func(*tls.ClientHelloInfo) (*tls.Config, error) {
+			return r.current(), nil
+		},
+	}
+}
+
+// current returns the config of the files, after reloading them if they were
+// modified.
+func (r *Reloader) current() *tls.Config {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if now := r.now(); now.Sub(r.checked) >= r.opts.ReloadInterval {
+		r.checked = now
+		if err := r.reload(); err != nil {
+			logz.WarnNoCtx("[tlsx] reload of the certificates failed, the previous ones are kept",
+				logz.String("cert_file", r.opts.CertFile), logz.Err(err))
+		}
+	}
+	return r.config.Load()
+}
+
+func (r *Reloader) reload() error {
+	mtimes, err := r.modTimes()
+	if err != nil {
+		return err
+	}
+	changed := false
+	for i := range mtimes {
+		changed = changed || !mtimes[i].Equal(r.mtimes[i])
+	}
+	if !changed {
+		return nil
+	}
+
+	if err := r.load(); err != nil {
+		return err
+	}
+	r.mtimes = mtimes
+	logz.InfoNoCtx("[tlsx] certificates reloaded", logz.String("cert_file", r.opts.CertFile))
+	return nil
+}
+
+func (r *Reloader) modTimes() ([]time.Time, error) {
+	var mtimes []time.Time
+	for _, name := range []string{r.opts.CertFile, r.opts.KeyFile, r.opts.ClientCAFile} {
+		if name == "" {
+			continue
+		}
+		info, err := os.Stat(name)
+		if err != nil {
+			return nil, err
+		}
+		mtimes = append(mtimes, info.ModTime())
+	}
+	return mtimes, nil
+}
+
+func (r *Reloader) load() error {
+	cert, err := tls.LoadX509KeyPair(r.opts.CertFile, r.opts.KeyFile)
+	if err != nil {
+		return fmt.Errorf("tlsx: load certificate: %w", err)
+	}
+
+	config := &tls.Config{
+		MinVersion:   r.opts.MinVersion,
+		NextProtos:   r.opts.NextProtos,
+		Certificates: []tls.Certificate{cert},
+	}
+	if r.opts.ClientCAFile != "" {
+		pem, err := os.ReadFile(r.opts.ClientCAFile)
+		if err != nil {
+			return fmt.Errorf("tlsx: load client CA: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return errors.New("tlsx: no certificate found in " + r.opts.ClientCAFile)
+		}
+		config.ClientCAs = pool
+		config.ClientAuth = r.opts.ClientAuth
+	}
+
+	r.config.Store(config)
+	return nil
+}
+
+// ClientAuth parses the name of a client auth type: none, request, require,
+// verify_if_given or require_and_verify.
+func ClientAuth(name string) (tls.ClientAuthType, error) {
+	switch name {
+	case "", "none":
+		return tls.NoClientCert, nil
+	case "request":
+		return tls.RequestClientCert, nil
+	case "require":
+		return tls.RequireAnyClientCert, nil
+	case "verify_if_given":
+		return tls.VerifyClientCertIfGiven, nil
+	case "require_and_verify":
+		return tls.RequireAndVerifyClientCert, nil
+	default:
+		return tls.NoClientCert, fmt.Errorf("tlsx: unknown client auth %q", name)
+	}
+}
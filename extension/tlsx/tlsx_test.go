@@ -0,0 +1,105 @@
+package tlsx
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// writeCert writes a self-signed certificate of the common name and its key.
+func writeCert(t *testing.T, dir, name string) (certFile, keyFile string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(time.Now().UnixNano()),
+		Subject:               pkix.Name{CommonName: name},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		DNSNames:              []string{"localhost"},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	assert.NoError(t, err)
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	assert.NoError(t, err)
+
+	certFile = filepath.Join(dir, "tls.crt")
+	keyFile = filepath.Join(dir, "tls.key")
+	assert.NoError(t, os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600))
+	assert.NoError(t, os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer}), 0o600))
+	return certFile, keyFile
+}
+
+func commonName(t *testing.T, config *tls.Config) string {
+	cert, err := config.GetCertificate(&tls.ClientHelloInfo{})
+	assert.NoError(t, err)
+	leaf, err := x509.ParseCertificate(cert.Certificate[0])
+	assert.NoError(t, err)
+	return leaf.Subject.CommonName
+}
+
+func TestReloader(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile := writeCert(t, dir, "first")
+
+	_, err := NewReloader(Options{CertFile: certFile, KeyFile: filepath.Join(dir, "missing.key")})
+	assert.Error(t, err)
+
+	r, err := NewReloader(Options{CertFile: certFile, KeyFile: keyFile, NextProtos: []string{"h2", "http/1.1"}})
+	assert.NoError(t, err)
+	now := time.Now()
+	r.now = func() time.Time { return now }
+	config := r.Config()
+	assert.Equal(t, "first", commonName(t, config))
+
+	writeCert(t, dir, "second")
+	future := now.Add(time.Minute)
+	assert.NoError(t, os.Chtimes(certFile, future, future))
+	assert.Equal(t, "first", commonName(t, config), "the files are not checked before the interval")
+
+	now = now.Add(time.Minute)
+	assert.Equal(t, "second", commonName(t, config))
+	current, err := config.GetConfigForClient(&tls.ClientHelloInfo{})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"h2", "http/1.1"}, current.NextProtos)
+
+	assert.NoError(t, os.WriteFile(keyFile, []byte("invalid"), 0o600))
+	later := future.Add(time.Minute)
+	assert.NoError(t, os.Chtimes(keyFile, later, later))
+	now = now.Add(time.Minute)
+	assert.Equal(t, "second", commonName(t, config), "the previous certificate is kept")
+}
+
+func TestClientCA(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile := writeCert(t, dir, "server")
+	caFile := filepath.Join(t.TempDir(), "ca.crt")
+	ca, _ := writeCert(t, filepath.Dir(caFile), "ca")
+	assert.NoError(t, os.Rename(ca, caFile))
+
+	auth, err := ClientAuth("require_and_verify")
+	assert.NoError(t, err)
+	r, err := NewReloader(Options{CertFile: certFile, KeyFile: keyFile, ClientCAFile: caFile, ClientAuth: auth})
+	assert.NoError(t, err)
+	current, err := r.Config().GetConfigForClient(&tls.ClientHelloInfo{})
+	assert.NoError(t, err)
+	assert.Equal(t, tls.RequireAndVerifyClientCert, current.ClientAuth)
+	assert.NotNil(t, current.ClientCAs)
+
+	_, err = ClientAuth("sometimes")
+	assert.Error(t, err)
+}
@@ -0,0 +1,236 @@
+package tracing
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// SpanData is the read-only copy of an ended span handed to the exporters.
+type SpanData struct {
+	ServiceName   string
+	Name          string
+	Kind          SpanKind
+	SpanContext   SpanContext
+	ParentSpanID  SpanID
+	StartTime     time.Time
+	EndTime       time.Time
+	Attributes    map[string]any
+	StatusCode    StatusCode
+	StatusMessage string
+}
+
+// Exporter sends the ended spans to a backend.
+type Exporter interface {
+	ExportSpans(ctx context.Context, spans []SpanData) error
+	Shutdown(ctx context.Context) error
+}
+
+// BatchOptions is the options of the batching of the ended spans.
+type BatchOptions struct {
+	// QueueSize is the number of spans buffered before they are dropped.
+	QueueSize int
+
+	// BatchSize is the maximum number of spans in an export.
+	BatchSize int
+
+	// Timeout is the maximum delay before the buffered spans are exported.
+	Timeout time.Duration
+}
+
+// batchProcessor exports the ended spans in batches from a background
+// goroutine, spans are dropped instead of blocking the callers when the
+// queue is full.
+type batchProcessor struct {
+	exporter Exporter
+	opts     BatchOptions
+	onError  func(error)
+
+	mu      sync.RWMutex
+	closed  bool
+	queue   chan SpanData
+	done    chan struct{}
+	dropped atomic.Uint64
+}
+
+func newBatchProcessor(exporter Exporter, opts BatchOptions, onError func(error)) *batchProcessor {
+	if opts.QueueSize <= 0 {
+		opts.QueueSize = 2048
+	}
+	if opts.BatchSize <= 0 || opts.BatchSize > opts.QueueSize {
+		opts.BatchSize = min(512, opts.QueueSize)
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = 5 * time.Second
+	}
+
+	p := &batchProcessor{
+		exporter: exporter,
+		opts:     opts,
+		onError:  onError,
+		queue:    make(chan SpanData, opts.QueueSize),
+		done:     make(chan struct{}),
+	}
+	go p.run()
+	return p
+}
+
+func (p *batchProcessor) OnEnd(span SpanData) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	if p.closed {
+		return
+	}
+
+	select {
+	case p.queue <- span:
+	default:
+		p.dropped.Add(1)
+	}
+}
+
+func (p *batchProcessor) run() {
+	defer close(p.done)
+
+	ticker := time.NewTicker(p.opts.Timeout)
+	defer ticker.Stop()
+
+	batch := make([]SpanData, 0, p.opts.BatchSize)
+	export := func() {
+		if len(batch) == 0 {
+			return
+		}
+		if err := p.exporter.ExportSpans(context.Background(), batch); err != nil && p.onError != nil {
+			p.onError(err)
+		}
+		batch = make([]SpanData, 0, p.opts.BatchSize)
+	}
+
+	for {
+		select {
+		case span, ok := <-p.queue:
+			if !ok {
+				export()
+				return
+			}
+			batch = append(batch, span)
+			if len(batch) >= p.opts.BatchSize {
+				export()
+			}
+		case <-ticker.C:
+			export()
+		}
+	}
+}
+
+// Shutdown stops accepting spans, exports the queued ones and shuts the
+// exporter down.
+func (p *batchProcessor) Shutdown(ctx context.Context) error {
+	p.mu.Lock()
+	if !p.closed {
+		p.closed = true
+		close(p.queue)
+	}
+	p.mu.Unlock()
+
+	select {
+	case <-p.done:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	return p.exporter.Shutdown(ctx)
+}
+
+// WriterExporter writes the spans as JSON lines, for stdout or a local file.
+type WriterExporter struct {
+	mu      sync.Mutex
+	encoder *json.Encoder
+	closer  io.Closer
+}
+
+// NewWriterExporter creates an exporter writing to w, w is not closed by
+// Shutdown.
+func NewWriterExporter(w io.Writer) *WriterExporter {
+	return &WriterExporter{encoder: json.NewEncoder(w)}
+}
+
+// NewFileExporter creates an exporter appending to the file, which is closed
+// by Shutdown.
+func NewFileExporter(filename string) (*WriterExporter, error) {
+	if err := os.MkdirAll(filepath.Dir(filename), 0o755); err != nil {
+		return nil, err
+	}
+	f, err := os.OpenFile(filename, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, err
+	}
+
+	e := NewWriterExporter(f)
+	e.closer = f
+	return e, nil
+}
+
+type jsonSpan struct {
+	Service      string         `json:"service,omitempty"`
+	Name         string         `json:"name"`
+	Kind         string         `json:"kind"`
+	TraceID      string         `json:"trace_id"`
+	SpanID       string         `json:"span_id"`
+	ParentSpanID string         `json:"parent_span_id,omitempty"`
+	Start        time.Time      `json:"start"`
+	End          time.Time      `json:"end"`
+	Duration     string         `json:"duration"`
+	Attributes   map[string]any `json:"attributes,omitempty"`
+	Status       string         `json:"status"`
+	Message      string         `json:"message,omitempty"`
+}
+
+var (
+	kindNames   = map[SpanKind]string{SpanKindInternal: "internal", SpanKindServer: "server", SpanKindClient: "client", SpanKindProducer: "producer", SpanKindConsumer: "consumer"}
+	statusNames = map[StatusCode]string{StatusUnset: "unset", StatusOK: "ok", StatusError: "error"}
+)
+
+func (e *WriterExporter) ExportSpans(_ context.Context, spans []SpanData) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	for _, span := range spans {
+		v := jsonSpan{
+			Service:    span.ServiceName,
+			Name:       span.Name,
+			Kind:       kindNames[span.Kind],
+			TraceID:    span.SpanContext.TraceID.String(),
+			SpanID:     span.SpanContext.SpanID.String(),
+			Start:      span.StartTime,
+			End:        span.EndTime,
+			Duration:   span.EndTime.Sub(span.StartTime).String(),
+			Attributes: span.Attributes,
+			Status:     statusNames[span.StatusCode],
+			Message:    span.StatusMessage,
+		}
+		if span.ParentSpanID.IsValid() {
+			v.ParentSpanID = span.ParentSpanID.String()
+		}
+		if err := e.encoder.Encode(v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (e *WriterExporter) Shutdown(context.Context) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.closer == nil {
+		return nil
+	}
+	err := e.closer.Close()
+	e.closer = nil
+	return err
+}
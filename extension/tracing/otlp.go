@@ -0,0 +1,205 @@
+package tracing
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const instrumentationScope = "demo/extension/tracing"
+
+// OTLPOptions is the options of the OTLPExporter.
+type OTLPOptions struct {
+	// Endpoint is the traces URL of the collector, for example
+	// http://localhost:4318/v1/traces.
+	Endpoint string
+
+	// Headers are sent with every export, for example the credentials.
+	Headers map[string]string
+
+	// Timeout of an export, defaults to 10s.
+	Timeout time.Duration
+
+	// Client overrides the http client, mostly for tests.
+	Client *http.Client
+}
+
+// OTLPExporter exports the spans to an OpenTelemetry collector with the
+// OTLP/HTTP protocol in the JSON encoding.
+type OTLPExporter struct {
+	opts   OTLPOptions
+	client *http.Client
+}
+
+func NewOTLPExporter(opts OTLPOptions) (*OTLPExporter, error) {
+	if opts.Endpoint == "" {
+		return nil, fmt.Errorf("tracing: otlp endpoint is required")
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = 10 * time.Second
+	}
+
+	client := opts.Client
+	if client == nil {
+		client = &http.Client{}
+	}
+	return &OTLPExporter{opts: opts, client: client}, nil
+}
+
+func (e *OTLPExporter) ExportSpans(ctx context.Context, spans []SpanData) error {
+	body, err := json.Marshal(otlpRequest(spans))
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, e.opts.Timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, e.opts.Endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range e.opts.Headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("tracing: export spans: %w", err)
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("tracing: export spans: unexpected status %s", resp.Status)
+	}
+	return nil
+}
+
+func (e *OTLPExporter) Shutdown(context.Context) error {
+	e.client.CloseIdleConnections()
+	return nil
+}
+
+// The types below are the subset of the OTLP JSON encoding used by the
+// exporter, ids are hex encoded and 64 bit integers are strings.
+
+type otlpKeyValue struct {
+	Key   string       `json:"key"`
+	Value otlpAnyValue `json:"value"`
+}
+
+type otlpAnyValue struct {
+	StringValue *string  `json:"stringValue,omitempty"`
+	BoolValue   *bool    `json:"boolValue,omitempty"`
+	IntValue    *string  `json:"intValue,omitempty"`
+	DoubleValue *float64 `json:"doubleValue,omitempty"`
+}
+
+type otlpSpan struct {
+	TraceID           string         `json:"traceId"`
+	SpanID            string         `json:"spanId"`
+	TraceState        string         `json:"traceState,omitempty"`
+	ParentSpanID      string         `json:"parentSpanId,omitempty"`
+	Name              string         `json:"name"`
+	Kind              SpanKind       `json:"kind"`
+	StartTimeUnixNano string         `json:"startTimeUnixNano"`
+	EndTimeUnixNano   string         `json:"endTimeUnixNano"`
+	Attributes        []otlpKeyValue `json:"attributes,omitempty"`
+	Status            otlpStatus     `json:"status"`
+}
+
+type otlpStatus struct {
+	Code    StatusCode `json:"code,omitempty"`
+	Message string     `json:"message,omitempty"`
+}
+
+type otlpScopeSpans struct {
+	Scope struct {
+		Name string `json:"name"`
+	} `json:"scope"`
+	Spans []otlpSpan `json:"spans"`
+}
+
+type otlpResourceSpans struct {
+	Resource struct {
+		Attributes []otlpKeyValue `json:"attributes"`
+	} `json:"resource"`
+	ScopeSpans []otlpScopeSpans `json:"scopeSpans"`
+}
+
+type otlpExportRequest struct {
+	ResourceSpans []otlpResourceSpans `json:"resourceSpans"`
+}
+
+func otlpRequest(spans []SpanData) otlpExportRequest {
+	var (
+		req      otlpExportRequest
+		services = make(map[string]int)
+	)
+
+	for _, span := range spans {
+		i, ok := services[span.ServiceName]
+		if !ok {
+			rs := otlpResourceSpans{ScopeSpans: make([]otlpScopeSpans, 1)}
+			rs.Resource.Attributes = []otlpKeyValue{otlpAttribute("service.name", span.ServiceName)}
+			rs.ScopeSpans[0].Scope.Name = instrumentationScope
+
+			i = len(req.ResourceSpans)
+			services[span.ServiceName] = i
+			req.ResourceSpans = append(req.ResourceSpans, rs)
+		}
+
+		s := otlpSpan{
+			TraceID:           span.SpanContext.TraceID.String(),
+			SpanID:            span.SpanContext.SpanID.String(),
+			TraceState:        span.SpanContext.Tracestate,
+			Name:              span.Name,
+			Kind:              span.Kind,
+			StartTimeUnixNano: strconv.FormatInt(span.StartTime.UnixNano(), 10),
+			EndTimeUnixNano:   strconv.FormatInt(span.EndTime.UnixNano(), 10),
+			Status:            otlpStatus{Code: span.StatusCode, Message: span.StatusMessage},
+		}
+		if span.ParentSpanID.IsValid() {
+			s.ParentSpanID = span.ParentSpanID.String()
+		}
+		for k, v := range span.Attributes {
+			s.Attributes = append(s.Attributes, otlpAttribute(k, v))
+		}
+
+		scope := &req.ResourceSpans[i].ScopeSpans[0]
+		scope.Spans = append(scope.Spans, s)
+	}
+	return req
+}
+
+func otlpAttribute(key string, value any) otlpKeyValue {
+	kv := otlpKeyValue{Key: key}
+	switch v := value.(type) {
+	case string:
+		kv.Value.StringValue = &v
+	case bool:
+		kv.Value.BoolValue = &v
+	case int:
+		s := strconv.FormatInt(int64(v), 10)
+		kv.Value.IntValue = &s
+	case int64:
+		s := strconv.FormatInt(v, 10)
+		kv.Value.IntValue = &s
+	case uint64:
+		s := strconv.FormatUint(v, 10)
+		kv.Value.IntValue = &s
+	case float64:
+		kv.Value.DoubleValue = &v
+	default:
+		s := fmt.Sprint(v)
+		kv.Value.StringValue = &s
+	}
+	return kv
+}
@@ -0,0 +1,29 @@
+package tracing
+
+import (
+	"context"
+	"net/http"
+)
+
+// Inject sets the traceparent and tracestate headers from the span of ctx.
+func Inject(ctx context.Context, header http.Header) {
+	sc := SpanFromContext(ctx).SpanContext()
+	if !sc.IsValid() {
+		return
+	}
+	header.Set(TraceparentHeader, sc.Traceparent())
+	if sc.Tracestate != "" {
+		header.Set(TracestateHeader, sc.Tracestate)
+	}
+}
+
+// Extract returns a copy of ctx carrying the remote span context of the
+// headers, ctx is returned as is when there is none or it is invalid.
+func Extract(ctx context.Context, header http.Header) context.Context {
+	sc, err := ParseTraceparent(header.Get(TraceparentHeader))
+	if err != nil {
+		return ctx
+	}
+	sc.Tracestate = header.Get(TracestateHeader)
+	return ContextWithRemoteSpanContext(ctx, sc)
+}
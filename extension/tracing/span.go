@@ -0,0 +1,163 @@
+package tracing
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// SpanKind is the role of a span in a trace.
+type SpanKind int
+
+const (
+	SpanKindInternal SpanKind = iota + 1
+	SpanKindServer
+	SpanKindClient
+	SpanKindProducer
+	SpanKindConsumer
+)
+
+// StatusCode is the status of a span.
+type StatusCode int
+
+const (
+	StatusUnset StatusCode = iota
+	StatusOK
+	StatusError
+)
+
+// Span is an operation of a trace. A nil Span is valid and does nothing, the
+// spans which are not sampled are not recorded but still propagated.
+type Span struct {
+	tracer *Tracer
+
+	mu            sync.Mutex
+	name          string
+	kind          SpanKind
+	spanContext   SpanContext
+	parentSpanID  SpanID
+	start         time.Time
+	end           time.Time
+	attributes    map[string]any
+	statusCode    StatusCode
+	statusMessage string
+	ended         bool
+}
+
+// SpanContext returns the span context, the zero value for a nil span.
+func (s *Span) SpanContext() SpanContext {
+	if s == nil {
+		return SpanContext{}
+	}
+	return s.spanContext
+}
+
+// IsRecording reports whether the span will be exported when it ends.
+func (s *Span) IsRecording() bool {
+	return s != nil && s.spanContext.IsSampled() && s.tracer != nil && s.tracer.processor != nil
+}
+
+// SetName renames the span.
+func (s *Span) SetName(name string) {
+	if !s.IsRecording() {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.name = name
+}
+
+// SetAttribute sets an attribute, values should be strings, integers, floats
+// or booleans, others are exported as strings.
+func (s *Span) SetAttribute(key string, value any) {
+	if !s.IsRecording() {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.attributes == nil {
+		s.attributes = make(map[string]any)
+	}
+	s.attributes[key] = value
+}
+
+// SetStatus sets the status of the span.
+func (s *Span) SetStatus(code StatusCode, message string) {
+	if !s.IsRecording() {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.statusCode, s.statusMessage = code, message
+}
+
+// RecordError marks the span as failed with the error, a nil error is ignored.
+func (s *Span) RecordError(err error) {
+	if err == nil {
+		return
+	}
+	s.SetAttribute("exception.message", err.Error())
+	s.SetStatus(StatusError, err.Error())
+}
+
+// End ends the span and hands it over to the exporter, only the first call
+// has effect.
+func (s *Span) End() {
+	if !s.IsRecording() {
+		return
+	}
+
+	s.mu.Lock()
+	if s.ended {
+		s.mu.Unlock()
+		return
+	}
+	s.ended = true
+	s.end = time.Now()
+	data := s.snapshot()
+	s.mu.Unlock()
+
+	s.tracer.processor.OnEnd(data)
+}
+
+func (s *Span) snapshot() SpanData {
+	attributes := make(map[string]any, len(s.attributes))
+	for k, v := range s.attributes {
+		attributes[k] = v
+	}
+
+	return SpanData{
+		ServiceName:   s.tracer.serviceName,
+		Name:          s.name,
+		Kind:          s.kind,
+		SpanContext:   s.spanContext,
+		ParentSpanID:  s.parentSpanID,
+		StartTime:     s.start,
+		EndTime:       s.end,
+		Attributes:    attributes,
+		StatusCode:    s.statusCode,
+		StatusMessage: s.statusMessage,
+	}
+}
+
+type spanContextKey struct{}
+
+// ContextWithSpan returns a copy of ctx carrying the span.
+func ContextWithSpan(ctx context.Context, span *Span) context.Context {
+	return context.WithValue(ctx, spanContextKey{}, span)
+}
+
+// SpanFromContext returns the span of the context, or nil.
+func SpanFromContext(ctx context.Context) *Span {
+	if ctx == nil {
+		return nil
+	}
+	span, _ := ctx.Value(spanContextKey{}).(*Span)
+	return span
+}
+
+// ContextWithRemoteSpanContext returns a copy of ctx carrying a span context
+// received from another process, it becomes the parent of the next span.
+func ContextWithRemoteSpanContext(ctx context.Context, sc SpanContext) context.Context {
+	return ContextWithSpan(ctx, &Span{spanContext: sc})
+}
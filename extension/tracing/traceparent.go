@@ -0,0 +1,114 @@
+package tracing
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+const (
+	// TraceparentHeader is the W3C trace context header.
+	TraceparentHeader = "traceparent"
+
+	// TracestateHeader is the W3C vendor specific trace state header.
+	TracestateHeader = "tracestate"
+
+	flagSampled byte = 0x01
+)
+
+var ErrInvalidTraceparent = errors.New("tracing: invalid traceparent")
+
+// TraceID is the W3C trace id.
+type TraceID [16]byte
+
+func (t TraceID) IsValid() bool { return t != TraceID{} }
+
+func (t TraceID) String() string { return hex.EncodeToString(t[:]) }
+
+// SpanID is the W3C parent id.
+type SpanID [8]byte
+
+func (s SpanID) IsValid() bool { return s != SpanID{} }
+
+func (s SpanID) String() string { return hex.EncodeToString(s[:]) }
+
+func newTraceID() TraceID {
+	var id TraceID
+	for !id.IsValid() {
+		_, _ = rand.Read(id[:])
+	}
+	return id
+}
+
+func newSpanID() SpanID {
+	var id SpanID
+	for !id.IsValid() {
+		_, _ = rand.Read(id[:])
+	}
+	return id
+}
+
+// SpanContext is the part of a span which is propagated across processes.
+type SpanContext struct {
+	TraceID    TraceID
+	SpanID     SpanID
+	Flags      byte
+	Tracestate string
+	Remote     bool
+}
+
+func (sc SpanContext) IsValid() bool {
+	return sc.TraceID.IsValid() && sc.SpanID.IsValid()
+}
+
+func (sc SpanContext) IsSampled() bool {
+	return sc.Flags&flagSampled != 0
+}
+
+// Traceparent formats the span context as a version 00 traceparent header.
+func (sc SpanContext) Traceparent() string {
+	return fmt.Sprintf("00-%s-%s-%02x", sc.TraceID, sc.SpanID, sc.Flags)
+}
+
+// ParseTraceparent parses a traceparent header. Versions other than 00 are
+// accepted as long as the 00 fields can be parsed, as required by the spec.
+func ParseTraceparent(value string) (SpanContext, error) {
+	var sc SpanContext
+
+	parts := strings.Split(strings.TrimSpace(value), "-")
+	if len(parts) < 4 {
+		return sc, ErrInvalidTraceparent
+	}
+	version, traceID, spanID, flags := parts[0], parts[1], parts[2], parts[3]
+	if len(version) != 2 || version == "ff" || (version == "00" && len(parts) != 4) {
+		return sc, ErrInvalidTraceparent
+	}
+	if len(traceID) != 32 || len(spanID) != 16 || len(flags) != 2 {
+		return sc, ErrInvalidTraceparent
+	}
+	for _, field := range []string{version, traceID, spanID, flags} {
+		if strings.ToLower(field) != field {
+			return sc, ErrInvalidTraceparent
+		}
+	}
+
+	if _, err := hex.Decode(sc.TraceID[:], []byte(traceID)); err != nil {
+		return sc, ErrInvalidTraceparent
+	}
+	if _, err := hex.Decode(sc.SpanID[:], []byte(spanID)); err != nil {
+		return sc, ErrInvalidTraceparent
+	}
+	var f [1]byte
+	if _, err := hex.Decode(f[:], []byte(flags)); err != nil {
+		return sc, ErrInvalidTraceparent
+	}
+	sc.Flags = f[0]
+
+	if !sc.IsValid() {
+		return SpanContext{}, ErrInvalidTraceparent
+	}
+	sc.Remote = true
+	return sc, nil
+}
@@ -0,0 +1,151 @@
+package tracing
+
+import (
+	"context"
+	"encoding/binary"
+	"math"
+	"sync/atomic"
+	"time"
+)
+
+// Options is the options of the Tracer.
+type Options struct {
+	// ServiceName is exported as the service.name resource attribute.
+	ServiceName string
+
+	// SampleRatio is the ratio of the root spans to record, between 0 and 1.
+	// Spans with a parent follow the sampling decision of the parent.
+	SampleRatio float64
+
+	// Exporter receives the ended spans, nothing is recorded when it is nil.
+	Exporter Exporter
+
+	// Batch configures how the ended spans are handed over to the exporter.
+	Batch BatchOptions
+
+	// ErrorHandler is called with the export errors, they are dropped when it
+	// is nil.
+	ErrorHandler func(error)
+}
+
+// Tracer creates spans. Spans are created even when they are not sampled so
+// that the trace context is still propagated.
+type Tracer struct {
+	serviceName string
+	threshold   uint64
+	processor   *batchProcessor
+}
+
+var defaultTracer atomic.Pointer[Tracer]
+
+func init() {
+	defaultTracer.Store(NewTracer(Options{}))
+}
+
+// NewTracer creates a tracer, it should be shut down to flush the spans.
+func NewTracer(opts Options) *Tracer {
+	t := &Tracer{serviceName: opts.ServiceName}
+
+	switch {
+	case opts.SampleRatio >= 1:
+		t.threshold = math.MaxUint64
+	case opts.SampleRatio > 0:
+		t.threshold = uint64(opts.SampleRatio * math.MaxUint64)
+	}
+	if opts.Exporter != nil {
+		t.processor = newBatchProcessor(opts.Exporter, opts.Batch, opts.ErrorHandler)
+	}
+	return t
+}
+
+// Default returns the tracer used by Start.
+func Default() *Tracer {
+	return defaultTracer.Load()
+}
+
+// SetDefault replaces the tracer used by Start.
+func SetDefault(t *Tracer) {
+	defaultTracer.Store(t)
+}
+
+// Start starts a span with the default tracer.
+func Start(ctx context.Context, name string, opts ...StartOption) (context.Context, *Span) {
+	return Default().Start(ctx, name, opts...)
+}
+
+// StartOption configures a span to start.
+type StartOption func(*Span)
+
+// WithSpanKind sets the kind of the span, defaults to SpanKindInternal.
+func WithSpanKind(kind SpanKind) StartOption {
+	return func(s *Span) {
+		s.kind = kind
+	}
+}
+
+// WithAttributes sets the attributes of the span.
+func WithAttributes(attributes map[string]any) StartOption {
+	return func(s *Span) {
+		for k, v := range attributes {
+			s.attributes[k] = v
+		}
+	}
+}
+
+// Start starts a span as the child of the span of ctx, the returned context
+// carries the new span. The span must be ended by the caller.
+func (t *Tracer) Start(ctx context.Context, name string, opts ...StartOption) (context.Context, *Span) {
+	span := &Span{
+		tracer:     t,
+		name:       name,
+		kind:       SpanKindInternal,
+		start:      time.Now(),
+		attributes: make(map[string]any),
+	}
+
+	parent := SpanFromContext(ctx).SpanContext()
+	if parent.IsValid() {
+		span.parentSpanID = parent.SpanID
+		span.spanContext = SpanContext{
+			TraceID:    parent.TraceID,
+			Flags:      parent.Flags,
+			Tracestate: parent.Tracestate,
+		}
+	} else {
+		span.spanContext.TraceID = newTraceID()
+		if t.sample(span.spanContext.TraceID) {
+			span.spanContext.Flags = flagSampled
+		}
+	}
+	span.spanContext.SpanID = newSpanID()
+
+	for _, opt := range opts {
+		opt(span)
+	}
+	return ContextWithSpan(ctx, span), span
+}
+
+// sample decides by the random part of the trace id, so that all the services
+// with the same ratio make the same decision.
+func (t *Tracer) sample(id TraceID) bool {
+	if t.threshold == 0 {
+		return false
+	}
+	return binary.BigEndian.Uint64(id[8:]) <= t.threshold
+}
+
+// Shutdown exports the pending spans and shuts the exporter down.
+func (t *Tracer) Shutdown(ctx context.Context) error {
+	if t.processor == nil {
+		return nil
+	}
+	return t.processor.Shutdown(ctx)
+}
+
+// Dropped returns the number of spans dropped because the queue was full.
+func (t *Tracer) Dropped() uint64 {
+	if t.processor == nil {
+		return 0
+	}
+	return t.processor.dropped.Load()
+}
@@ -0,0 +1,182 @@
+package tracing
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseTraceparent(t *testing.T) {
+	value := "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+	sc, err := ParseTraceparent(value)
+	assert.NoError(t, err)
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", sc.TraceID.String())
+	assert.Equal(t, "00f067aa0ba902b7", sc.SpanID.String())
+	assert.True(t, sc.IsSampled())
+	assert.True(t, sc.Remote)
+	assert.Equal(t, value, sc.Traceparent())
+
+	_, err = ParseTraceparent("01-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00-future")
+	assert.NoError(t, err)
+
+	for _, invalid := range []string{
+		"",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7",
+		"00-00000000000000000000000000000000-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01",
+		"00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01",
+		"ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra",
+		"00-xyz92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+	} {
+		_, err = ParseTraceparent(invalid)
+		assert.ErrorIs(t, err, ErrInvalidTraceparent, invalid)
+	}
+}
+
+func TestPropagation(t *testing.T) {
+	tracer := NewTracer(Options{SampleRatio: 1})
+
+	header := http.Header{}
+	header.Set(TraceparentHeader, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00")
+	header.Set(TracestateHeader, "vendor=1")
+	ctx := Extract(context.Background(), header)
+
+	ctx, span := tracer.Start(ctx, "child")
+	sc := span.SpanContext()
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", sc.TraceID.String())
+	assert.False(t, sc.IsSampled(), "the sampling decision of the parent is kept")
+	assert.False(t, span.IsRecording())
+
+	out := http.Header{}
+	Inject(ctx, out)
+	assert.Equal(t, sc.Traceparent(), out.Get(TraceparentHeader))
+	assert.Equal(t, "vendor=1", out.Get(TracestateHeader))
+
+	assert.Equal(t, context.Background(), Extract(context.Background(), http.Header{}))
+}
+
+func TestSampleRatio(t *testing.T) {
+	never := NewTracer(Options{SampleRatio: 0})
+	always := NewTracer(Options{SampleRatio: 1})
+	for i := 0; i < 100; i++ {
+		_, span := never.Start(context.Background(), "root")
+		assert.False(t, span.SpanContext().IsSampled())
+		_, span = always.Start(context.Background(), "root")
+		assert.True(t, span.SpanContext().IsSampled())
+	}
+}
+
+func TestNilSpan(t *testing.T) {
+	var span *Span
+	span.SetAttribute("key", "value")
+	span.RecordError(errors.New("failed"))
+	span.End()
+	assert.False(t, span.SpanContext().IsValid())
+	assert.Nil(t, SpanFromContext(context.Background()))
+}
+
+func TestWriterExporter(t *testing.T) {
+	var buf bytes.Buffer
+	tracer := NewTracer(Options{ServiceName: "demo", SampleRatio: 1, Exporter: NewWriterExporter(&buf)})
+
+	ctx, parent := tracer.Start(context.Background(), "parent", WithSpanKind(SpanKindServer))
+	_, child := tracer.Start(ctx, "child", WithAttributes(map[string]any{"key": "value"}))
+	child.RecordError(errors.New("failed"))
+	child.End()
+	child.End()
+	parent.End()
+	assert.NoError(t, tracer.Shutdown(context.Background()))
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	assert.Len(t, lines, 2)
+
+	var span map[string]any
+	assert.NoError(t, json.Unmarshal([]byte(lines[0]), &span))
+	assert.Equal(t, "child", span["name"])
+	assert.Equal(t, "demo", span["service"])
+	assert.Equal(t, "internal", span["kind"])
+	assert.Equal(t, "error", span["status"])
+	assert.Equal(t, parent.SpanContext().SpanID.String(), span["parent_span_id"])
+	assert.Equal(t, parent.SpanContext().TraceID.String(), span["trace_id"])
+	assert.Equal(t, "value", span["attributes"].(map[string]any)["key"])
+
+	// spans ended after the shutdown are dropped
+	_, span2 := tracer.Start(context.Background(), "late")
+	span2.End()
+}
+
+func TestOTLPExporter(t *testing.T) {
+	var (
+		mu       sync.Mutex
+		requests []otlpExportRequest
+	)
+	collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v1/traces", r.URL.Path)
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		assert.Equal(t, "secret", r.Header.Get("Authorization"))
+
+		body, _ := io.ReadAll(r.Body)
+		var req otlpExportRequest
+		assert.NoError(t, json.Unmarshal(body, &req))
+		mu.Lock()
+		requests = append(requests, req)
+		mu.Unlock()
+	}))
+	defer collector.Close()
+
+	exporter, err := NewOTLPExporter(OTLPOptions{
+		Endpoint: collector.URL + "/v1/traces",
+		Headers:  map[string]string{"Authorization": "secret"},
+	})
+	assert.NoError(t, err)
+	tracer := NewTracer(Options{ServiceName: "demo", SampleRatio: 1, Exporter: exporter, Batch: BatchOptions{BatchSize: 2}})
+
+	ctx, parent := tracer.Start(context.Background(), "GET /hello", WithSpanKind(SpanKindServer))
+	parent.SetAttribute("http.status_code", 200)
+	_, child := tracer.Start(ctx, "appservice.Hello")
+	child.End()
+	parent.End()
+	_, other := tracer.Start(context.Background(), "other")
+	other.End()
+	assert.NoError(t, tracer.Shutdown(context.Background()))
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Len(t, requests, 2, "a full batch and the remaining span at shutdown")
+
+	rs := requests[0].ResourceSpans[0]
+	assert.Equal(t, "service.name", rs.Resource.Attributes[0].Key)
+	assert.Equal(t, "demo", *rs.Resource.Attributes[0].Value.StringValue)
+
+	spans := rs.ScopeSpans[0].Spans
+	assert.Len(t, spans, 2)
+	assert.Equal(t, "appservice.Hello", spans[0].Name)
+	assert.Equal(t, spans[1].SpanID, spans[0].ParentSpanID)
+	assert.Equal(t, spans[1].TraceID, spans[0].TraceID)
+	assert.Equal(t, SpanKindServer, spans[1].Kind)
+	assert.Equal(t, "200", *spans[1].Attributes[0].Value.IntValue)
+}
+
+func TestOTLPExporterError(t *testing.T) {
+	collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer collector.Close()
+
+	exporter, err := NewOTLPExporter(OTLPOptions{Endpoint: collector.URL})
+	assert.NoError(t, err)
+	assert.Error(t, exporter.ExportSpans(context.Background(), []SpanData{{Name: "span"}}))
+
+	_, err = NewOTLPExporter(OTLPOptions{})
+	assert.Error(t, err)
+}
@@ -0,0 +1,110 @@
+// Package worker runs the background workers, such as the cron jobs and the
+// subscribers, and stops them with a deadline at shutdown.
+package worker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime/debug"
+	"slices"
+	"sync"
+
+	"demo/extension/logz"
+)
+
+// ErrStopped is returned by Go once the group is stopped.
+var ErrStopped = errors.New("worker: group is stopped")
+
+// Group is a group of the background workers.
+type Group struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+
+	mu      sync.Mutex
+	stopped bool
+	running map[string]int
+}
+
+func NewGroup() *Group {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Group{ctx: ctx, cancel: cancel, running: make(map[string]int)}
+}
+
+// Go runs fn in a goroutine, its context is canceled when the group is
+// stopped. The errors other than context.Canceled and the panics are logged.
+func (g *Group) Go(name string, fn func(ctx context.Context) error) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.stopped {
+		return ErrStopped
+	}
+	g.running[name]++
+	g.wg.Add(1)
+
+	go func() {
+		defer g.wg.Done()
+		defer g.finish(name)
+		defer func() {
+			if r := recover(); r != nil {
+				logz.Error(g.ctx, "[worker] worker panicked",
+					logz.String("worker", name),
+					logz.Any("panic", r),
+					logz.String("stack", string(debug.Stack())),
+				)
+			}
+		}()
+
+		if err := fn(g.ctx); err != nil && !errors.Is(err, context.Canceled) {
+			logz.Error(g.ctx, "[worker] worker failed", logz.String("worker", name), logz.Err(err))
+		}
+	}()
+	return nil
+}
+
+func (g *Group) finish(name string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.running[name]--; g.running[name] <= 0 {
+		delete(g.running, name)
+	}
+}
+
+// Running returns the names of the running workers.
+func (g *Group) Running() []string {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	names := make([]string, 0, len(g.running))
+	for name, n := range g.running {
+		for i := 0; i < n; i++ {
+			names = append(names, name)
+		}
+	}
+	slices.Sort(names)
+	return names
+}
+
+// Stop cancels the workers and waits for them until ctx is done, the workers
+// which did not return in time are logged and abandoned.
+func (g *Group) Stop(ctx context.Context) error {
+	g.mu.Lock()
+	g.stopped = true
+	g.mu.Unlock()
+	g.cancel()
+
+	done := make(chan struct{})
+	go func() {
+		g.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		running := g.Running()
+		logz.Warn(ctx, "[worker] workers did not stop in time and were abandoned", logz.Any("workers", running))
+		return fmt.Errorf("worker: %d workers did not stop in time: %w", len(running), ctx.Err())
+	}
+}
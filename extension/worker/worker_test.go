@@ -0,0 +1,56 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGroup(t *testing.T) {
+	g := NewGroup()
+
+	started := make(chan struct{}, 3)
+	assert.NoError(t, g.Go("subscriber", func(ctx context.Context) error {
+		started <- struct{}{}
+		<-ctx.Done()
+		return ctx.Err()
+	}))
+	assert.NoError(t, g.Go("failing", func(context.Context) error {
+		started <- struct{}{}
+		return errors.New("boom")
+	}))
+	assert.NoError(t, g.Go("panicking", func(context.Context) error {
+		started <- struct{}{}
+		panic("boom")
+	}))
+	for i := 0; i < 3; i++ {
+		<-started
+	}
+	assert.Eventually(t, func() bool {
+		return len(g.Running()) == 1
+	}, time.Second, time.Millisecond)
+	assert.Equal(t, []string{"subscriber"}, g.Running())
+
+	assert.NoError(t, g.Stop(context.Background()))
+	assert.Empty(t, g.Running())
+	assert.ErrorIs(t, g.Go("late", func(context.Context) error { return nil }), ErrStopped)
+}
+
+func TestGroupStopDeadline(t *testing.T) {
+	g := NewGroup()
+	release := make(chan struct{})
+	defer close(release)
+	assert.NoError(t, g.Go("cron", func(context.Context) error {
+		<-release
+		return nil
+	}))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	err := g.Stop(ctx)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Equal(t, []string{"cron"}, g.Running())
+}
@@ -0,0 +1,19 @@
+package appservice
+
+import (
+	"context"
+
+	"demo/extension/tracing"
+)
+
+// Trace runs an application service call in a span named after the service
+// and the method, for example "order.Create". The error returned by fn marks
+// the span as failed.
+func Trace(ctx context.Context, name string, fn func(ctx context.Context) error) error {
+	ctx, span := tracing.Start(ctx, name)
+	defer span.End()
+
+	err := fn(ctx)
+	span.RecordError(err)
+	return err
+}
@@ -0,0 +1,68 @@
+package appservice
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"demo/extension/tracing"
+)
+
+// recordingExporter keeps the exported spans.
+type recordingExporter struct {
+	mu    sync.Mutex
+	spans []tracing.SpanData
+}
+
+func (e *recordingExporter) ExportSpans(_ context.Context, spans []tracing.SpanData) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.spans = append(e.spans, spans...)
+	return nil
+}
+
+func (e *recordingExporter) Shutdown(context.Context) error {
+	return nil
+}
+
+func TestTrace(t *testing.T) {
+	exporter := &recordingExporter{}
+	tracer := tracing.NewTracer(tracing.Options{ServiceName: "demo", SampleRatio: 1, Exporter: exporter})
+	previous := tracing.Default()
+	tracing.SetDefault(tracer)
+	t.Cleanup(func() { tracing.SetDefault(previous) })
+
+	ctx, parent := tracer.Start(context.Background(), "GET /orders", tracing.WithSpanKind(tracing.SpanKindServer))
+	var child tracing.SpanContext
+	err := Trace(ctx, "order.Create", func(ctx context.Context) error {
+		child = tracing.SpanFromContext(ctx).SpanContext()
+		return nil
+	})
+	assert.NoError(t, err)
+
+	failure := errors.New("out of stock")
+	err = Trace(ctx, "order.Reserve", func(context.Context) error { return failure })
+	assert.ErrorIs(t, err, failure, "the error of the call is returned")
+	parent.End()
+	assert.NoError(t, tracer.Shutdown(context.Background()))
+
+	spans := make(map[string]tracing.SpanData)
+	for _, s := range exporter.spans {
+		spans[s.Name] = s
+	}
+	assert.Len(t, spans, 3)
+
+	create := spans["order.Create"]
+	assert.Equal(t, child, create.SpanContext, "the call runs in the context of its span")
+	assert.Equal(t, parent.SpanContext().SpanID, create.ParentSpanID)
+	assert.Equal(t, parent.SpanContext().TraceID, create.SpanContext.TraceID)
+	assert.Equal(t, tracing.StatusUnset, create.StatusCode)
+
+	reserve := spans["order.Reserve"]
+	assert.Equal(t, parent.SpanContext().SpanID, reserve.ParentSpanID)
+	assert.Equal(t, tracing.StatusError, reserve.StatusCode)
+	assert.Equal(t, "out of stock", reserve.StatusMessage)
+}
@@ -0,0 +1,12 @@
+package remote
+
+import (
+	"demo/config"
+	"demo/extension/authz"
+)
+
+// newAuthorizer grants the permissions of the roles in the config, it is set as
+// the default authorizer used by the route middlewares and the app services.
+func newAuthorizer(conf *config.Schema) *authz.Authorizer {
+	return authz.NewAuthorizer(authz.NewStaticRoles(conf.Authz.Roles))
+}
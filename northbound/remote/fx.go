@@ -3,11 +3,30 @@ package remote
 import (
 	"go.uber.org/fx"
 
+	"demo/extension/authz"
+	"demo/extension/metrics"
 	"demo/northbound/remote/restful"
 	"demo/southbound/adapter/configloader"
 )
 
 var Module = fx.Module("remote",
 	fx.Provide(configloader.FromYaml),
+	fx.Provide(metrics.NewRegistry),
+	fx.Provide(newCursorCodec),
+	fx.Provide(newPaginationOptions),
+	fx.Provide(newOAuth2StateCodec),
+	fx.Provide(newOAuth2Providers),
+	fx.Provide(newJWTAuthenticator),
+	fx.Provide(newAuthorizer),
+	fx.Invoke(authz.SetDefault),
+	fx.Provide(newKV),
+	fx.Provide(newSessionManager),
+	fx.Provide(newCaptcha),
+	fx.Provide(newAccounts),
+	fx.Provide(newHealth),
+	loggerModule,
+	tracingModule,
+	metricsModule,
+	workerModule,
 	restful.Module,
 )
@@ -0,0 +1,31 @@
+package grpc
+
+import (
+	"context"
+	"time"
+
+	"google.golang.org/grpc"
+
+	"demo/extension/metrics"
+)
+
+// UnaryMetricsInterceptor observes the code, outcome and duration of every
+// call.
+func UnaryMetricsInterceptor(m *metrics.GRPCMetrics) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		start := time.Now()
+		resp, err := handler(ctx, req)
+		m.Observe(info.FullMethod, err, time.Since(start))
+		return resp, err
+	}
+}
+
+// StreamMetricsInterceptor is the stream version of UnaryMetricsInterceptor.
+func StreamMetricsInterceptor(m *metrics.GRPCMetrics) grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		start := time.Now()
+		err := handler(srv, ss)
+		m.Observe(info.FullMethod, err, time.Since(start))
+		return err
+	}
+}
@@ -0,0 +1,50 @@
+package grpc
+
+import (
+	"context"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+
+	"demo/extension/contextz"
+	"demo/extension/propagation"
+)
+
+// UnaryRequestIdInterceptor accepts the request id of the incoming metadata
+// or generates one, stores it in the context and returns it in the header.
+func UnaryRequestIdInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		ctx = withRequestId(ctx)
+		return handler(ctx, req)
+	}
+}
+
+// StreamRequestIdInterceptor is the stream version of UnaryRequestIdInterceptor.
+func StreamRequestIdInterceptor() grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		return handler(srv, &serverStream{ServerStream: ss, ctx: withRequestId(ss.Context())})
+	}
+}
+
+func withRequestId(ctx context.Context) context.Context {
+	var requestId string
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if values := md.Get(propagation.RequestIdMetadataKey); len(values) > 0 {
+			requestId = values[0]
+		}
+	}
+	requestId = propagation.RequestIdOrNew(requestId)
+
+	_ = grpc.SetHeader(ctx, metadata.Pairs(propagation.RequestIdMetadataKey, requestId))
+	return contextz.WithRequestId(ctx, requestId)
+}
+
+// serverStream overrides the context of a grpc.ServerStream.
+type serverStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *serverStream) Context() context.Context {
+	return s.ctx
+}
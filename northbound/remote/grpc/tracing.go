@@ -0,0 +1,53 @@
+package grpc
+
+import (
+	"context"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+
+	"demo/extension/tracing"
+)
+
+// UnaryTracingInterceptor continues the trace of the incoming traceparent
+// metadata with a server span for every call.
+func UnaryTracingInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		ctx, span := startServerSpan(ctx, info.FullMethod)
+		defer span.End()
+
+		resp, err := handler(ctx, req)
+		span.RecordError(err)
+		return resp, err
+	}
+}
+
+// StreamTracingInterceptor is the stream version of UnaryTracingInterceptor.
+func StreamTracingInterceptor() grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx, span := startServerSpan(ss.Context(), info.FullMethod)
+		defer span.End()
+
+		err := handler(srv, &serverStream{ServerStream: ss, ctx: ctx})
+		span.RecordError(err)
+		return err
+	}
+}
+
+func startServerSpan(ctx context.Context, method string) (context.Context, *tracing.Span) {
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if values := md.Get(tracing.TraceparentHeader); len(values) > 0 {
+			if sc, err := tracing.ParseTraceparent(values[0]); err == nil {
+				if state := md.Get(tracing.TracestateHeader); len(state) > 0 {
+					sc.Tracestate = state[0]
+				}
+				ctx = tracing.ContextWithRemoteSpanContext(ctx, sc)
+			}
+		}
+	}
+
+	ctx, span := tracing.Start(ctx, method, tracing.WithSpanKind(tracing.SpanKindServer))
+	span.SetAttribute("rpc.system", "grpc")
+	span.SetAttribute("rpc.method", method)
+	return ctx, span
+}
@@ -0,0 +1,46 @@
+package remote
+
+import (
+	"demo/config"
+	"demo/extension/buildinfo"
+	"demo/extension/health"
+	"demo/extension/kv"
+)
+
+// newHealth returns the health checks of the components, the other modules
+// may register their own checks.
+func newHealth(conf *config.Schema, store kv.KV) *health.Health {
+	releaseId := buildinfo.Version
+	if buildinfo.Commit != "" {
+		releaseId += "+" + buildinfo.Commit
+	}
+	h := health.New(health.Options{
+		ServiceId:   conf.Name,
+		Description: "health of " + conf.Name,
+		Version:     buildinfo.Major(),
+		ReleaseId:   releaseId,
+		Timeout:     conf.Health.Timeout,
+	})
+
+	h.Register(health.Check{
+		Name:          "kv:responseTime",
+		ComponentId:   conf.Session.Store,
+		ComponentType: "datastore",
+		Checker:       health.KV(store),
+		Critical:      true,
+	})
+
+	paths := conf.Health.DiskPaths
+	if conf.Session.Store == "file" {
+		paths = append(paths[:len(paths):len(paths)], conf.Session.Dir)
+	}
+	for _, path := range paths {
+		h.Register(health.Check{
+			Name:          "disk:available",
+			ComponentId:   path,
+			ComponentType: "system",
+			Checker:       health.DiskSpace(path, conf.Health.DiskMinFreeMB<<20),
+		})
+	}
+	return h
+}
@@ -0,0 +1,52 @@
+package remote
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"demo/config"
+	"demo/extension/jwt"
+)
+
+// newJWTAuthenticator returns nil if the jwt authentication is disabled.
+func newJWTAuthenticator(conf *config.Schema) (*jwt.Authenticator, error) {
+	c := conf.JWT
+	if !c.Enable {
+		return nil, nil
+	}
+
+	var keys jwt.MultiKeySet
+	if c.Secret != "" {
+		keys = append(keys, jwt.NewSecretKeySet("", []byte(c.Secret)))
+	}
+	for _, file := range c.JWKSFiles {
+		set, err := jwt.NewFileKeySet(file, time.Minute)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, set)
+	}
+	for _, url := range c.JWKSURLs {
+		keys = append(keys, jwt.NewRemoteKeySet(url, jwt.RemoteOptions{
+			Client: &http.Client{Timeout: 10 * time.Second},
+			MaxAge: c.RefreshInterval,
+		}))
+	}
+	if len(keys) == 0 {
+		return nil, errors.New("jwt: one of secret, jwks_files or jwks_urls is required")
+	}
+
+	return jwt.NewAuthenticator(keys, jwt.VerifyOptions{
+		Algorithms:     c.Algorithms,
+		Issuer:         c.Issuer,
+		Audience:       c.Audience,
+		RequireExpires: c.RequireExpires,
+		Leeway:         c.Leeway,
+	}, jwt.ClaimsMapping{
+		UserId: c.Claims.UserId,
+		Tenant: c.Claims.Tenant,
+		Roles:  c.Claims.Roles,
+		Scopes: c.Claims.Scopes,
+	}), nil
+}
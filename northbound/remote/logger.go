@@ -0,0 +1,63 @@
+package remote
+
+import (
+	"context"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"go.uber.org/fx"
+
+	"demo/config"
+	"demo/extension/logz"
+	"demo/extension/metrics"
+)
+
+// loggerModule configures logz before the other modules are invoked, so that
+// their startup logs already use the configured logger.
+var loggerModule = fx.Module("logger",
+	fx.Invoke(setupLogger),
+)
+
+func setupLogger(lc fx.Lifecycle, conf *config.Schema, registry *prometheus.Registry) error {
+	opts := logz.Options{
+		Level:     conf.Log.Level,
+		Format:    conf.Log.Format,
+		Output:    conf.Log.Output,
+		File:      conf.Log.File,
+		AddSource: conf.Log.AddSource,
+		Rotate: logz.RotateOptions{
+			MaxSize:    int64(conf.Log.Rotate.MaxSizeMB) * 1024 * 1024,
+			Interval:   conf.Log.Rotate.Interval,
+			MaxBackups: conf.Log.Rotate.MaxBackups,
+			MaxAge:     conf.Log.Rotate.MaxAge,
+			Compress:   conf.Log.Rotate.Compress,
+		},
+	}
+	if conf.Log.Async.Enable {
+		opts.Async = &logz.AsyncOptions{
+			QueueSize:     conf.Log.Async.QueueSize,
+			FlushInterval: conf.Log.Async.FlushInterval,
+		}
+	}
+
+	if conf.Log.Sampling.Enable {
+		opts.Sampling = &logz.SamplingOptions{
+			Tick:       conf.Log.Sampling.Tick,
+			First:      conf.Log.Sampling.First,
+			Thereafter: conf.Log.Sampling.Thereafter,
+		}
+	}
+
+	if err := logz.Setup(opts); err != nil {
+		return err
+	}
+	if err := metrics.RegisterLogMetrics(registry); err != nil {
+		return err
+	}
+
+	lc.Append(fx.Hook{
+		OnStop: func(ctx context.Context) error {
+			return logz.Close()
+		},
+	})
+	return nil
+}
@@ -0,0 +1,89 @@
+package remote
+
+import (
+	"context"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"go.uber.org/fx"
+
+	"demo/config"
+	"demo/extension/metrics"
+)
+
+// metricsModule provides the SLO calculator and pushes the metrics to the push
+// gateway if it is enabled, the last push happens after the http server is
+// stopped.
+var metricsModule = fx.Module("metrics",
+	fx.Provide(newSLO),
+	fx.Invoke(setupMetricsPush),
+)
+
+func setupMetricsPush(lc fx.Lifecycle, conf *config.Schema, registry *prometheus.Registry) error {
+	c := conf.Metrics.Push
+	if !c.Enable {
+		return nil
+	}
+
+	job := c.Job
+	if job == "" {
+		job = conf.Name
+	}
+	pusher, err := metrics.NewPusher(registry, metrics.PushOptions{
+		URL:        c.URL,
+		Job:        job,
+		Grouping:   c.Grouping,
+		Interval:   c.Interval,
+		Username:   c.Username,
+		Password:   c.Password,
+		Timeout:    c.Timeout,
+		MaxRetries: c.MaxRetries,
+		Backoff:    c.Backoff,
+	})
+	if err != nil {
+		return err
+	}
+
+	lc.Append(fx.Hook{
+		OnStart: func(context.Context) error {
+			pusher.Start()
+			return nil
+		},
+		OnStop: func(ctx context.Context) error {
+			return pusher.Stop(ctx)
+		},
+	})
+	return nil
+}
+
+// newSLO returns the SLO calculator of the configured objectives registered
+// in the registry, or nil if there is no objective.
+func newSLO(conf *config.Schema, registry *prometheus.Registry) (*metrics.SLO, error) {
+	c := conf.Metrics.SLO
+	if len(c.Objectives) == 0 {
+		return nil, nil
+	}
+
+	objectives := make([]metrics.Objective, len(c.Objectives))
+	for i, o := range c.Objectives {
+		objectives[i] = metrics.Objective{
+			Name:          o.Name,
+			Method:        o.Method,
+			Route:         o.Route,
+			Availability:  o.Availability,
+			Latency:       o.Latency,
+			LatencyTarget: o.LatencyTarget,
+		}
+	}
+	slo, err := metrics.NewSLO(conf.Name, metrics.SLOOptions{
+		Objectives: objectives,
+		Windows:    c.Windows,
+		Resolution: c.Resolution,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := registry.Register(slo); err != nil {
+		return nil, err
+	}
+	return slo, nil
+}
@@ -0,0 +1,48 @@
+package remote
+
+import (
+	"crypto/rand"
+
+	"demo/config"
+	"demo/extension/logz"
+	"demo/extension/oauth2"
+)
+
+func newOAuth2StateCodec(conf *config.Schema) (*oauth2.StateCodec, error) {
+	key := []byte(conf.OAuth2.StateKey)
+	if len(key) == 0 {
+		if len(conf.OAuth2.Providers) > 0 {
+			logz.WarnNoCtx("[remote] oauth2 state key is not configured, using a random key")
+		}
+		key = make([]byte, 32)
+		if _, err := rand.Read(key); err != nil {
+			return nil, err
+		}
+	}
+	return oauth2.NewStateCodec(key, conf.OAuth2.StateTTL), nil
+}
+
+func newOAuth2Providers(conf *config.Schema) ([]*oauth2.Provider, error) {
+	providers := make([]*oauth2.Provider, 0, len(conf.OAuth2.Providers))
+	for _, p := range conf.OAuth2.Providers {
+		provider, err := oauth2.NewProvider(oauth2.Config{
+			Name:         p.Name,
+			ClientID:     p.ClientID,
+			ClientSecret: p.ClientSecret,
+			RedirectURL:  p.RedirectURL,
+			Scopes:       p.Scopes,
+			Issuer:       p.Issuer,
+			Endpoint: oauth2.Endpoint{
+				AuthURL:     p.AuthURL,
+				TokenURL:    p.TokenURL,
+				UserInfoURL: p.UserInfoURL,
+				JWKSURL:     p.JWKSURL,
+			},
+		})
+		if err != nil {
+			return nil, err
+		}
+		providers = append(providers, provider)
+	}
+	return providers, nil
+}
@@ -0,0 +1,33 @@
+package remote
+
+import (
+	"crypto/rand"
+
+	"demo/config"
+	"demo/extension/logz"
+	"demo/extension/pagination"
+)
+
+func newCursorCodec(conf *config.Schema) (*pagination.CursorCodec, error) {
+	signKey := []byte(conf.Pagination.Cursor.SignKey)
+	if len(signKey) == 0 {
+		logz.WarnNoCtx("[remote] pagination cursor sign key is not configured, using a random key")
+		signKey = make([]byte, 32)
+		if _, err := rand.Read(signKey); err != nil {
+			return nil, err
+		}
+	}
+
+	return pagination.NewCursorCodec(pagination.CursorOptions{
+		SignKey:    signKey,
+		EncryptKey: []byte(conf.Pagination.Cursor.EncryptKey),
+		TTL:        conf.Pagination.Cursor.TTL,
+	})
+}
+
+func newPaginationOptions(conf *config.Schema) pagination.Options {
+	return pagination.Options{
+		DefaultPageSize: conf.Pagination.DefaultPageSize,
+		MaxPageSize:     conf.Pagination.MaxPageSize,
+	}
+}
@@ -0,0 +1,54 @@
+package restful
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"go.uber.org/fx"
+
+	"demo/config"
+	"demo/northbound/remote/restful/engine"
+	"demo/northbound/remote/restful/handler"
+	"demo/northbound/remote/restful/middleware"
+	"demo/northbound/remote/restful/router"
+)
+
+// runAdmin serves the admin endpoints on their own listener, apart from the
+// api so that they are not exposed with it. It is invoked before run so that
+// it is stopped after the api, the probes and the metrics are served while the
+// api drains.
+func runAdmin(lc fx.Lifecycle, conf *config.Schema, registry *prometheus.Registry, health *handler.Health, logLevel *handler.LogLevel, cfg *handler.Config) {
+	if !conf.Admin.Enable {
+		return
+	}
+
+	e := engine.New()
+	e.Use(middleware.RequestId())
+	e.Use(middleware.Recovery(nil))
+	e.Use(middleware.LogError())
+	router.RegisterAdminServer(conf, e, registry, health, logLevel, cfg)
+
+	srv := &http.Server{
+		Addr:              fmt.Sprintf("%s:%d", conf.Admin.Host, conf.Admin.Port),
+		Handler:           e,
+		ReadHeaderTimeout: conf.HTTP.ReadHeaderTimeout,
+	}
+	conns := trackConns(srv)
+
+	lc.Append(fx.Hook{
+		OnStart: func(ctx context.Context) error {
+			ln, err := net.Listen("tcp", srv.Addr)
+			if err != nil {
+				return err
+			}
+			go serve(ctx, "admin", srv, ln)
+			return nil
+		},
+		OnStop: func(ctx context.Context) error {
+			return shutdown(ctx, "admin", srv, conns)
+		},
+	})
+}
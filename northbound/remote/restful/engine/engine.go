@@ -3,5 +3,9 @@ package engine
 import "github.com/gin-gonic/gin"
 
 func New() *gin.Engine {
-	return gin.New()
+	engine := gin.New()
+	// gin.Context is passed as context.Context to logz and others, make its
+	// Value fall back to the values of the request context.
+	engine.ContextWithFallback = true
+	return engine
 }
@@ -11,9 +11,19 @@ import (
 
 var Module = fx.Module("restful",
 	fx.Provide(handler.NewHello),
+	fx.Provide(handler.NewHealth),
+	fx.Provide(handler.NewLogLevel),
+	fx.Provide(handler.NewConfig),
+	fx.Provide(handler.NewOAuth2),
+	fx.Provide(handler.NewLogin),
 	fx.Provide(engine.New),
 	fx.Provide(router.NewAPIRouter),
 	fx.Invoke(middleware.Setup),
+	fx.Invoke(router.RegisterHealth),
 	fx.Invoke(router.RegisterHello),
+	fx.Invoke(router.RegisterOAuth2),
+	fx.Invoke(router.RegisterLogin),
+	fx.Invoke(router.RegisterAdmin),
+	fx.Invoke(runAdmin),
 	fx.Invoke(run),
 )
@@ -0,0 +1,92 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"demo/config"
+	"demo/extension/errorx"
+	"demo/extension/logz"
+	"demo/northbound/remote/restful/response"
+)
+
+// secretFields are the config keys whose values are never exposed.
+var secretFields = []string{"token", "password", "secret", "client_secret", "state_key", "tracing.otlp.headers"}
+
+type Config struct {
+	conf     *config.Schema
+	redactor *logz.Redactor
+}
+
+func NewConfig(conf *config.Schema) (*Config, error) {
+	redactor, err := logz.NewRedactor(logz.RedactOptions{
+		Fields: append(append([]string{}, secretFields...), conf.Log.Redact.Fields...),
+		Mask:   conf.Log.Redact.Mask,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &Config{conf: conf, redactor: redactor}, nil
+}
+
+// Get returns the effective configuration with the secrets masked, the keys
+// are the same as the config file.
+func (h *Config) Get(ctx *gin.Context) {
+	body, err := json.Marshal(configValue(reflect.ValueOf(h.conf)))
+	if err != nil {
+		response.Error(ctx, errorx.ErrInternalServer.Wrap(err))
+		return
+	}
+	ctx.Data(http.StatusOK, "application/json; charset=utf-8", []byte(h.redactor.Body("application/json", body)))
+}
+
+// configValue converts the config structs to maps keyed by their mapstructure
+// tags.
+func configValue(v reflect.Value) any {
+	if v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return nil
+		}
+		v = v.Elem()
+	}
+
+	switch v.Kind() {
+	case reflect.Struct:
+		rv := make(map[string]any, v.NumField())
+		for i := 0; i < v.NumField(); i++ {
+			field := v.Type().Field(i)
+			if !field.IsExported() {
+				continue
+			}
+			name, _, _ := strings.Cut(field.Tag.Get("mapstructure"), ",")
+			if name == "" {
+				name = strings.ToLower(field.Name)
+			}
+			rv[name] = configValue(v.Field(i))
+		}
+		return rv
+	case reflect.Slice:
+		rv := make([]any, v.Len())
+		for i := range rv {
+			rv[i] = configValue(v.Index(i))
+		}
+		return rv
+	case reflect.Map:
+		rv := make(map[string]any, v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			rv[iter.Key().String()] = configValue(iter.Value())
+		}
+		return rv
+	default:
+		if d, ok := v.Interface().(time.Duration); ok {
+			return d.String()
+		}
+		return v.Interface()
+	}
+}
@@ -1,21 +1,55 @@
 package handler
 
 import (
+	"encoding/json"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
+
+	"demo/extension/health"
 )
 
-// HealthyHandler health checks for the server.
-// guide: https://tools.ietf.org/id/draft-inadarei-api-health-check-01.html#rfc.section.3
-func HealthyHandler(ctx *gin.Context) {
-	ctx.Header("Cache-Control", "max-age=3600")
-	ctx.Header("Content-Type", "application/health+json")
-
-	ctx.JSON(http.StatusOK, gin.H{
-		"status":      "pass",
-		"version":     "1",
-		"releaseID":   "1.0.0",
-		"description": "health of scan-management service",
-	})
+// Health serves the liveness and readiness probes.
+// guide: https://datatracker.ietf.org/doc/html/draft-inadarei-api-health-check
+type Health struct {
+	health *health.Health
+}
+
+func NewHealth(h *health.Health) *Health {
+	return &Health{health: h}
+}
+
+// Live reports whether the service is alive, it fails only if a restart is
+// required.
+func (h *Health) Live(ctx *gin.Context) {
+	h.respond(ctx, h.health.Liveness(ctx))
+}
+
+// Ready reports whether the service can serve the requests.
+func (h *Health) Ready(ctx *gin.Context) {
+	h.respond(ctx, h.health.Readiness(ctx))
+}
+
+func (h *Health) respond(ctx *gin.Context, result health.Result) {
+	status := http.StatusOK
+	if result.Status == health.StatusFail {
+		status = http.StatusServiceUnavailable
+	}
+
+	ctx.Header("Cache-Control", "no-store")
+	ctx.Render(status, healthJSON{result})
+}
+
+// healthJSON renders the result with the content type of the draft.
+type healthJSON struct {
+	result health.Result
+}
+
+func (r healthJSON) Render(w http.ResponseWriter) error {
+	r.WriteContentType(w)
+	return json.NewEncoder(w).Encode(r.result)
+}
+
+func (r healthJSON) WriteContentType(w http.ResponseWriter) {
+	w.Header().Set("Content-Type", "application/health+json")
 }
@@ -0,0 +1,44 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"demo/extension/errorx"
+	"demo/extension/logz"
+	"demo/northbound/remote/restful/response"
+)
+
+type LogLevel struct{}
+
+func NewLogLevel() *LogLevel {
+	return &LogLevel{}
+}
+
+type logLevelBody struct {
+	Level string `json:"level" binding:"required"`
+}
+
+// Get returns the current minimum level of the logger.
+func (h *LogLevel) Get(ctx *gin.Context) {
+	ctx.JSON(http.StatusOK, logLevelBody{Level: logz.Level()})
+}
+
+// Set changes the minimum level of the logger at runtime.
+func (h *LogLevel) Set(ctx *gin.Context) {
+	var body logLevelBody
+	if err := ctx.ShouldBindJSON(&body); err != nil {
+		response.Error(ctx, errorx.ErrIllegalArgument.WithWrap(err))
+		return
+	}
+
+	previous := logz.Level()
+	if err := logz.SetLevel(body.Level); err != nil {
+		response.Error(ctx, errorx.ErrIllegalArgument.WithWrap(err))
+		return
+	}
+
+	logz.Warn(ctx, "[admin] log level changed", logz.String("from", previous), logz.String("to", logz.Level()))
+	ctx.JSON(http.StatusOK, logLevelBody{Level: logz.Level()})
+}
@@ -0,0 +1,164 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"demo/config"
+	"demo/extension/captcha"
+	"demo/extension/contextz"
+	"demo/extension/errorx"
+	"demo/extension/kv"
+	"demo/extension/logz"
+	"demo/extension/session"
+	"demo/extension/throttle"
+	"demo/northbound/remote/restful/response"
+)
+
+// Accounts verifies the username and the password of an account.
+type Accounts interface {
+	// Authenticate returns the principal of the account, it fails with
+	// ErrInvalidUsernameOrPassword or ErrUserDisabled.
+	Authenticate(ctx context.Context, username, password string) (*contextz.Principal, error)
+}
+
+type Login struct {
+	accounts        Accounts
+	sessions        *session.Manager
+	captchas        *captcha.Captcha
+	accountThrottle *throttle.Throttle
+	ipThrottle      *throttle.Throttle
+	requireCaptcha  bool
+	cookie          config.SessionCookie
+}
+
+func NewLogin(conf *config.Schema, accounts Accounts, sessions *session.Manager, captchas *captcha.Captcha, store kv.KV) *Login {
+	return &Login{
+		accounts: accounts,
+		sessions: sessions,
+		captchas: captchas,
+		accountThrottle: throttle.New(store, throttle.Options{
+			Max:    conf.Login.MaxAccountFailures,
+			Window: conf.Login.LockDuration,
+			Prefix: "login:account:",
+		}),
+		ipThrottle: throttle.New(store, throttle.Options{
+			Max:    conf.Login.MaxIPFailures,
+			Window: conf.Login.LockDuration,
+			Prefix: "login:ip:",
+		}),
+		requireCaptcha: conf.Login.Captcha,
+		cookie:         conf.Session.Cookie,
+	}
+}
+
+type loginBody struct {
+	Username  string `json:"username" binding:"required"`
+	Password  string `json:"password" binding:"required"`
+	CaptchaId string `json:"captcha_id"`
+	Captcha   string `json:"captcha"`
+}
+
+type loginResult struct {
+	UserId    string    `json:"user_id"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// Captcha returns a new captcha challenge for the login.
+func (h *Login) Captcha(ctx *gin.Context) {
+	challenge, err := h.captchas.Generate(ctx)
+	if err != nil {
+		response.Error(ctx, errorx.ErrInternalServer.Wrap(err))
+		return
+	}
+	ctx.JSON(http.StatusOK, challenge)
+}
+
+// Login verifies the credentials and starts a session. The failed logins are
+// counted per account and per ip, which are locked once they failed too often.
+func (h *Login) Login(ctx *gin.Context) {
+	var body loginBody
+	if err := ctx.ShouldBindJSON(&body); err != nil {
+		response.Error(ctx, errorx.ErrIllegalArgument.WithWrap(err))
+		return
+	}
+
+	ip := ctx.ClientIP()
+	if err := h.accountThrottle.Check(ctx, body.Username); err != nil {
+		response.Error(ctx, err)
+		return
+	}
+	if err := h.ipThrottle.Check(ctx, ip); err != nil {
+		response.Error(ctx, err)
+		return
+	}
+
+	if h.requireCaptcha {
+		if err := h.captchas.Verify(ctx, body.CaptchaId, body.Captcha); err != nil {
+			response.Error(ctx, err)
+			return
+		}
+	}
+
+	principal, err := h.accounts.Authenticate(ctx, body.Username, body.Password)
+	if errors.Is(err, errorx.ErrInvalidUsernameOrPassword) {
+		logz.Warn(ctx, "[login] login failed", logz.String("username", body.Username), logz.String("ip", ip))
+		if err := h.accountThrottle.Fail(ctx, body.Username); err != nil {
+			logz.Error(ctx, "[login] count failed login", logz.Err(err))
+		}
+		if err := h.ipThrottle.Fail(ctx, ip); err != nil {
+			logz.Error(ctx, "[login] count failed login", logz.Err(err))
+		}
+	}
+	if err != nil {
+		response.Error(ctx, err)
+		return
+	}
+	if err := h.accountThrottle.Reset(ctx, body.Username); err != nil {
+		logz.Error(ctx, "[login] reset failed logins", logz.Err(err))
+	}
+
+	s, err := h.sessions.Create(ctx, principal, nil)
+	if err != nil {
+		response.Error(ctx, errorx.ErrInternalServer.Wrap(err))
+		return
+	}
+	h.setCookie(ctx, s.Id, int(h.sessions.TTL().Seconds()))
+
+	logz.Info(ctx, "[login] user logged in", logz.String("username", body.Username), logz.String("ip", ip))
+	ctx.JSON(http.StatusOK, loginResult{UserId: s.UserId, ExpiresAt: s.ExpiresAt})
+}
+
+// Logout destroys the session of the cookie.
+func (h *Login) Logout(ctx *gin.Context) {
+	if id, err := ctx.Cookie(h.cookie.Name); err == nil && id != "" {
+		if err := h.sessions.Destroy(ctx, id); err != nil {
+			response.Error(ctx, errorx.ErrInternalServer.Wrap(err))
+			return
+		}
+	}
+	h.setCookie(ctx, "", -1)
+	ctx.Status(http.StatusNoContent)
+}
+
+func (h *Login) setCookie(ctx *gin.Context, value string, maxAge int) {
+	setSessionCookie(ctx, h.cookie, value, maxAge)
+}
+
+// setSessionCookie sets the cookie of the session id, a negative maxAge
+// deletes it.
+func setSessionCookie(ctx *gin.Context, cookie config.SessionCookie, value string, maxAge int) {
+	switch cookie.SameSite {
+	case "strict":
+		ctx.SetSameSite(http.SameSiteStrictMode)
+	case "none":
+		ctx.SetSameSite(http.SameSiteNoneMode)
+	default:
+		ctx.SetSameSite(http.SameSiteLaxMode)
+	}
+	ctx.SetCookie(cookie.Name, value, maxAge, cookie.Path, cookie.Domain, cookie.Secure, cookie.HTTPOnly)
+}
@@ -0,0 +1,158 @@
+package handler
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"demo/config"
+	"demo/extension/contextz"
+	"demo/extension/errorx"
+	"demo/extension/logz"
+	"demo/extension/oauth2"
+	"demo/extension/session"
+	"demo/northbound/remote/restful/response"
+)
+
+const oauth2StateCookie = "oauth2_state"
+
+type OAuth2 struct {
+	providers map[string]*oauth2.Provider
+	states    *oauth2.StateCodec
+	sessions  *session.Manager
+	maxAge    int
+	secure    bool
+	cookie    config.SessionCookie
+}
+
+func NewOAuth2(conf *config.Schema, states *oauth2.StateCodec, providers []*oauth2.Provider, sessions *session.Manager) *OAuth2 {
+	h := &OAuth2{
+		providers: make(map[string]*oauth2.Provider, len(providers)),
+		states:    states,
+		sessions:  sessions,
+		maxAge:    int(conf.OAuth2.StateTTL.Seconds()),
+		secure:    conf.OAuth2.CookieSecure,
+		cookie:    conf.Session.Cookie,
+	}
+	for _, p := range providers {
+		h.providers[p.Name()] = p
+	}
+	return h
+}
+
+// Login redirects the user to the authorization endpoint of the provider, the
+// state of the login is kept in a signed cookie until the callback.
+func (h *OAuth2) Login(ctx *gin.Context) {
+	provider, ok := h.provider(ctx)
+	if !ok {
+		return
+	}
+
+	st := h.states.New(provider.Name())
+	if redirect := provider.RedirectURL(); strings.HasPrefix(redirect, "/") {
+		origin, ok := contextz.GetOrigin(ctx.Request.Context())
+		if !ok {
+			response.Error(ctx, errorx.ErrInternalServer.WithMessage("the origin of the request is not resolved"))
+			return
+		}
+		st.RedirectURI = origin.URL(redirect)
+	}
+	authURL, err := provider.AuthCodeURL(ctx, st)
+	if err != nil {
+		response.Error(ctx, err)
+		return
+	}
+	cookie, err := h.states.Encode(st)
+	if err != nil {
+		response.Error(ctx, errorx.ErrInternalServer.Wrap(err))
+		return
+	}
+
+	ctx.SetSameSite(http.SameSiteLaxMode)
+	ctx.SetCookie(oauth2StateCookie, cookie, h.maxAge, "/", "", h.secure, true)
+	ctx.Redirect(http.StatusFound, authURL)
+}
+
+type oauth2LoginResult struct {
+	loginResult
+	Identity *oauth2.Identity `json:"identity"`
+}
+
+// Callback completes the login and starts a session of the user, the same
+// session as the password login.
+func (h *OAuth2) Callback(ctx *gin.Context) {
+	provider, ok := h.provider(ctx)
+	if !ok {
+		return
+	}
+
+	if reason := ctx.Query("error"); reason != "" {
+		response.Error(ctx, errorx.ErrOAuth2AuthorizationFailed.WithMessageF("%s: %s", reason, ctx.Query("error_description")))
+		return
+	}
+	code := ctx.Query("code")
+	if code == "" {
+		response.Error(ctx, errorx.ErrRequiredAuthenticationCode)
+		return
+	}
+
+	cookie, err := ctx.Cookie(oauth2StateCookie)
+	if err != nil {
+		response.Error(ctx, errorx.ErrInvalidAuthenticationState.WithMessage("state cookie is missing"))
+		return
+	}
+	// the state can only be used once
+	ctx.SetSameSite(http.SameSiteLaxMode)
+	ctx.SetCookie(oauth2StateCookie, "", -1, "/", "", h.secure, true)
+
+	st, err := h.states.Decode(cookie, provider.Name(), ctx.Query("state"))
+	if err != nil {
+		response.Error(ctx, err)
+		return
+	}
+
+	identity, err := provider.Authenticate(ctx, code, st)
+	if err != nil {
+		response.Error(ctx, err)
+		return
+	}
+
+	s, err := h.sessions.Create(ctx, identityPrincipal(identity), map[string]any{"provider": identity.Provider})
+	if err != nil {
+		response.Error(ctx, errorx.ErrInternalServer.Wrap(err))
+		return
+	}
+	setSessionCookie(ctx, h.cookie, s.Id, int(h.sessions.TTL().Seconds()))
+
+	logz.Info(ctx, "[oauth2] user logged in", logz.String("provider", identity.Provider), logz.String("subject", identity.Subject))
+	ctx.JSON(http.StatusOK, oauth2LoginResult{
+		loginResult: loginResult{UserId: s.UserId, ExpiresAt: s.ExpiresAt},
+		Identity:    identity,
+	})
+}
+
+// identityPrincipal maps the identity to a principal, the subject is only
+// unique within its provider so the user id is prefixed by the provider.
+func identityPrincipal(identity *oauth2.Identity) *contextz.Principal {
+	claims := make(map[string]any, len(identity.Claims)+3)
+	for k, v := range identity.Claims {
+		claims[k] = v
+	}
+	claims["provider"] = identity.Provider
+	if identity.Email != "" {
+		claims["email"] = identity.Email
+	}
+	if identity.Name != "" {
+		claims["name"] = identity.Name
+	}
+	return &contextz.Principal{UserId: identity.Provider + ":" + identity.Subject, Claims: claims}
+}
+
+func (h *OAuth2) provider(ctx *gin.Context) (*oauth2.Provider, bool) {
+	provider, ok := h.providers[ctx.Param("provider")]
+	if !ok {
+		response.Error(ctx, errorx.ErrResourceNotFound.WithMessageF("oauth2 provider %s is not configured", ctx.Param("provider")))
+	}
+	return provider, ok
+}
@@ -0,0 +1,117 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+
+	"github.com/gin-gonic/gin"
+
+	"demo/extension/contextz"
+	"demo/extension/errorx"
+	"demo/northbound/remote/restful/response"
+)
+
+// DefaultSessionCookie 默认的会话 cookie 名称
+const DefaultSessionCookie = "session_id"
+
+// credentialsKey 记录请求是否携带了令牌或会话 cookie
+const credentialsKey = "auth.credentials"
+
+// TokenVerifier 校验 Bearer 令牌并返回对应的主体
+type TokenVerifier interface {
+	VerifyToken(ctx context.Context, token string) (*contextz.Principal, error)
+}
+
+// SessionLoader 根据会话 ID 加载会话及其主体，会话不存在或已过期时返回 nil 会话
+type SessionLoader interface {
+	LoadSession(ctx context.Context, id string) (*contextz.Session, *contextz.Principal, error)
+}
+
+// AuthOptions 认证中间件的配置，未配置的认证方式将被忽略
+type AuthOptions struct {
+	Tokens TokenVerifier
+	// Sessions 通过 cookie 中的会话 ID 认证
+	Sessions SessionLoader
+	// CookieName 会话 cookie 名称，默认为 DefaultSessionCookie
+	CookieName string
+}
+
+// Authenticate 从 Bearer 令牌或会话 cookie 中解析当前主体并存入请求上下文。
+// 请求未携带凭证时以匿名身份继续，需要登录的接口应使用 RequireLogin；
+// 携带了无效的令牌时返回 ErrInvalidToken。
+func Authenticate(opts AuthOptions) gin.HandlerFunc {
+	if opts.CookieName == "" {
+		opts.CookieName = DefaultSessionCookie
+	}
+
+	return func(c *gin.Context) {
+		ctx := c.Request.Context()
+
+		token, hasToken := bearerToken(c)
+		id, _ := c.Cookie(opts.CookieName)
+		c.Set(credentialsKey, hasToken || id != "")
+
+		if hasToken && opts.Tokens != nil {
+			principal, err := opts.Tokens.VerifyToken(ctx, token)
+			if err != nil {
+				response.Error(c, asAuthError(err, errorx.ErrInvalidToken))
+				return
+			}
+			ctx = contextz.WithPrincipal(ctx, principal)
+		} else if id != "" && opts.Sessions != nil {
+			session, principal, err := opts.Sessions.LoadSession(ctx, id)
+			if err != nil {
+				response.Error(c, asAuthError(err, errorx.ErrInvalidSession))
+				return
+			}
+			if session != nil {
+				ctx = contextz.WithSession(ctx, session)
+				if principal != nil {
+					ctx = contextz.WithPrincipal(ctx, principal)
+				}
+			}
+		}
+
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+	}
+}
+
+// RequireLogin 拒绝匿名请求，被跳过的请求（如登录接口）可以匿名访问。
+// 未携带令牌及会话 cookie 时返回 ErrTokenRequired，携带的会话已失效等情况返回 ErrLoginRequired
+func RequireLogin(skippers ...SkipperFunc) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if skipHandler(c, skippers...) {
+			c.Next()
+			return
+		}
+		if _, err := contextz.RequirePrincipal(c.Request.Context()); err != nil {
+			if !c.GetBool(credentialsKey) {
+				err = errorx.ErrTokenRequired
+			}
+			response.Error(c, err)
+			return
+		}
+		c.Next()
+	}
+}
+
+// RequireRoles 拒绝匿名请求及不具备全部角色的主体
+func RequireRoles(roles ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if err := contextz.RequireRoles(c.Request.Context(), roles...); err != nil {
+			response.Error(c, err)
+			return
+		}
+		c.Next()
+	}
+}
+
+// asAuthError 保留认证器返回的 errorx 错误，其他错误包装为 fallback
+func asAuthError(err error, fallback errorx.Error) error {
+	var ex errorx.Error
+	if errors.As(err, &ex) {
+		return err
+	}
+	return fallback.Wrap(err)
+}
@@ -0,0 +1,86 @@
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+
+	"demo/extension/contextz"
+	"demo/extension/errorx"
+)
+
+type stubTokens struct{}
+
+func (stubTokens) VerifyToken(_ context.Context, token string) (*contextz.Principal, error) {
+	if token != "valid" {
+		return nil, errorx.ErrInvalidToken
+	}
+	return &contextz.Principal{UserId: "u1"}, nil
+}
+
+type stubSessions struct{}
+
+func (stubSessions) LoadSession(_ context.Context, id string) (*contextz.Session, *contextz.Principal, error) {
+	if id != "s1" {
+		return nil, nil, nil
+	}
+	return &contextz.Session{Id: id, UserId: "u2"}, &contextz.Principal{UserId: "u2"}, nil
+}
+
+// responseCode returns the errorx code of the response, zero for a success.
+func responseCode(t *testing.T, w *httptest.ResponseRecorder) int {
+	if w.Code == http.StatusOK {
+		return 0
+	}
+	var body struct {
+		Code int `json:"code"`
+	}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+	return body.Code
+}
+
+func TestRequireLogin(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	engine.Use(Authenticate(AuthOptions{Tokens: stubTokens{}, Sessions: stubSessions{}}))
+	engine.Use(RequireLogin(SkipWithPathPrefix("/public")))
+	engine.GET("/private", func(c *gin.Context) { c.Status(http.StatusOK) })
+	engine.GET("/public", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	testcases := []struct {
+		name          string
+		path          string
+		authorization string
+		cookie        string
+		want          errorx.Error
+	}{
+		{name: "no credentials", path: "/private", want: errorx.ErrTokenRequired},
+		{name: "invalid token", path: "/private", authorization: "Bearer forged", want: errorx.ErrInvalidToken},
+		{name: "expired session", path: "/private", cookie: "gone", want: errorx.ErrLoginRequired},
+		{name: "token", path: "/private", authorization: "Bearer valid"},
+		{name: "session", path: "/private", cookie: "s1"},
+		{name: "public", path: "/public"},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tc.path, nil)
+			if tc.authorization != "" {
+				req.Header.Set("Authorization", tc.authorization)
+			}
+			if tc.cookie != "" {
+				req.AddCookie(&http.Cookie{Name: DefaultSessionCookie, Value: tc.cookie})
+			}
+			w := httptest.NewRecorder()
+			engine.ServeHTTP(w, req)
+			assert.Equal(t, tc.want.Code, responseCode(t, w))
+			if tc.want.Code != 0 {
+				assert.Equal(t, http.StatusUnauthorized, w.Code)
+			}
+		})
+	}
+}
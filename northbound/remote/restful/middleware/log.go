@@ -2,7 +2,9 @@ package middleware
 
 import (
 	"bytes"
+	"context"
 	"io/ioutil"
+	"net/http"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -12,36 +14,63 @@ import (
 
 const MAX_LOG_CONTENT_LENGTH = 1024
 
-func Logger(skippers ...SkipperFunc) gin.HandlerFunc {
+// RequestSampler 请求日志采样器的名称
+const RequestSampler = "request"
+
+// LoggerOptions 请求日志中间件的配置
+type LoggerOptions struct {
+	// Redactor 对请求头与请求体中的敏感数据脱敏，为空时不脱敏
+	Redactor *logz.Redactor
+
+	// Sampler 按路由对请求日志采样，为空时不采样；失败（5xx）的请求总会记录响应日志
+	Sampler *logz.Sampler
+}
+
+// Logger 记录请求与响应日志
+func Logger(opts LoggerOptions, skippers ...SkipperFunc) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		if skipHandler(c, skippers...) {
 			c.Next()
 			return
 		}
 
+		sampled := opts.Sampler == nil || opts.Sampler.Sample(c.FullPath())
+
 		start := time.Now()
-		logRequest(c)
+		if sampled {
+			logRequest(loggingContext(c, opts), c, opts.Redactor)
+		}
 		c.Next()
-		logResponse(c, start)
+		if sampled || c.Writer.Status() >= http.StatusInternalServerError {
+			logResponse(loggingContext(c, opts), c, start)
+		}
+	}
+}
+
+// loggingContext 已按路由采样的日志不再经过 logz 的采样
+func loggingContext(c *gin.Context, opts LoggerOptions) context.Context {
+	if opts.Sampler != nil {
+		return logz.SkipSampling(c.Request.Context())
 	}
+	return c.Request.Context()
 }
 
-func logRequest(c *gin.Context) {
-	logz.Info(c.Request.Context(), "[middleware] http request",
+func logRequest(ctx context.Context, c *gin.Context, redactor *logz.Redactor) {
+	logz.Info(ctx, "[middleware] http request",
 		logz.Any("method", c.Request.Method),
 		logz.Any("uri", c.Request.URL.Path),
 		logz.Any("content_type", c.Request.Header.Get("Content-Type")),
 		logz.Any("content_length", c.Request.ContentLength),
-		logz.Any("query", c.Request.URL.RawQuery),
-		logz.Any("headers", c.Request.Header),
+		logz.Any("query", redactor.Query(c.Request.URL.RawQuery)),
+		logz.Any("headers", redactor.Headers(c.Request.Header)),
 		logz.Any("ip", c.ClientIP()),
 		logz.Any("ua", c.Request.Header.Get("User-Agent")),
-		logz.Any("body", getPrintableBody(c)),
+		logz.Any("body", getPrintableBody(c, redactor)),
 	)
 }
 
-func logResponse(c *gin.Context, start time.Time) {
-	logz.Info(c.Request.Context(), "[middleware] http response",
+func logResponse(ctx context.Context, c *gin.Context, start time.Time) {
+	logz.Info(ctx, "[middleware] http response",
 		logz.Any("status", c.Writer.Status()),
 		logz.Any("method", c.Request.Method),
 		logz.Any("uri", c.Request.URL.Path),
@@ -49,9 +78,9 @@ func logResponse(c *gin.Context, start time.Time) {
 	)
 }
 
-func getPrintableBody(c *gin.Context) string {
+func getPrintableBody(c *gin.Context, redactor *logz.Redactor) string {
 	if c.Request.ContentLength < MAX_LOG_CONTENT_LENGTH {
-		return string(readBody(c))
+		return redactor.Body(c.GetHeader("Content-Type"), readBody(c))
 	}
 	return "Exceeded the maximum data limit"
 }
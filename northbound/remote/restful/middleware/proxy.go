@@ -0,0 +1,199 @@
+package middleware
+
+import (
+	"net"
+	"net/netip"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"demo/config"
+	"demo/extension/contextz"
+	"demo/extension/errorx"
+	"demo/northbound/remote/restful/response"
+)
+
+// SocketPeer 是 Unix socket 连接对端的地址，socket 连接没有对端 IP，以环回地址代替
+const SocketPeer = "127.0.0.1"
+
+// TrustedProxies 返回可信代理的列表，未启用反向代理时为空，未配置时信任环回地址。
+// 监听 Unix socket 时反向代理经由 socket 转发，始终信任 SocketPeer
+func TrustedProxies(conf config.HTTPServer) []string {
+	if !conf.ReverseProxy {
+		return nil
+	}
+	proxies := conf.TrustedProxies
+	if len(proxies) == 0 {
+		proxies = []string{"127.0.0.0/8", "::1"}
+	}
+	if conf.Network == "unix" {
+		proxies = append(proxies[:len(proxies):len(proxies)], SocketPeer)
+	}
+	return proxies
+}
+
+// UnixSocketPeer 将 Unix socket 连接的对端地址设为 SocketPeer，使 ClientIP 及
+// ResolveOrigin 能够识别经由 socket 转发的反向代理，需在其他中间件之前使用
+func UnixSocketPeer() gin.HandlerFunc {
+	peer := net.JoinHostPort(SocketPeer, "0")
+	return func(c *gin.Context) {
+		if _, err := netip.ParseAddrPort(c.Request.RemoteAddr); err != nil {
+			c.Request.RemoteAddr = peer
+		}
+		c.Next()
+	}
+}
+
+// ParseProxies 解析可信代理的 IP 或 CIDR 列表，单个 IP 视为仅包含自身的网段
+func ParseProxies(proxies []string) ([]netip.Prefix, error) {
+	prefixes := make([]netip.Prefix, 0, len(proxies))
+	for _, p := range proxies {
+		if !strings.Contains(p, "/") {
+			addr, err := netip.ParseAddr(p)
+			if err != nil {
+				return nil, err
+			}
+			prefixes = append(prefixes, netip.PrefixFrom(addr, addr.BitLen()))
+			continue
+		}
+		prefix, err := netip.ParsePrefix(p)
+		if err != nil {
+			return nil, err
+		}
+		prefixes = append(prefixes, prefix.Masked())
+	}
+	return prefixes, nil
+}
+
+// ResolveOrigin 还原客户端请求的协议与主机并存入请求上下文，
+// 仅信任来自可信代理的 Forwarded 与 X-Forwarded-Proto/X-Forwarded-Host 请求头
+func ResolveOrigin(trusted []netip.Prefix) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		origin := contextz.Origin{Scheme: "http", Host: c.Request.Host}
+		if c.Request.TLS != nil {
+			origin.Scheme = "https"
+		}
+		if isTrustedProxy(trusted, c.Request.RemoteAddr) {
+			scheme, host := forwarded(c)
+			if scheme == "http" || scheme == "https" {
+				origin.Scheme = scheme
+			}
+			if host != "" && !strings.ContainsAny(host, "/ ") {
+				origin.Host = host
+			}
+		}
+
+		c.Request = c.Request.WithContext(contextz.WithOrigin(c.Request.Context(), origin))
+		c.Next()
+	}
+}
+
+// AllowHosts 拒绝主机不在白名单中的请求并响应 421，主机可带端口，
+// "*.example.com" 匹配其子域名，白名单为空时不做限制
+func AllowHosts(hosts []string, skippers ...SkipperFunc) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if len(hosts) == 0 || skipHandler(c, skippers...) {
+			c.Next()
+			return
+		}
+
+		origin, ok := contextz.GetOrigin(c.Request.Context())
+		if !ok {
+			origin = contextz.Origin{Scheme: "http", Host: c.Request.Host}
+		}
+		if !hostAllowed(hosts, origin) {
+			response.Error(c, errorx.ErrMisdirected.WithMessageF("host %s is not served", origin.Host))
+			return
+		}
+		c.Next()
+	}
+}
+
+// isTrustedProxy 判断请求的直连地址是否为可信代理
+func isTrustedProxy(trusted []netip.Prefix, remoteAddr string) bool {
+	if len(trusted) == 0 {
+		return false
+	}
+	host, _, err := net.SplitHostPort(remoteAddr)
+	if err != nil {
+		host = remoteAddr
+	}
+	addr, err := netip.ParseAddr(host)
+	if err != nil {
+		return false
+	}
+	addr = addr.Unmap()
+	for _, p := range trusted {
+		if p.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// forwarded 返回最近一跳代理转发的协议与主机，即各请求头的最后一个值，
+// 之前的值可能由客户端伪造。Forwarded 优先于 X-Forwarded-*
+func forwarded(c *gin.Context) (scheme, host string) {
+	last := func(v string) string {
+		if i := strings.LastIndex(v, ","); i >= 0 {
+			v = v[i+1:]
+		}
+		return strings.TrimSpace(v)
+	}
+
+	if value := strings.Join(c.Request.Header.Values("Forwarded"), ","); value != "" {
+		for _, pair := range strings.Split(last(value), ";") {
+			key, v, ok := strings.Cut(strings.TrimSpace(pair), "=")
+			if !ok {
+				continue
+			}
+			v = strings.Trim(v, `"`)
+			switch strings.ToLower(key) {
+			case "proto":
+				scheme = strings.ToLower(v)
+			case "host":
+				host = v
+			}
+		}
+		return scheme, host
+	}
+	values := func(name string) string {
+		return strings.Join(c.Request.Header.Values(name), ",")
+	}
+	return strings.ToLower(last(values("X-Forwarded-Proto"))), last(values("X-Forwarded-Host"))
+}
+
+// hostAllowed 判断主机是否在白名单中，未带端口的主机按协议的默认端口匹配带端口的条目
+func hostAllowed(hosts []string, origin contextz.Origin) bool {
+	hostname, port, err := net.SplitHostPort(origin.Host)
+	if err != nil {
+		hostname = origin.Host
+		port = "80"
+		if origin.Scheme == "https" {
+			port = "443"
+		}
+	}
+	hostname = strings.ToLower(strings.TrimSuffix(hostname, "."))
+
+	for _, h := range hosts {
+		allowedName, allowedPort, err := net.SplitHostPort(h)
+		if err != nil {
+			allowedName, allowedPort = h, ""
+		}
+		if allowedPort != "" && allowedPort != port {
+			continue
+		}
+
+		allowedName = strings.ToLower(allowedName)
+		if suffix, ok := strings.CutPrefix(allowedName, "*"); ok {
+			if strings.HasSuffix(hostname, suffix) && len(hostname) > len(suffix) {
+				return true
+			}
+			continue
+		}
+		if hostname == allowedName {
+			return true
+		}
+	}
+	return false
+}
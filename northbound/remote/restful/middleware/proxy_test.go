@@ -0,0 +1,258 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+
+	"demo/config"
+	"demo/extension/contextz"
+)
+
+func TestParseProxies(t *testing.T) {
+	prefixes, err := ParseProxies([]string{"10.0.0.0/8", "192.168.1.1", "::1", "172.16.1.1/12"})
+	assert.NoError(t, err)
+	assert.Equal(t, []netip.Prefix{
+		netip.MustParsePrefix("10.0.0.0/8"),
+		netip.MustParsePrefix("192.168.1.1/32"),
+		netip.MustParsePrefix("::1/128"),
+		netip.MustParsePrefix("172.16.0.0/12"),
+	}, prefixes)
+
+	_, err = ParseProxies([]string{"localhost"})
+	assert.Error(t, err)
+	_, err = ParseProxies([]string{"10.0.0.0/33"})
+	assert.Error(t, err)
+}
+
+func TestIsTrustedProxy(t *testing.T) {
+	trusted, err := ParseProxies([]string{"127.0.0.0/8", "::1", "10.1.0.0/16"})
+	assert.NoError(t, err)
+
+	testcases := []struct {
+		remoteAddr string
+		want       bool
+	}{
+		{remoteAddr: "127.0.0.1:5000", want: true},
+		{remoteAddr: "10.1.2.3:443", want: true},
+		{remoteAddr: "[::1]:8080", want: true},
+		{remoteAddr: "[::ffff:127.0.0.1]:8080", want: true},
+		{remoteAddr: "10.2.0.1:443", want: false},
+		{remoteAddr: "[2001:db8::1]:8080", want: false},
+		{remoteAddr: "127.0.0.1", want: true},
+		{remoteAddr: "not-an-ip:80", want: false},
+		{remoteAddr: "", want: false},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.remoteAddr, func(t *testing.T) {
+			assert.Equal(t, tc.want, isTrustedProxy(trusted, tc.remoteAddr))
+		})
+	}
+	assert.False(t, isTrustedProxy(nil, "127.0.0.1:5000"), "no proxy is trusted by default")
+}
+
+func TestResolveOrigin(t *testing.T) {
+	trusted, err := ParseProxies([]string{"127.0.0.1", "::1"})
+	assert.NoError(t, err)
+
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	engine.Use(ResolveOrigin(trusted))
+	engine.GET("/origin", func(c *gin.Context) {
+		origin, _ := contextz.GetOrigin(c.Request.Context())
+		c.String(http.StatusOK, origin.String())
+	})
+
+	testcases := []struct {
+		name       string
+		remoteAddr string
+		header     http.Header
+		want       string
+	}{
+		{
+			name:       "direct",
+			remoteAddr: "127.0.0.1:5000",
+			want:       "http://app.local",
+		},
+		{
+			name:       "forwarded",
+			remoteAddr: "127.0.0.1:5000",
+			header:     http.Header{"Forwarded": {`for=1.2.3.4;proto=https;host="app.example.com"`}},
+			want:       "https://app.example.com",
+		},
+		{
+			name:       "spoofed left-most forwarded",
+			remoteAddr: "127.0.0.1:5000",
+			header:     http.Header{"Forwarded": {"host=evil.com;proto=http, host=app.example.com;proto=https"}},
+			want:       "https://app.example.com",
+		},
+		{
+			name:       "spoofed left-most x-forwarded",
+			remoteAddr: "[::1]:5000",
+			header: http.Header{
+				"X-Forwarded-Proto": {"http, https"},
+				"X-Forwarded-Host":  {"evil.com, app.example.com"},
+			},
+			want: "https://app.example.com",
+		},
+		{
+			name:       "repeated header lines",
+			remoteAddr: "127.0.0.1:5000",
+			header:     http.Header{"X-Forwarded-Host": {"evil.com", "app.example.com:8443"}},
+			want:       "http://app.example.com:8443",
+		},
+		{
+			name:       "forwarded takes precedence",
+			remoteAddr: "127.0.0.1:5000",
+			header: http.Header{
+				"Forwarded":        {"host=app.example.com"},
+				"X-Forwarded-Host": {"other.example.com"},
+			},
+			want: "http://app.example.com",
+		},
+		{
+			name:       "untrusted remote address",
+			remoteAddr: "203.0.113.9:5000",
+			header: http.Header{
+				"Forwarded":         {"host=evil.com;proto=https"},
+				"X-Forwarded-Proto": {"https"},
+				"X-Forwarded-Host":  {"evil.com"},
+			},
+			want: "http://app.local",
+		},
+		{
+			name:       "invalid values",
+			remoteAddr: "127.0.0.1:5000",
+			header: http.Header{
+				"X-Forwarded-Proto": {"javascript"},
+				"X-Forwarded-Host":  {"evil.com/path"},
+			},
+			want: "http://app.local",
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "http://app.local/origin", nil)
+			req.RemoteAddr = tc.remoteAddr
+			for name, values := range tc.header {
+				req.Header[name] = values
+			}
+			w := httptest.NewRecorder()
+			engine.ServeHTTP(w, req)
+			assert.Equal(t, tc.want, w.Body.String())
+		})
+	}
+}
+
+func TestTrustedProxies(t *testing.T) {
+	testcases := []struct {
+		name string
+		conf config.HTTPServer
+		want []string
+	}{
+		{name: "no reverse proxy", conf: config.HTTPServer{TrustedProxies: []string{"10.0.0.1"}, Network: "unix"}},
+		{name: "loopback", conf: config.HTTPServer{ReverseProxy: true, Network: "tcp"}, want: []string{"127.0.0.0/8", "::1"}},
+		{name: "configured", conf: config.HTTPServer{ReverseProxy: true, TrustedProxies: []string{"10.0.0.1"}}, want: []string{"10.0.0.1"}},
+		{name: "unix socket", conf: config.HTTPServer{ReverseProxy: true, TrustedProxies: []string{"10.0.0.1"}, Network: "unix"}, want: []string{"10.0.0.1", SocketPeer}},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.want, TrustedProxies(tc.conf))
+		})
+	}
+}
+
+func TestUnixSocketPeer(t *testing.T) {
+	proxies := TrustedProxies(config.HTTPServer{ReverseProxy: true, TrustedProxies: []string{"10.0.0.1"}, Network: "unix"})
+	trusted, err := ParseProxies(proxies)
+	assert.NoError(t, err)
+
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	assert.NoError(t, engine.SetTrustedProxies(proxies))
+	engine.Use(UnixSocketPeer(), ResolveOrigin(trusted))
+	engine.GET("/origin", func(c *gin.Context) {
+		origin, _ := contextz.GetOrigin(c.Request.Context())
+		c.String(http.StatusOK, c.ClientIP()+" "+origin.String())
+	})
+
+	testcases := []struct {
+		name       string
+		remoteAddr string
+		want       string
+	}{
+		// the peer of a unix socket has no address, it is "@" or empty
+		{name: "unix socket", remoteAddr: "@", want: "203.0.113.7 https://app.example.com"},
+		{name: "unnamed unix socket", remoteAddr: "", want: "203.0.113.7 https://app.example.com"},
+		{name: "tcp peer", remoteAddr: "192.0.2.1:5000", want: "192.0.2.1 http://app.local"},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "http://app.local/origin", nil)
+			req.RemoteAddr = tc.remoteAddr
+			req.Header.Set("X-Forwarded-For", "203.0.113.7")
+			req.Header.Set("X-Forwarded-Proto", "https")
+			req.Header.Set("X-Forwarded-Host", "app.example.com")
+			w := httptest.NewRecorder()
+			engine.ServeHTTP(w, req)
+			assert.Equal(t, tc.want, w.Body.String())
+		})
+	}
+}
+
+func TestHostAllowed(t *testing.T) {
+	hosts := []string{"example.com", "*.example.org", "localhost:8088", "secure.example.net:443", "[::1]:8088"}
+
+	testcases := []struct {
+		scheme string
+		host   string
+		want   bool
+	}{
+		{scheme: "https", host: "example.com", want: true},
+		{scheme: "http", host: "EXAMPLE.com.", want: true},
+		{scheme: "https", host: "example.com:8443", want: true},
+		{scheme: "https", host: "api.example.com", want: false},
+		{scheme: "https", host: "api.example.org", want: true},
+		{scheme: "https", host: "a.b.example.org:443", want: true},
+		{scheme: "https", host: "example.org", want: false},
+		{scheme: "https", host: "example.org.evil.com", want: false},
+		{scheme: "https", host: "evilexample.org", want: false},
+		{scheme: "http", host: "localhost:8088", want: true},
+		{scheme: "http", host: "localhost", want: false},
+		{scheme: "https", host: "secure.example.net", want: true},
+		{scheme: "http", host: "secure.example.net", want: false},
+		{scheme: "http", host: "[::1]:8088", want: true},
+		{scheme: "http", host: "[::1]:8089", want: false},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.scheme+"://"+tc.host, func(t *testing.T) {
+			assert.Equal(t, tc.want, hostAllowed(hosts, contextz.Origin{Scheme: tc.scheme, Host: tc.host}))
+		})
+	}
+}
+
+func TestAllowHosts(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	engine.Use(AllowHosts([]string{"app.example.com"}, SkipWithPathPrefix("/healthz")))
+	engine.GET("/healthz", func(c *gin.Context) { c.Status(http.StatusOK) })
+	engine.GET("/api", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	testcases := []struct {
+		url  string
+		want int
+	}{
+		{url: "http://evil.com/api", want: http.StatusMisdirectedRequest},
+		{url: "http://evil.com/healthz", want: http.StatusOK},
+		{url: "http://app.example.com/api", want: http.StatusOK},
+	}
+	for _, tc := range testcases {
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tc.url, nil))
+		assert.Equal(t, tc.want, w.Code, tc.url)
+	}
+}
@@ -0,0 +1,131 @@
+package middleware
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"demo/config"
+	"demo/extension/contextz"
+	"demo/extension/errorx"
+	"demo/extension/kv"
+	"demo/extension/logz"
+	"demo/extension/ratelimit"
+	"demo/northbound/remote/restful/response"
+)
+
+// rateLimitPolicy 限流策略及其限流器
+type rateLimitPolicy struct {
+	config.RateLimitPolicy
+	limiter *ratelimit.Limiter
+}
+
+// RateLimit 按配置的策略对路由组限流，请求匹配的每个策略分别计数，
+// 并以剩余配额最少的策略设置 RateLimit-* 响应头。超出客户端的配额时返回 ErrRejected，
+// 超出路由的总配额时返回 ErrServerBusy，需在 Authenticate 之后使用以便按主体限流
+func RateLimit(store kv.KV, conf config.RateLimit) (gin.HandlerFunc, error) {
+	policies := make([]rateLimitPolicy, 0, len(conf.Policies))
+	for _, p := range conf.Policies {
+		limiter, err := ratelimit.New(store, ratelimit.Options{
+			Algorithm: p.Algorithm,
+			Limit:     p.Limit,
+			Period:    p.Period,
+			Burst:     p.Burst,
+			Prefix:    "ratelimit:" + p.Name + ":",
+		})
+		if err != nil {
+			return nil, fmt.Errorf("rate limit policy %s: %w", p.Name, err)
+		}
+		policies = append(policies, rateLimitPolicy{RateLimitPolicy: p, limiter: limiter})
+	}
+
+	return func(c *gin.Context) {
+		ctx := c.Request.Context()
+
+		var (
+			tightest ratelimit.Result
+			policy   string
+		)
+		for _, p := range policies {
+			if !p.matches(c) {
+				continue
+			}
+
+			rv, err := p.limiter.Allow(ctx, rateLimitKey(c, p.Key))
+			if err != nil {
+				if !conf.FailOpen {
+					response.Error(c, errorx.ErrServerBusy.Wrap(err))
+					return
+				}
+				logz.Error(ctx, "[ratelimit] rate limit failure", logz.String("policy", p.Name), logz.Err(err))
+				continue
+			}
+			if !rv.Allowed {
+				setRateLimitHeaders(c, p.limiter.Policy(), rv)
+				c.Header("Retry-After", deltaSeconds(rv.RetryAfter))
+				ex := errorx.ErrRejected
+				if p.Key == "route" {
+					ex = errorx.ErrServerBusy
+				}
+				response.Error(c, ex.WithMessageF("rate limit %s exceeded, retry in %ss", p.Name, deltaSeconds(rv.RetryAfter)))
+				return
+			}
+			if policy == "" || rv.Remaining < tightest.Remaining {
+				tightest, policy = rv, p.limiter.Policy()
+			}
+		}
+
+		if policy != "" {
+			setRateLimitHeaders(c, policy, tightest)
+		}
+		c.Next()
+	}, nil
+}
+
+// matches 判断请求是否属于策略的路由组及方法
+func (p rateLimitPolicy) matches(c *gin.Context) bool {
+	path := c.Request.URL.Path
+	if !strings.HasPrefix(path, p.Group) ||
+		len(path) > len(p.Group) && !strings.HasSuffix(p.Group, "/") && path[len(p.Group)] != '/' {
+		return false
+	}
+	if len(p.Methods) == 0 {
+		return true
+	}
+	for _, m := range p.Methods {
+		if strings.EqualFold(m, c.Request.Method) {
+			return true
+		}
+	}
+	return false
+}
+
+// rateLimitKey 返回请求的限流键，匿名请求按 IP 限流
+func rateLimitKey(c *gin.Context, key string) string {
+	switch key {
+	case "principal":
+		if principal, ok := contextz.GetPrincipal(c.Request.Context()); ok && principal.UserId != "" {
+			return "principal:" + principal.Tenant + "/" + principal.UserId
+		}
+	case "route":
+		return "route:" + c.Request.Method + " " + c.FullPath()
+	}
+	return "ip:" + c.ClientIP()
+}
+
+// setRateLimitHeaders 设置 IETF RateLimit 草案的响应头
+func setRateLimitHeaders(c *gin.Context, policy string, rv ratelimit.Result) {
+	c.Header("RateLimit-Policy", policy)
+	c.Header("RateLimit-Limit", strconv.Itoa(rv.Limit))
+	c.Header("RateLimit-Remaining", strconv.Itoa(rv.Remaining))
+	c.Header("RateLimit-Reset", deltaSeconds(rv.Reset))
+}
+
+// deltaSeconds 将时长向上取整为秒
+func deltaSeconds(d time.Duration) string {
+	return strconv.FormatInt(int64(math.Ceil(d.Seconds())), 10)
+}
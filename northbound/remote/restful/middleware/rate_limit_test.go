@@ -0,0 +1,110 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+
+	"demo/config"
+	"demo/extension/errorx"
+	"demo/extension/kv"
+)
+
+// brokenKV fails every operation.
+type brokenKV struct {
+	kv.KV
+}
+
+func (brokenKV) Get(context.Context, string) ([]byte, error) {
+	return nil, errors.New("connection refused")
+}
+
+func (brokenKV) Incr(context.Context, string, time.Duration) (int64, error) {
+	return 0, errors.New("connection refused")
+}
+
+func newRateLimitEngine(t *testing.T, store kv.KV, conf config.RateLimit) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	limit, err := RateLimit(store, conf)
+	assert.NoError(t, err)
+
+	engine := gin.New()
+	engine.Use(limit)
+	engine.GET("/api/login", func(c *gin.Context) { c.Status(http.StatusOK) })
+	engine.GET("/api/orders", func(c *gin.Context) { c.Status(http.StatusOK) })
+	engine.GET("/api/export", func(c *gin.Context) { c.Status(http.StatusOK) })
+	return engine
+}
+
+func get(engine *gin.Engine, path string, header http.Header) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	req.RemoteAddr = "192.0.2.1:5000"
+	for name, values := range header {
+		req.Header[name] = values
+	}
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, req)
+	return w
+}
+
+func TestRateLimit(t *testing.T) {
+	engine := newRateLimitEngine(t, kv.NewMemory(), config.RateLimit{
+		FailOpen: true,
+		Policies: []config.RateLimitPolicy{
+			{Name: "login", Group: "/api/login", Key: "ip", Limit: 2, Period: time.Minute},
+			{Name: "orders", Group: "/api/orders", Key: "principal", Algorithm: "sliding_window", Limit: 1, Period: time.Minute},
+			{Name: "export", Group: "/api/export", Key: "route", Limit: 1, Period: time.Minute},
+		},
+	})
+
+	w := get(engine, "/api/login", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "2;w=60", w.Header().Get("RateLimit-Policy"))
+	assert.Equal(t, "2", w.Header().Get("RateLimit-Limit"))
+	assert.Equal(t, "1", w.Header().Get("RateLimit-Remaining"))
+	assert.Equal(t, "30", w.Header().Get("RateLimit-Reset"))
+	assert.Empty(t, w.Header().Get("Retry-After"))
+
+	assert.Equal(t, http.StatusOK, get(engine, "/api/login", nil).Code)
+	w = get(engine, "/api/login", nil)
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, errorx.ErrRejected.Code, responseCode(t, w))
+	assert.Equal(t, "0", w.Header().Get("RateLimit-Remaining"))
+	assert.Equal(t, "30", w.Header().Get("Retry-After"))
+
+	// the unauthenticated api keys do not get a quota of their own, rotating
+	// them does not escape the limit of the ip
+	assert.Equal(t, http.StatusOK, get(engine, "/api/orders", http.Header{"X-Api-Key": {"k1"}}).Code)
+	for _, key := range []string{"k2", "k3", "k4"} {
+		w = get(engine, "/api/orders", http.Header{"X-Api-Key": {key}})
+		assert.Equal(t, http.StatusTooManyRequests, w.Code, key)
+	}
+
+	// a route limit is shared by all the clients
+	assert.Equal(t, http.StatusOK, get(engine, "/api/export", nil).Code)
+	w = get(engine, "/api/export", nil)
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	assert.Equal(t, errorx.ErrServerBusy.Code, responseCode(t, w))
+	assert.NotEmpty(t, w.Header().Get("Retry-After"))
+}
+
+func TestRateLimitStoreFailure(t *testing.T) {
+	conf := config.RateLimit{
+		Policies: []config.RateLimitPolicy{{Name: "login", Group: "/api/login", Limit: 1, Period: time.Minute}},
+	}
+
+	w := get(newRateLimitEngine(t, brokenKV{}, conf), "/api/login", nil)
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	assert.Equal(t, errorx.ErrServerBusy.Code, responseCode(t, w))
+
+	conf.FailOpen = true
+	w = get(newRateLimitEngine(t, brokenKV{}, conf), "/api/login", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Empty(t, w.Header().Get("RateLimit-Limit"))
+}
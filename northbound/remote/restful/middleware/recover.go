@@ -2,7 +2,6 @@ package middleware
 
 import (
 	"net"
-	"net/http"
 	"net/http/httputil"
 	"os"
 	"runtime/debug"
@@ -11,10 +10,13 @@ import (
 	"github.com/gin-gonic/gin"
 
 	"demo/extension/datetime"
+	"demo/extension/errorx"
 	"demo/extension/logz"
+	"demo/northbound/remote/restful/response"
 )
 
-func Recovery() gin.HandlerFunc {
+// Recovery 从 panic 中恢复并记录请求信息，请求头及查询参数中的敏感数据由 redactor 脱敏
+func Recovery(redactor *logz.Redactor) gin.HandlerFunc {
 	return func(ctx *gin.Context) {
 		defer func() {
 			err := recover()
@@ -22,7 +24,11 @@ func Recovery() gin.HandlerFunc {
 				return
 			}
 
-			httpRequest, _ := httputil.DumpRequest(ctx.Request, false)
+			request := ctx.Request.Clone(ctx.Request.Context())
+			request.Header = redactor.Headers(ctx.Request.Header)
+			request.URL.RawQuery = redactor.Query(request.URL.RawQuery)
+			request.RequestURI = request.URL.RequestURI()
+			httpRequest, _ := httputil.DumpRequest(request, false)
 
 			if isBrokenPipe(err) {
 				logz.Error(ctx, ctx.Request.URL.Path, logz.Any("error", err), logz.Any("request", string(httpRequest)))
@@ -36,7 +42,7 @@ func Recovery() gin.HandlerFunc {
 				logz.Any("request", string(httpRequest)),
 				logz.Any("stack", string(debug.Stack())),
 			)
-			ctx.AbortWithStatus(http.StatusInternalServerError)
+			response.Error(ctx, errorx.ErrInternalServer)
 		}()
 		ctx.Next()
 	}
@@ -0,0 +1,42 @@
+package middleware
+
+import (
+	"bytes"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+
+	"demo/extension/logz"
+)
+
+func TestRecoveryRedactsRequest(t *testing.T) {
+	var logs bytes.Buffer
+	logger := logz.Logger()
+	logz.SetLogger(slog.New(slog.NewJSONHandler(&logs, nil)))
+	t.Cleanup(func() { logz.SetLogger(logger) })
+
+	redactor, err := logz.NewRedactor(logz.RedactOptions{
+		Headers: []string{"Authorization"},
+		Fields:  []string{"code", "state"},
+	})
+	assert.NoError(t, err)
+
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	engine.Use(Recovery(redactor))
+	engine.GET("/oauth2/mock/callback", func(c *gin.Context) { panic("boom") })
+
+	req := httptest.NewRequest(http.MethodGet, "/oauth2/mock/callback?code=secret&state=s1&page=2", nil)
+	req.Header.Set("Authorization", "Bearer secret-token")
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.Contains(t, logs.String(), "/oauth2/mock/callback?code=******&state=******&page=2")
+	assert.NotContains(t, logs.String(), "secret")
+	assert.Equal(t, "code=secret&state=s1&page=2", req.URL.RawQuery, "the request is untouched")
+}
@@ -0,0 +1,20 @@
+package middleware
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"demo/extension/contextz"
+	"demo/extension/propagation"
+)
+
+// RequestId 接收请求头 X-Request-ID 中合法的请求 ID，否则生成新的请求 ID，
+// 并将其存入请求上下文，同时在响应头中返回
+func RequestId() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		requestId := propagation.RequestIdOrNew(c.GetHeader(propagation.RequestIdHeader))
+
+		c.Request = c.Request.WithContext(contextz.WithRequestId(c.Request.Context(), requestId))
+		c.Header(propagation.RequestIdHeader, requestId)
+		c.Next()
+	}
+}
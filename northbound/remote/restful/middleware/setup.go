@@ -5,16 +5,66 @@ import (
 
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
 
 	"demo/config"
+	"demo/extension/logz"
 	"demo/extension/metrics"
 )
 
-func Setup(engine *gin.Engine, conf *config.Schema) {
-	engine.Use(Recovery())
-	engine.Use(Logger(SkipWithPathPrefix("/healthz")))
+func Setup(engine *gin.Engine, conf *config.Schema, registry *prometheus.Registry, slo *metrics.SLO) error {
+	redactor, err := logz.NewRedactor(logz.RedactOptions{
+		Headers:  conf.Log.Redact.Headers,
+		Fields:   conf.Log.Redact.Fields,
+		Patterns: conf.Log.Redact.Patterns,
+		Mask:     conf.Log.Redact.Mask,
+	})
+	if err != nil {
+		return err
+	}
+
+	loggerOpts := LoggerOptions{Redactor: redactor}
+	if sampling := conf.Log.Sampling; sampling.Enable {
+		routes := make(map[string]logz.SamplingOptions, len(sampling.Routes))
+		for _, r := range sampling.Routes {
+			routes[r.Route] = logz.SamplingOptions{Tick: sampling.Tick, First: r.First, Thereafter: r.Thereafter}
+		}
+		loggerOpts.Sampler = logz.NewSampler(RequestSampler, logz.SamplingOptions{
+			Tick:       sampling.Tick,
+			First:      sampling.First,
+			Thereafter: sampling.Thereafter,
+		}, routes)
+	}
+
+	// only the headers of the trusted proxies are used for the client ip and
+	// the origin, gin trusts any proxy by default
+	proxies := TrustedProxies(conf.HTTP)
+	if err := engine.SetTrustedProxies(proxies); err != nil {
+		return err
+	}
+	trusted, err := ParseProxies(proxies)
+	if err != nil {
+		return err
+	}
+
+	if conf.HTTP.Network == "unix" {
+		engine.Use(UnixSocketPeer())
+	}
+	engine.Use(RequestId())
+	engine.Use(ResolveOrigin(trusted))
+	engine.Use(Tracing())
+	engine.Use(Recovery(redactor))
+	engine.Use(Logger(loggerOpts, SkipWithPathPrefix("/healthz", "/readyz")))
 	engine.Use(LogError())
-	metrics.NewPrometheus(conf.Name).Use(engine)
+	engine.Use(AllowHosts(conf.HTTP.Domain, SkipWithPathPrefix("/healthz", "/readyz")))
+
+	// the metrics are served by the admin listener, or under /admin with the
+	// http token, never on the api without authentication
+	prom, err := metrics.NewPrometheus(conf.Name, registry, metricsOptions(conf.Metrics.HTTP, slo))
+	if err != nil {
+		return err
+	}
+	engine.Use(prom.HandlerFunc())
 
 	if conf.CORS.Enable {
 		engine.Use(cors.New(cors.Config{
@@ -28,4 +78,24 @@ func Setup(engine *gin.Engine, conf *config.Schema) {
 			AllowWildcard:    conf.CORS.AllowWildcard,
 		}))
 	}
+
+	return nil
+}
+
+func metricsOptions(conf config.MetricsHTTP, slo *metrics.SLO) metrics.Options {
+	histogram := func(buckets []float64) metrics.HistogramOptions {
+		opts := metrics.HistogramOptions{Buckets: buckets}
+		if conf.Native.Enable {
+			opts.NativeBucketFactor = conf.Native.BucketFactor
+			opts.NativeMaxBuckets = conf.Native.MaxBuckets
+			opts.NativeMinResetDuration = conf.Native.MinResetDuration
+		}
+		return opts
+	}
+	return metrics.Options{
+		Duration:     histogram(conf.DurationBuckets),
+		RequestSize:  histogram(conf.RequestSizeBuckets),
+		ResponseSize: histogram(conf.ResponseSizeBuckets),
+		SLO:          slo,
+	}
 }
@@ -0,0 +1,72 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"demo/extension/errorx"
+	"demo/northbound/remote/restful/response"
+)
+
+// TokenAuth 校验请求头 Authorization: Bearer <token> 是否与指定的静态令牌一致
+func TokenAuth(token string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		provided, ok := bearerToken(c)
+		if !ok {
+			response.Error(c, errorx.ErrTokenRequired)
+			return
+		}
+		if subtle.ConstantTimeCompare([]byte(provided), []byte(token)) != 1 {
+			response.Error(c, errorx.ErrInvalidToken)
+			return
+		}
+		c.Next()
+	}
+}
+
+// bearerToken 从请求头中获取 Bearer 令牌
+func bearerToken(c *gin.Context) (string, bool) {
+	scheme, token, ok := strings.Cut(c.GetHeader("Authorization"), " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") || token == "" {
+		return "", false
+	}
+	return strings.TrimSpace(token), true
+}
+
+// AdminAuth 校验管理端点的静态令牌或 Basic 认证，二者均未配置时不做校验
+func AdminAuth(token, username, password string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if token == "" && username == "" {
+			c.Next()
+			return
+		}
+
+		if provided, ok := bearerToken(c); ok && token != "" {
+			if subtle.ConstantTimeCompare([]byte(provided), []byte(token)) != 1 {
+				response.Error(c, errorx.ErrInvalidToken)
+				return
+			}
+			c.Next()
+			return
+		}
+
+		if user, pass, ok := c.Request.BasicAuth(); ok && username != "" {
+			userOk := subtle.ConstantTimeCompare([]byte(user), []byte(username)) == 1
+			passOk := subtle.ConstantTimeCompare([]byte(pass), []byte(password)) == 1
+			if !userOk || !passOk {
+				c.Header("WWW-Authenticate", `Basic realm="admin"`)
+				response.Error(c, errorx.ErrInvalidToken)
+				return
+			}
+			c.Next()
+			return
+		}
+
+		if username != "" {
+			c.Header("WWW-Authenticate", `Basic realm="admin"`)
+		}
+		response.Error(c, errorx.ErrTokenRequired)
+	}
+}
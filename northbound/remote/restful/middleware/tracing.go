@@ -0,0 +1,46 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"demo/extension/contextz"
+	"demo/extension/tracing"
+)
+
+// Tracing 从请求头 traceparent 中提取上游的追踪上下文，为每个请求创建服务端 span，
+// 并在响应头中返回 traceparent，便于调用方关联
+func Tracing() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+
+		ctx := tracing.Extract(c.Request.Context(), c.Request.Header)
+		ctx, span := tracing.Start(ctx, c.Request.Method+" "+route, tracing.WithSpanKind(tracing.SpanKindServer))
+		defer span.End()
+
+		span.SetAttribute("http.method", c.Request.Method)
+		span.SetAttribute("http.route", route)
+		span.SetAttribute("http.target", c.Request.URL.Path)
+		span.SetAttribute("http.client_ip", c.ClientIP())
+		if requestId := contextz.RequestId(ctx); requestId != "" {
+			span.SetAttribute("request_id", requestId)
+		}
+
+		c.Request = c.Request.WithContext(ctx)
+		c.Header(tracing.TraceparentHeader, span.SpanContext().Traceparent())
+		c.Next()
+
+		status := c.Writer.Status()
+		span.SetAttribute("http.status_code", status)
+		if err := c.Errors.Last(); err != nil {
+			span.SetAttribute("exception.message", err.Error())
+		}
+		if status >= http.StatusInternalServerError {
+			span.SetStatus(tracing.StatusError, http.StatusText(status))
+		}
+	}
+}
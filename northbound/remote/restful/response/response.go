@@ -0,0 +1,95 @@
+package response
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"demo/extension/contextz"
+	"demo/extension/errorx"
+)
+
+// ErrorBody is the response body of a failed request.
+type ErrorBody struct {
+	Code      int    `json:"code"`
+	Reason    string `json:"reason"`
+	Message   string `json:"message,omitempty"`
+	RequestId string `json:"request_id,omitempty"`
+}
+
+// httpStatus maps errorx codes to http status codes, errors not listed here
+// are responded with 500 if they are system faults and 400 otherwise.
+var httpStatus = map[int]int{
+	errorx.ErrIllegalArgument.Code: http.StatusBadRequest,
+	errorx.ErrInvalidParam.Code:    http.StatusBadRequest,
+	errorx.ErrRequestParmas.Code:   http.StatusBadRequest,
+	errorx.ErrMisdirected.Code:     http.StatusMisdirectedRequest,
+
+	errorx.ErrRequiredAuthenticationCode.Code: http.StatusBadRequest,
+	errorx.ErrInvalidAuthenticationState.Code: http.StatusBadRequest,
+	errorx.ErrOAuth2AuthorizationFailed.Code:  http.StatusUnauthorized,
+	errorx.ErrOAuth2UserInfoFailed.Code:       http.StatusBadGateway,
+	errorx.ErrInvalidCaptcha.Code:             http.StatusBadRequest,
+
+	errorx.ErrLoginRequired.Code:      http.StatusUnauthorized,
+	errorx.ErrInvalidSession.Code:     http.StatusUnauthorized,
+	errorx.ErrInvalidAccessToken.Code: http.StatusUnauthorized,
+	errorx.ErrInvalidToken.Code:       http.StatusUnauthorized,
+	errorx.ErrTokenRequired.Code:      http.StatusUnauthorized,
+	errorx.ErrUnauthenticated.Code:    http.StatusUnauthorized,
+
+	errorx.ErrInvalidUsernameOrPassword.Code: http.StatusUnauthorized,
+	errorx.ErrUserDisabled.Code:              http.StatusForbidden,
+
+	errorx.ErrForbidden.Code:    http.StatusForbidden,
+	errorx.ErrUnauthorized.Code: http.StatusForbidden,
+
+	errorx.ErrResourceNotFound.Code:      http.StatusNotFound,
+	errorx.ErrResourceAlreadyExists.Code: http.StatusConflict,
+
+	errorx.ErrRejected.Code:      http.StatusTooManyRequests,
+	errorx.ErrServerBusy.Code:    http.StatusServiceUnavailable,
+	errorx.ErrTaskQueueFull.Code: http.StatusServiceUnavailable,
+
+	errorx.ErrUnknownException.Code: http.StatusInternalServerError,
+	errorx.ErrInternalServer.Code:   http.StatusInternalServerError,
+	errorx.ErrDBOperation.Code:      http.StatusInternalServerError,
+	errorx.ErrUnknown.Code:          http.StatusInternalServerError,
+}
+
+// Error aborts the request and writes err as the response body. The error is
+// attached to the gin context so that middleware.LogError can log it.
+func Error(c *gin.Context, err error) {
+	_ = c.Error(err)
+
+	code, _, reason, message := errorx.Explode(err)
+	status, ok := httpStatus[code]
+	if !ok {
+		status = http.StatusBadRequest
+		if errorx.GetErrorLevel(unwrap(err), errorx.LevelError) >= errorx.LevelError {
+			status = http.StatusInternalServerError
+		}
+	}
+
+	if status >= http.StatusInternalServerError {
+		// do not expose details of system faults
+		message = ""
+	}
+
+	c.AbortWithStatusJSON(status, ErrorBody{
+		Code:      code,
+		Reason:    reason,
+		Message:   message,
+		RequestId: contextz.RequestId(c.Request.Context()),
+	})
+}
+
+// unwrap returns the errorx.Error wrapped in err, or err itself.
+func unwrap(err error) error {
+	var ex errorx.Error
+	if errors.As(err, &ex) {
+		return ex
+	}
+	return err
+}
@@ -0,0 +1,69 @@
+package router
+
+import (
+	"net/http/pprof"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+
+	"demo/config"
+	"demo/extension/logz"
+	"demo/extension/metrics"
+	"demo/northbound/remote/restful/handler"
+	"demo/northbound/remote/restful/middleware"
+)
+
+// RegisterAdmin registers the admin endpoints on the api server under /admin
+// if the admin listener is disabled, they are protected by the static http
+// token and disabled if the token is not configured. The metrics are never
+// served on the api server without the token.
+func RegisterAdmin(conf *config.Schema, engine *gin.Engine, registry *prometheus.Registry, logLevel *handler.LogLevel, cfg *handler.Config) {
+	if conf.Admin.Enable {
+		return
+	}
+	if conf.HTTP.Token == "" {
+		logz.WarnNoCtx("[restful] admin endpoints are disabled because http.token is not configured")
+		return
+	}
+
+	admin := engine.Group("/admin", middleware.TokenAuth(conf.HTTP.Token))
+	admin.GET("/metrics", gin.WrapH(metrics.Handler(registry)))
+	admin.GET("/config", cfg.Get)
+	admin.GET("/log/level", logLevel.Get)
+	admin.PUT("/log/level", logLevel.Set)
+	if conf.Admin.Pprof {
+		registerPprof(admin)
+	}
+}
+
+// RegisterAdminServer registers the endpoints of the admin listener. The
+// health checks are public so that the probes need no credentials.
+func RegisterAdminServer(conf *config.Schema, engine *gin.Engine, registry *prometheus.Registry, health *handler.Health, logLevel *handler.LogLevel, cfg *handler.Config) {
+	RegisterHealth(engine, health)
+
+	if conf.Admin.Token == "" && conf.Admin.Username == "" {
+		logz.WarnNoCtx("[admin] endpoints are not authenticated because neither admin.token nor admin.username is configured")
+	}
+	admin := engine.Group("", middleware.AdminAuth(conf.Admin.Token, conf.Admin.Username, conf.Admin.Password))
+	admin.GET("/metrics", gin.WrapH(metrics.Handler(registry)))
+	admin.GET("/config", cfg.Get)
+	admin.GET("/log/level", logLevel.Get)
+	admin.PUT("/log/level", logLevel.Set)
+
+	if conf.Admin.Pprof {
+		registerPprof(admin)
+	}
+}
+
+func registerPprof(router *gin.RouterGroup) {
+	debug := router.Group("/debug/pprof")
+	debug.GET("/", gin.WrapF(pprof.Index))
+	debug.GET("/cmdline", gin.WrapF(pprof.Cmdline))
+	debug.GET("/profile", gin.WrapF(pprof.Profile))
+	debug.GET("/symbol", gin.WrapF(pprof.Symbol))
+	debug.POST("/symbol", gin.WrapF(pprof.Symbol))
+	debug.GET("/trace", gin.WrapF(pprof.Trace))
+	debug.GET("/:name", func(c *gin.Context) {
+		pprof.Handler(c.Param("name")).ServeHTTP(c.Writer, c.Request)
+	})
+}
@@ -4,11 +4,39 @@ import (
 	"github.com/gin-gonic/gin"
 
 	"demo/config"
-	"demo/northbound/remote/restful/handler"
+	"demo/extension/jwt"
+	"demo/extension/kv"
+	"demo/extension/session"
+	"demo/northbound/remote/restful/middleware"
 )
 
-func NewAPIRouter(conf *config.Schema, engine *gin.Engine) *gin.RouterGroup {
-	engine.GET("/healthz", handler.HealthyHandler)
-
-	return engine.Group(conf.HTTP.APIPrefix)
+// NewAPIRouter returns the group of the api routes. The callers are
+// authenticated by a bearer token or the session cookie, and they must be
+// logged in except for the public paths if the jwt authentication is enabled.
+// The permissions of the authz routes in the config are required as well, and
+// the route groups are rate limited by the policies in the config.
+func NewAPIRouter(conf *config.Schema, engine *gin.Engine, auth *jwt.Authenticator, sessions *session.Manager, store kv.KV) (*gin.RouterGroup, error) {
+	api := engine.Group(conf.HTTP.APIPrefix)
+	opts := middleware.AuthOptions{Sessions: sessions, CookieName: conf.Session.Cookie.Name}
+	if auth != nil {
+		opts.Tokens = auth
+	}
+	api.Use(middleware.Authenticate(opts))
+	if conf.RateLimit.Enable {
+		if conf.RateLimit.Store == "memory" {
+			store = kv.NewMemory()
+		}
+		limit, err := middleware.RateLimit(store, conf.RateLimit)
+		if err != nil {
+			return nil, err
+		}
+		api.Use(limit)
+	}
+	if auth != nil {
+		api.Use(middleware.RequireLogin(middleware.SkipWithPathPrefix(conf.JWT.PublicPaths...)))
+	}
+	if len(conf.Authz.Routes) > 0 {
+		api.Use(middleware.AuthorizeRoutes(conf.Authz.Routes))
+	}
+	return api, nil
 }
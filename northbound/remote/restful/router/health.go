@@ -0,0 +1,13 @@
+package router
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"demo/northbound/remote/restful/handler"
+)
+
+// RegisterHealth registers the liveness and readiness probes.
+func RegisterHealth(engine *gin.Engine, health *handler.Health) {
+	engine.GET("/healthz", health.Live)
+	engine.GET("/readyz", health.Ready)
+}
@@ -0,0 +1,15 @@
+package router
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"demo/northbound/remote/restful/handler"
+)
+
+// RegisterLogin registers the username and password login, the session is
+// kept in a cookie.
+func RegisterLogin(router *gin.RouterGroup, login *handler.Login) {
+	router.GET("/auth/captcha", login.Captcha)
+	router.POST("/auth/login", login.Login)
+	router.POST("/auth/logout", login.Logout)
+}
@@ -0,0 +1,14 @@
+package router
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"demo/northbound/remote/restful/handler"
+)
+
+// RegisterOAuth2 registers the login endpoints of the OAuth2 providers, the
+// callback must match the redirect url configured for the provider.
+func RegisterOAuth2(router *gin.RouterGroup, oauth2 *handler.OAuth2) {
+	router.GET("/auth/oauth2/:provider/login", oauth2.Login)
+	router.GET("/auth/oauth2/:provider/callback", oauth2.Callback)
+}
@@ -4,55 +4,136 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"net"
 	"net/http"
+	"sync"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"go.uber.org/fx"
 
 	"demo/config"
+	"demo/extension/health"
 	"demo/extension/logz"
 )
 
-func run(lc fx.Lifecycle, conf *config.Schema, engine *gin.Engine) {
-	srv := &http.Server{
-		Addr:    fmt.Sprintf("%s:%d", conf.HTTP.Host, conf.HTTP.Port),
-		Handler: engine,
+func run(lc fx.Lifecycle, conf *config.Schema, engine *gin.Engine, h *health.Health) error {
+	srv, err := newServer(conf.HTTP, engine)
+	if err != nil {
+		return err
 	}
+	conns := trackConns(srv)
 
 	lc.Append(fx.Hook{
 		OnStart: func(ctx context.Context) error {
-			go listenAndServe(ctx, srv)
+			ln, err := listen(conf.HTTP, srv)
+			if err != nil {
+				return err
+			}
+			go serve(ctx, "restful", srv, ln)
 			return nil
 		},
 		OnStop: func(ctx context.Context) error {
-			return shutdown(ctx, srv)
+			h.Drain("shutting down")
+			drain(ctx, "restful", conf.Shutdown.DrainPeriod)
+
+			ctx, cancel := context.WithTimeout(ctx, conf.Shutdown.Timeout)
+			defer cancel()
+			return shutdown(ctx, "restful", srv, conns)
 		},
 	})
+	return nil
 }
 
-func listenAndServe(ctx context.Context, srv *http.Server) {
-	logz.Info(ctx, fmt.Sprintf("[restful] listening and serving HTTP on %s", srv.Addr))
-	if err := srv.ListenAndServe(); err != nil {
+func serve(ctx context.Context, name string, srv *http.Server, ln net.Listener) {
+	var err error
+	if srv.TLSConfig != nil {
+		logz.Info(ctx, fmt.Sprintf("[%s] listening and serving HTTPS on %s", name, ln.Addr()))
+		err = srv.ServeTLS(ln, "", "")
+	} else {
+		logz.Info(ctx, fmt.Sprintf("[%s] listening and serving HTTP on %s", name, ln.Addr()))
+		err = srv.Serve(ln)
+	}
+	if err != nil {
 		if errors.Is(err, http.ErrServerClosed) {
-			logz.Info(ctx, "[restful] service graceful shutdown")
+			logz.Info(ctx, "["+name+"] service graceful shutdown")
 			return
 		}
 		logz.Error(
 			ctx,
-			"[restful] service shutdown failure",
+			"["+name+"] service shutdown failure",
 			logz.Any("listen_address", srv.Addr),
 			logz.Any("err", err),
 		)
 	}
 }
 
-func shutdown(ctx context.Context, srv *http.Server) error {
-	logz.Info(ctx, "[restful] received shutdown signal")
+// drain waits for the load balancers to notice the failing readiness.
+func drain(ctx context.Context, name string, period time.Duration) {
+	if period <= 0 {
+		return
+	}
+
+	logz.Info(ctx, "["+name+"] draining before shutdown", logz.String("drain_period", period.String()))
+	timer := time.NewTimer(period)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+	case <-ctx.Done():
+	}
+}
+
+// shutdown stops accepting connections and waits for the in-flight requests
+// until ctx is done, the connections which are still active are closed then.
+func shutdown(ctx context.Context, name string, srv *http.Server, conns *connTracker) error {
+	logz.Info(ctx, "["+name+"] received shutdown signal")
 	if err := srv.Shutdown(ctx); err != nil {
-		logz.Error(ctx, "[restful] an error occurred in server forced to shutdown", logz.Any("err", err))
-		return err
+		active := conns.active()
+		logz.Error(ctx, "["+name+"] an error occurred in server forced to shutdown",
+			logz.Any("err", err),
+			logz.Int("active_connections", len(active)),
+			logz.Any("remote_addrs", active),
+		)
+		return errors.Join(err, srv.Close())
 	}
 
-	logz.Info(ctx, "[restful] service shutdown successfully")
+	logz.Info(ctx, "["+name+"] service shutdown successfully")
 	return nil
 }
+
+// connTracker tracks the states of the connections of a server, to report
+// the connections which are cut at shutdown.
+type connTracker struct {
+	mu    sync.Mutex
+	conns map[net.Conn]http.ConnState
+}
+
+func trackConns(srv *http.Server) *connTracker {
+	t := &connTracker{conns: make(map[net.Conn]http.ConnState)}
+	srv.ConnState = t.track
+	return t
+}
+
+func (t *connTracker) track(conn net.Conn, state http.ConnState) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	switch state {
+	case http.StateClosed, http.StateHijacked:
+		delete(t.conns, conn)
+	default:
+		t.conns[conn] = state
+	}
+}
+
+// active returns the remote addresses of the connections serving a request.
+func (t *connTracker) active() []string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	var addrs []string
+	for conn, state := range t.conns {
+		if state == http.StateActive {
+			addrs = append(addrs, conn.RemoteAddr().String())
+		}
+	}
+	return addrs
+}
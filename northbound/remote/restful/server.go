@@ -0,0 +1,80 @@
+package restful
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+
+	"github.com/gin-gonic/gin"
+
+	"demo/config"
+	"demo/extension/tlsx"
+)
+
+// newServer returns the http server of the api with the timeouts, the limits
+// and the TLS of the config.
+func newServer(conf config.HTTPServer, engine *gin.Engine) (*http.Server, error) {
+	engine.UseH2C = conf.H2C
+	srv := &http.Server{
+		Addr:              fmt.Sprintf("%s:%d", conf.Host, conf.Port),
+		Handler:           engine.Handler(),
+		ReadTimeout:       conf.ReadTimeout,
+		ReadHeaderTimeout: conf.ReadHeaderTimeout,
+		WriteTimeout:      conf.WriteTimeout,
+		IdleTimeout:       conf.IdleTimeout,
+		MaxHeaderBytes:    conf.MaxHeaderBytes,
+	}
+	if conf.Network == "unix" {
+		srv.Addr = conf.Socket
+	}
+
+	nextProtos := []string{"h2", "http/1.1"}
+	if !conf.HTTP2 {
+		// a non-nil map disables the HTTP/2 over TLS
+		srv.TLSNextProto = make(map[string]func(*http.Server, *tls.Conn, http.Handler))
+		nextProtos = []string{"http/1.1"}
+	}
+
+	if conf.TLS.Enable {
+		clientAuth, err := tlsx.ClientAuth(conf.TLS.ClientAuth)
+		if err != nil {
+			return nil, err
+		}
+		minVersion := uint16(tls.VersionTLS12)
+		if conf.TLS.MinVersion == "1.3" {
+			minVersion = tls.VersionTLS13
+		}
+
+		reloader, err := tlsx.NewReloader(tlsx.Options{
+			CertFile:       conf.TLS.CertFile,
+			KeyFile:        conf.TLS.KeyFile,
+			ClientCAFile:   conf.TLS.ClientCAFile,
+			ClientAuth:     clientAuth,
+			MinVersion:     minVersion,
+			NextProtos:     nextProtos,
+			ReloadInterval: conf.TLS.ReloadInterval,
+		})
+		if err != nil {
+			return nil, err
+		}
+		srv.TLSConfig = reloader.Config()
+	}
+	return srv, nil
+}
+
+// listen listens on the address of the server, the stale unix socket of a
+// previous process is removed first.
+func listen(conf config.HTTPServer, srv *http.Server) (net.Listener, error) {
+	if conf.Network != "unix" {
+		return net.Listen("tcp", srv.Addr)
+	}
+
+	if info, err := os.Stat(conf.Socket); err == nil && info.Mode()&os.ModeSocket != 0 {
+		if err := os.Remove(conf.Socket); err != nil {
+			return nil, err
+		}
+	}
+	return net.Listen("unix", conf.Socket)
+}
@@ -0,0 +1,77 @@
+package remote
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"demo/config"
+	"demo/extension/captcha"
+	"demo/extension/contextz"
+	"demo/extension/errorx"
+	"demo/extension/kv"
+	"demo/extension/password"
+	"demo/extension/session"
+	"demo/northbound/remote/restful/handler"
+)
+
+func newKV(conf *config.Schema) (kv.KV, error) {
+	if conf.Session.Store == "file" {
+		return kv.NewFile(conf.Session.Dir)
+	}
+	return kv.NewMemory(), nil
+}
+
+func newSessionManager(conf *config.Schema, store kv.KV) *session.Manager {
+	return session.NewManager(store, session.Options{TTL: conf.Session.TTL})
+}
+
+func newCaptcha(store kv.KV) *captcha.Captcha {
+	return captcha.New(store, captcha.Options{})
+}
+
+// configAccounts are the accounts of the login config.
+type configAccounts struct {
+	users map[string]config.LoginUser
+
+	// dummy is verified for the unknown users, so that they can not be told
+	// apart from the wrong passwords by the response time
+	dummyOnce sync.Once
+	dummy     string
+}
+
+func newAccounts(conf *config.Schema) handler.Accounts {
+	a := &configAccounts{users: make(map[string]config.LoginUser, len(conf.Login.Users))}
+	for _, u := range conf.Login.Users {
+		a.users[u.Username] = u
+	}
+	return a
+}
+
+func (a *configAccounts) Authenticate(_ context.Context, username, pass string) (*contextz.Principal, error) {
+	user, ok := a.users[username]
+	if !ok {
+		a.dummyOnce.Do(func() {
+			a.dummy, _ = password.Hash("")
+		})
+		_ = password.Verify(a.dummy, pass)
+		return nil, errorx.ErrInvalidUsernameOrPassword
+	}
+
+	err := password.Verify(user.Password, pass)
+	if errors.Is(err, password.ErrMismatch) {
+		return nil, errorx.ErrInvalidUsernameOrPassword
+	}
+	if err != nil {
+		return nil, errorx.ErrInternalServer.WithMessageF("password hash of %s is invalid", username).Wrap(err)
+	}
+	if user.Disabled {
+		return nil, errorx.ErrUserDisabled
+	}
+
+	userId := user.UserId
+	if userId == "" {
+		userId = user.Username
+	}
+	return &contextz.Principal{UserId: userId, Tenant: user.Tenant, Roles: user.Roles}, nil
+}
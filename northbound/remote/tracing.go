@@ -0,0 +1,70 @@
+package remote
+
+import (
+	"context"
+	"os"
+
+	"go.uber.org/fx"
+
+	"demo/config"
+	"demo/extension/logz"
+	"demo/extension/tracing"
+)
+
+// tracingModule installs the default tracer after the logger, the spans
+// pending at shutdown are flushed to the exporter.
+var tracingModule = fx.Module("tracing",
+	fx.Invoke(setupTracing),
+)
+
+func setupTracing(lc fx.Lifecycle, conf *config.Schema) error {
+	if !conf.Tracing.Enable {
+		return nil
+	}
+
+	var (
+		exporter tracing.Exporter
+		err      error
+	)
+	switch conf.Tracing.Exporter {
+	case "file":
+		exporter, err = tracing.NewFileExporter(conf.Tracing.File)
+	case "otlp":
+		exporter, err = tracing.NewOTLPExporter(tracing.OTLPOptions{
+			Endpoint: conf.Tracing.OTLP.Endpoint,
+			Headers:  conf.Tracing.OTLP.Headers,
+			Timeout:  conf.Tracing.OTLP.Timeout,
+		})
+	default:
+		exporter = tracing.NewWriterExporter(os.Stdout)
+	}
+	if err != nil {
+		return err
+	}
+
+	serviceName := conf.Tracing.ServiceName
+	if serviceName == "" {
+		serviceName = conf.Name
+	}
+	tracer := tracing.NewTracer(tracing.Options{
+		ServiceName: serviceName,
+		SampleRatio: conf.Tracing.SampleRatio,
+		Exporter:    exporter,
+		Batch: tracing.BatchOptions{
+			QueueSize: conf.Tracing.Batch.QueueSize,
+			BatchSize: conf.Tracing.Batch.BatchSize,
+			Timeout:   conf.Tracing.Batch.Timeout,
+		},
+		ErrorHandler: func(err error) {
+			logz.WarnNoCtx("[tracing] export spans failed", logz.Err(err))
+		},
+	})
+	tracing.SetDefault(tracer)
+
+	lc.Append(fx.Hook{
+		OnStop: func(ctx context.Context) error {
+			return tracer.Shutdown(ctx)
+		},
+	})
+	return nil
+}
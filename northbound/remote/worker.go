@@ -0,0 +1,28 @@
+package remote
+
+import (
+	"context"
+
+	"go.uber.org/fx"
+
+	"demo/config"
+	"demo/extension/worker"
+)
+
+// workerModule provides the group of the background workers such as the cron
+// jobs and the subscribers. It is listed before the restful module so that
+// the workers are stopped after the servers.
+var workerModule = fx.Module("worker",
+	fx.Provide(worker.NewGroup),
+	fx.Invoke(stopWorkers),
+)
+
+func stopWorkers(lc fx.Lifecycle, conf *config.Schema, g *worker.Group) {
+	lc.Append(fx.Hook{
+		OnStop: func(ctx context.Context) error {
+			ctx, cancel := context.WithTimeout(ctx, conf.Shutdown.WorkerTimeout)
+			defer cancel()
+			return g.Stop(ctx)
+		},
+	})
+}